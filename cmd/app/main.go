@@ -15,76 +15,454 @@
 // @host localhost:8080
 // @BasePath /
 // @schemes http
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description API key in the form "Bearer <key>"
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 
 	"github.com/ananikitina/song_lib/config"
 	_ "github.com/ananikitina/song_lib/docs"
+	"github.com/ananikitina/song_lib/internal/auth"
 	"github.com/ananikitina/song_lib/internal/handlers"
+	"github.com/ananikitina/song_lib/internal/health"
+	"github.com/ananikitina/song_lib/internal/identity"
+	"github.com/ananikitina/song_lib/internal/importer"
+	"github.com/ananikitina/song_lib/internal/logging"
+	"github.com/ananikitina/song_lib/internal/metrics"
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/ratelimit"
 	"github.com/ananikitina/song_lib/internal/repository/postgresql"
+	"github.com/ananikitina/song_lib/internal/server"
+	"github.com/ananikitina/song_lib/internal/service"
 	"github.com/ananikitina/song_lib/internal/service/domain"
+	"github.com/ananikitina/song_lib/internal/tenant"
+	"github.com/ananikitina/song_lib/internal/tracing"
+	"github.com/ananikitina/song_lib/internal/workers"
 	"github.com/ananikitina/song_lib/migrations"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
+// Дата, с которой маршруты без версии считаются устаревшими
+var legacyRoutesDeprecatedAt = time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+
 func main() {
-	log := logrus.New()
-	log.Out = os.Stdout
-	log.SetLevel(logrus.DebugLevel)
+	migrateOnly := flag.Bool("migrate-only", false, "apply database migrations and exit")
+	skipMigrations := flag.Bool("skip-migrations", false, "start without applying database migrations")
+	createAdminKey := flag.String("create-admin-key", "", "create an admin API key with the given name, print it and exit")
+	keyTenant := flag.String("tenant", tenant.Default, "tenant of the key created with --create-admin-key or of the songs imported with --import")
+	importFile := flag.String("import", "", "import songs from a CSV or JSON Lines file, print the summary and exit")
+	importFormat := flag.String("import-format", "", "format of the --import file: csv or jsonl; detected from the file extension when empty")
+	importMap := flag.String("import-map", "", "CSV column mapping for --import, e.g. Artist=group,Title=song")
+	importEnrich := flag.Bool("import-enrich", false, "fetch missing fields of imported songs from the external API")
+	importReport := flag.String("import-report", "", "file for rows that were not imported; defaults to <file>.errors.csv")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		log.Fatalf("failed to load config: %v", err)
+		logrus.Fatalf("failed to load config: %v", err)
 	}
 
-	dsn := cfg.DSN()
+	log, err := logging.NewLogger(cfg)
+	if err != nil {
+		logrus.Fatalf("failed to set up logger: %v", err)
+	}
+	log.AddHook(tracing.LogHook{})
 
-	var db *gorm.DB
+	shutdownTracing, err := tracing.Setup(ctx, cfg)
+	if err != nil {
+		log.Fatalf("failed to set up tracing: %v", err)
+	}
+
+	if *migrateOnly && *skipMigrations {
+		log.Fatal("--migrate-only and --skip-migrations are mutually exclusive")
+	}
 
-	db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Silent),
-	})
+	db, err := postgresql.Connect(ctx, cfg, log)
 	if err != nil {
-		log.Errorf("failed to connect to database: %v", err)
+		log.Fatalf("%v", err)
 	}
 
-	if err := migrations.RunMigration(dsn, log); err != nil {
-		log.Errorf("failed to run migrations: %v", err)
+	if *skipMigrations {
+		log.Warn("Skipping database migrations")
+	} else if err := migrations.RunMigration(cfg.DSN(), log); err != nil {
+		log.Fatalf("failed to run migrations: %v", err)
 	}
 
-	songRepository := postgresql.NewSongRepository(db, log)
-	songService := domain.NewSongService(songRepository, log)
-	songHandler := handlers.NewSongHandler(songService, log)
+	if *migrateOnly {
+		closeDB(db, log)
+		return
+	}
 
-	router := gin.Default()
+	backgroundWorkers := workers.NewGroup(log)
+
+	appMetrics := metrics.NewMetrics()
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("failed to get database connection pool: %v", err)
+	}
+	appMetrics.RegisterDBStats(sqlDB)
+
+	externalApiClient := &http.Client{
+		Timeout:   cfg.ExternalApiTimeout,
+		Transport: otelhttp.NewTransport(appMetrics.InstrumentExternalApi(http.DefaultTransport)),
+	}
+
+	songRepository := tracing.InstrumentSongRepository(
+		appMetrics.InstrumentSongRepository(postgresql.NewSongRepository(db, log, cfg.DbQueryTimeout)))
+	creditRepository := appMetrics.InstrumentCreditRepository(postgresql.NewCreditRepository(db, log, cfg.DbQueryTimeout))
+	songService := tracing.InstrumentSongService(domain.NewSongService(songRepository, creditRepository, log, cfg, externalApiClient))
+	changeRequestService := domain.NewChangeRequestService(
+		appMetrics.InstrumentChangeRequestRepository(postgresql.NewChangeRequestRepository(db, log, cfg.DbQueryTimeout)), songService, log)
+	songHandler := handlers.NewSongHandler(songService, changeRequestService, cfg.RequireIfMatch, log)
+	bulkSongService := domain.NewBulkSongService(songService, songRepository, backgroundWorkers, log,
+		cfg.BulkConcurrency, cfg.BulkMaxItems, cfg.BulkMaxAffected, cfg.BulkJobTTL)
+	bulkSongHandler := handlers.NewBulkSongHandler(bulkSongService, cfg.BulkMaxItems, log)
+	importService := domain.NewImportService(songService, songRepository, log, cfg)
+	importHandler := handlers.NewImportHandler(importService, cfg.BulkMaxItems, cfg.ImportTimeout, log)
+	exportHandler := handlers.NewExportHandler(songService, cfg.ExportTimeout, log)
+	changeRequestHandler := handlers.NewChangeRequestHandler(changeRequestService, log)
+	playlistService := domain.NewPlaylistService(
+		appMetrics.InstrumentPlaylistRepository(postgresql.NewPlaylistRepository(db, log, cfg.DbQueryTimeout)), songRepository, log)
+	playlistHandler := handlers.NewPlaylistHandler(playlistService, cfg.RequireIfMatch, log)
+	tagHandler := handlers.NewTagHandler(
+		domain.NewTagService(appMetrics.InstrumentTagRepository(postgresql.NewTagRepository(db, log, cfg.DbQueryTimeout)), songRepository, log), log)
+	creditHandler := handlers.NewCreditHandler(
+		domain.NewCreditService(creditRepository, songRepository, log), log)
+
+	apiKeyService := domain.NewAPIKeyService(appMetrics.InstrumentAPIKeyRepository(postgresql.NewAPIKeyRepository(db, log, cfg.DbQueryTimeout)), log)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService, log)
+	tenantService := domain.NewTenantService(appMetrics.InstrumentTenantRepository(postgresql.NewTenantRepository(db, log, cfg.DbQueryTimeout)), log)
+	tenantHandler := handlers.NewTenantHandler(tenantService, log)
+	authenticator, err := auth.NewAuthenticator(cfg, apiKeyService, tenantService, log)
+	if err != nil {
+		log.Fatalf("failed to set up authentication: %v", err)
+	}
+
+	if *createAdminKey != "" {
+		keyOwner, err := tenantService.GetTenant(ctx, *keyTenant)
+		if err != nil {
+			log.Fatalf("failed to get tenant %s: %v", *keyTenant, err)
+		}
+		key, err := apiKeyService.CreateKey(tenant.WithTenant(ctx, keyOwner), models.CreateAPIKeyRequest{
+			Name:   *createAdminKey,
+			Scopes: []string{models.ScopeAdmin},
+		})
+		if err != nil {
+			log.Fatalf("failed to create admin API key: %v", err)
+		}
+		fmt.Println(key.Key)
+		closeDB(db, log)
+		return
+	}
+
+	if *importFile != "" {
+		owner, err := tenantService.GetTenant(ctx, *keyTenant)
+		if err != nil {
+			log.Fatalf("failed to get tenant %s: %v", *keyTenant, err)
+		}
+		importCtx := identity.WithPrincipal(tenant.WithTenant(ctx, owner), &identity.Principal{
+			Subject: "cli:import",
+			Method:  identity.MethodCLI,
+			Tenant:  owner.ID,
+		})
+		err = importCatalogue(importCtx, importService, *importFile, *importFormat, *importMap, *importEnrich, *importReport, cfg.BulkMaxItems)
+		closeDB(db, log)
+		if err != nil {
+			log.Fatalf("failed to import %s: %v", *importFile, err)
+		}
+		return
+	}
+
+	readLimit, writeLimit := rateLimits(cfg, db, tenantService, backgroundWorkers, log)
+
+	expectedVersion, err := migrations.LatestVersion()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	healthHandler := handlers.NewHealthHandler([]health.Check{
+		health.DatabaseCheck(sqlDB, cfg.HealthCheckTimeout),
+		health.MigrationsCheck(func(ctx context.Context) (uint, bool, error) {
+			return migrations.CurrentVersion(ctx, sqlDB)
+		}, expectedVersion, cfg.HealthCheckTimeout),
+		health.HTTPCheck("external_api", cfg.ExternalApi, http.DefaultClient, cfg.HealthCheckTimeout),
+	}, log)
+
+	router := gin.New()
+	router.Use(
+		logging.RequestIDMiddleware(),
+		otelgin.Middleware(cfg.TracingServiceName, otelgin.WithFilter(tracing.Traced)),
+		logging.AccessLogMiddleware(log),
+		appMetrics.GinMiddleware(),
+		logging.RecoveryMiddleware(log),
+	)
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	// API routes
-	// @Router /add-song [post]
-	router.POST("/add-song", songHandler.AddSongHandler)
-	// @Router /update-song/{id} [put]
-	router.PUT("/update-song/:id", songHandler.UpdateSongHandler)
-	// @Router /delete-song/{id} [delete]
-	router.DELETE("/delete-song/:id", songHandler.DeleteSongHandler)
-	// @Router /songs [get]
-	router.GET("/songs", songHandler.GetAllSongsHandler)
-	// @Router /songs/{id}/verses [get]
-	router.GET("/songs/:id/verses", songHandler.GetSongVersesWithPaginationHandler)
-
-	err = router.Run(":" + cfg.AppPort)
+
+	// Health and build info
+	// @Router /healthz [get]
+	router.GET("/healthz", healthHandler.LivenessHandler)
+	// @Router /readyz [get]
+	router.GET("/readyz", healthHandler.ReadinessHandler)
+	// @Router /version [get]
+	router.GET("/version", healthHandler.VersionHandler)
+	router.GET("/metrics", gin.WrapH(appMetrics.Handler()))
+
+	// API v1
+	v1 := router.Group("/api/v1", authenticator.Identify())
+	// @Router /api/v1/songs [post]
+	v1.POST("/songs", writeLimit, authenticator.Require(models.ScopeSongsWrite), songHandler.AddSongHandler)
+	// @Router /api/v1/songs/bulk [post]
+	v1.POST("/songs/bulk", writeLimit, authenticator.Require(models.ScopeSongsWrite), bulkSongHandler.AddSongsHandler)
+	// @Router /api/v1/songs/import [post]
+	v1.POST("/songs/import", writeLimit, authenticator.Require(models.ScopeSongsWrite), importHandler.ImportSongsHandler)
+	// @Router /api/v1/songs/bulk-update [post]
+	v1.POST("/songs/bulk-update", writeLimit, authenticator.Require(models.ScopeSongsWrite), bulkSongHandler.UpdateSongsHandler)
+	// @Router /api/v1/songs/bulk-delete [post]
+	v1.POST("/songs/bulk-delete", writeLimit, authenticator.Require(models.ScopeSongsDelete), bulkSongHandler.DeleteSongsHandler)
+	// @Router /api/v1/songs/bulk/jobs/{id} [get]
+	v1.GET("/songs/bulk/jobs/:id", readLimit, authenticator.Require(models.ScopeSongsWrite), bulkSongHandler.GetJobHandler)
+	// @Router /api/v1/songs [get]
+	v1.GET("/songs", readLimit, authenticator.Require(models.ScopeSongsRead), songHandler.GetAllSongsHandler)
+	// @Router /api/v1/songs/export [get]
+	v1.GET("/songs/export", readLimit, authenticator.Require(models.ScopeSongsRead), exportHandler.ExportSongsHandler)
+	// @Router /api/v1/songs/{id} [get]
+	v1.GET("/songs/:id", readLimit, authenticator.Require(models.ScopeSongsRead), songHandler.GetSongHandler)
+	// @Router /api/v1/songs/{id} [put]
+	v1.PUT("/songs/:id", writeLimit, authenticator.RequireAny(models.ScopeSongsWrite, models.ScopeChangesPropose), songHandler.ReplaceSongHandler)
+	// @Router /api/v1/songs/{id} [patch]
+	v1.PATCH("/songs/:id", writeLimit, authenticator.RequireAny(models.ScopeSongsWrite, models.ScopeChangesPropose), songHandler.PatchSongHandler)
+	// @Router /api/v1/songs/{id} [delete]
+	v1.DELETE("/songs/:id", writeLimit, authenticator.Require(models.ScopeSongsDelete), songHandler.DeleteSongHandler)
+	// @Router /api/v1/songs/{id}/verses [get]
+	v1.GET("/songs/:id/verses", readLimit, authenticator.Require(models.ScopeSongsRead), songHandler.GetSongVersesWithPaginationHandler)
+	// @Router /api/v1/songs/{id}/tags [get]
+	v1.GET("/songs/:id/tags", readLimit, authenticator.Require(models.ScopeSongsRead), tagHandler.GetSongTagsHandler)
+	// @Router /api/v1/songs/{id}/tags [post]
+	v1.POST("/songs/:id/tags", writeLimit, authenticator.Require(models.ScopeSongsWrite), tagHandler.AddSongTagsHandler)
+	// @Router /api/v1/songs/{id}/tags [put]
+	v1.PUT("/songs/:id/tags", writeLimit, authenticator.Require(models.ScopeSongsWrite), tagHandler.SetSongTagsHandler)
+	// @Router /api/v1/songs/{id}/tags/{tag} [delete]
+	v1.DELETE("/songs/:id/tags/:tag", writeLimit, authenticator.Require(models.ScopeSongsWrite), tagHandler.RemoveSongTagHandler)
+	// @Router /api/v1/songs/{id}/credits [get]
+	v1.GET("/songs/:id/credits", readLimit, authenticator.Require(models.ScopeSongsRead), creditHandler.GetSongCreditsHandler)
+	// @Router /api/v1/songs/{id}/credits [put]
+	v1.PUT("/songs/:id/credits", writeLimit, authenticator.Require(models.ScopeSongsWrite), creditHandler.SetSongCreditsHandler)
+	// @Router /api/v1/tags [get]
+	v1.GET("/tags", readLimit, authenticator.Require(models.ScopeSongsRead), tagHandler.ListTagsHandler)
+
+	// Playlist routes
+	playlists := v1.Group("/playlists")
+	readPlaylists := authenticator.Require(models.ScopeSongsRead)
+	writePlaylists := authenticator.Require(models.ScopePlaylistsWrite)
+	// @Router /api/v1/playlists [post]
+	playlists.POST("", writeLimit, writePlaylists, playlistHandler.CreatePlaylistHandler)
+	// @Router /api/v1/playlists [get]
+	playlists.GET("", readLimit, readPlaylists, playlistHandler.ListPlaylistsHandler)
+	// @Router /api/v1/playlists/{id} [get]
+	playlists.GET("/:id", readLimit, readPlaylists, playlistHandler.GetPlaylistHandler)
+	// @Router /api/v1/playlists/{id} [put]
+	playlists.PUT("/:id", writeLimit, writePlaylists, playlistHandler.UpdatePlaylistHandler)
+	// @Router /api/v1/playlists/{id} [delete]
+	playlists.DELETE("/:id", writeLimit, writePlaylists, playlistHandler.DeletePlaylistHandler)
+	// @Router /api/v1/playlists/{id}/export [get]
+	playlists.GET("/:id/export", readLimit, readPlaylists, playlistHandler.ExportPlaylistHandler)
+	// @Router /api/v1/playlists/{id}/items [post]
+	playlists.POST("/:id/items", writeLimit, writePlaylists, playlistHandler.AddItemHandler)
+	// @Router /api/v1/playlists/{id}/items [put]
+	playlists.PUT("/:id/items", writeLimit, writePlaylists, playlistHandler.ReorderItemsHandler)
+	// @Router /api/v1/playlists/{id}/items/{itemId} [delete]
+	playlists.DELETE("/:id/items/:itemId", writeLimit, writePlaylists, playlistHandler.RemoveItemHandler)
+	// @Router /api/v1/playlists/{id}/items/{itemId}/move [post]
+	playlists.POST("/:id/items/:itemId/move", writeLimit, writePlaylists, playlistHandler.MoveItemHandler)
+
+	// Review routes
+	review := v1.Group("/change-requests")
+	requireReview := authenticator.Require(models.ScopeChangesReview)
+	// @Router /api/v1/change-requests [get]
+	review.GET("", readLimit, requireReview, changeRequestHandler.ListChangeRequestsHandler)
+	// @Router /api/v1/change-requests/{id}/approve [post]
+	review.POST("/:id/approve", writeLimit, requireReview, changeRequestHandler.ApproveChangeRequestHandler)
+	// @Router /api/v1/change-requests/{id}/reject [post]
+	review.POST("/:id/reject", writeLimit, requireReview, changeRequestHandler.RejectChangeRequestHandler)
+
+	// Admin routes
+	admin := v1.Group("/admin")
+	requireAdmin := authenticator.Require(models.ScopeAdmin)
+	// @Router /api/v1/admin/api-keys [post]
+	admin.POST("/api-keys", writeLimit, requireAdmin, apiKeyHandler.CreateKeyHandler)
+	// @Router /api/v1/admin/api-keys [get]
+	admin.GET("/api-keys", readLimit, requireAdmin, apiKeyHandler.ListKeysHandler)
+	// @Router /api/v1/admin/api-keys/{id}/rotate [post]
+	admin.POST("/api-keys/:id/rotate", writeLimit, requireAdmin, apiKeyHandler.RotateKeyHandler)
+	// @Router /api/v1/admin/api-keys/{id} [delete]
+	admin.DELETE("/api-keys/:id", writeLimit, requireAdmin, apiKeyHandler.RevokeKeyHandler)
+
+	// Tenant management for administrators of the default tenant
+	tenants := admin.Group("/tenants")
+	requirePlatformAdmin := authenticator.RequirePlatformAdmin()
+	// @Router /api/v1/admin/tenants [post]
+	tenants.POST("", writeLimit, requireAdmin, requirePlatformAdmin, tenantHandler.CreateTenantHandler)
+	// @Router /api/v1/admin/tenants [get]
+	tenants.GET("", readLimit, requireAdmin, requirePlatformAdmin, tenantHandler.ListTenantsHandler)
+	// @Router /api/v1/admin/tenants/{id} [put]
+	tenants.PUT("/:id", writeLimit, requireAdmin, requirePlatformAdmin, tenantHandler.UpdateTenantHandler)
+
+	// Deprecated unversioned routes, kept until LEGACY_ROUTES_SUNSET
+	if cfg.LegacyRoutesEnabled {
+		deprecated := func(successor string) gin.HandlerFunc {
+			return handlers.Deprecated(successor, legacyRoutesDeprecatedAt, cfg.LegacyRoutesSunset)
+		}
+		legacy := router.Group("", authenticator.Identify())
+		legacy.POST("/add-song", deprecated("/api/v1/songs"), writeLimit, authenticator.Require(models.ScopeSongsWrite), songHandler.LegacyAddSongHandler)
+		legacy.PUT("/update-song/:id", deprecated("/api/v1/songs/:id"), writeLimit, authenticator.RequireAny(models.ScopeSongsWrite, models.ScopeChangesPropose), songHandler.UpdateSongHandler)
+		legacy.DELETE("/delete-song/:id", deprecated("/api/v1/songs/:id"), writeLimit, authenticator.Require(models.ScopeSongsDelete), songHandler.LegacyDeleteSongHandler)
+		legacy.GET("/songs", deprecated("/api/v1/songs"), readLimit, authenticator.Require(models.ScopeSongsRead), songHandler.LegacyGetAllSongsHandler)
+		legacy.GET("/songs/:id/verses", deprecated("/api/v1/songs/:id/verses"), readLimit, authenticator.Require(models.ScopeSongsRead), songHandler.LegacyGetSongVersesHandler)
+
+		legacy.GET("/change-requests", deprecated("/api/v1/change-requests"), readLimit, requireReview, changeRequestHandler.ListChangeRequestsHandler)
+		legacy.POST("/change-requests/:id/approve", deprecated("/api/v1/change-requests/:id/approve"), writeLimit, requireReview, changeRequestHandler.ApproveChangeRequestHandler)
+		legacy.POST("/change-requests/:id/reject", deprecated("/api/v1/change-requests/:id/reject"), writeLimit, requireReview, changeRequestHandler.RejectChangeRequestHandler)
+
+		legacy.POST("/admin/api-keys", deprecated("/api/v1/admin/api-keys"), writeLimit, requireAdmin, apiKeyHandler.CreateKeyHandler)
+		legacy.GET("/admin/api-keys", deprecated("/api/v1/admin/api-keys"), readLimit, requireAdmin, apiKeyHandler.ListKeysHandler)
+		legacy.POST("/admin/api-keys/:id/rotate", deprecated("/api/v1/admin/api-keys/:id/rotate"), writeLimit, requireAdmin, apiKeyHandler.RotateKeyHandler)
+		legacy.DELETE("/admin/api-keys/:id", deprecated("/api/v1/admin/api-keys/:id"), writeLimit, requireAdmin, apiKeyHandler.RevokeKeyHandler)
+
+		legacy.POST("/admin/tenants", deprecated("/api/v1/admin/tenants"), writeLimit, requireAdmin, requirePlatformAdmin, tenantHandler.CreateTenantHandler)
+		legacy.GET("/admin/tenants", deprecated("/api/v1/admin/tenants"), readLimit, requireAdmin, requirePlatformAdmin, tenantHandler.ListTenantsHandler)
+		legacy.PUT("/admin/tenants/:id", deprecated("/api/v1/admin/tenants/:id"), writeLimit, requireAdmin, requirePlatformAdmin, tenantHandler.UpdateTenantHandler)
+	}
+
+	srv := server.NewServer(cfg, router, log)
+	serverErr, err := srv.Start()
 	if err != nil {
 		log.Fatalf("failed to start the server: %v", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		log.Info("Shutdown signal received")
+	case err := <-serverErr:
+		log.Errorf("server stopped unexpectedly: %v", err)
+	}
+	stop()
+
+	// Корректное завершение: запросы, фоновые задачи и соединения с БД
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Errorf("%v", err)
+	}
+	if err := backgroundWorkers.Shutdown(shutdownCtx); err != nil {
+		log.Errorf("failed to stop background workers: %v", err)
+	}
+	closeDB(db, log)
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Errorf("failed to flush traces: %v", err)
+	}
+
+	log.Info("Application stopped")
+}
+
+// Импорт каталога из файла; строки, которые не удалось импортировать, записываются в отчет reportPath
+func importCatalogue(ctx context.Context, importService service.ImportService, path, format, mapping string, enrich bool, reportPath string, maxRows int) error {
+	if format == "" {
+		format = importer.DetectFormat(path)
+	}
+	columns, err := importer.ParseMapping(mapping)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	rows, err := importer.Read(file, format, columns, maxRows)
+	if err != nil {
+		return err
+	}
+
+	report := models.NewImportReport()
+	if err := importService.ImportSongs(ctx, rows, models.ImportOptions{Enrich: enrich}, report.Add); err != nil {
+		return err
+	}
+
+	fmt.Printf("imported %d rows: %v\n", len(rows), report.Summary)
+	if len(report.Rejected) == 0 {
+		return nil
+	}
+
+	if reportPath == "" {
+		reportPath = path + ".errors.csv"
+	}
+	reportFile, err := os.Create(reportPath)
+	if err != nil {
+		return err
+	}
+	if err := importer.WriteReport(reportFile, report.Rejected); err != nil {
+		reportFile.Close()
+		return err
+	}
+	fmt.Printf("%d rows were not imported, see %s\n", len(report.Rejected), reportPath)
+	return reportFile.Close()
+}
+
+// Закрытие пула соединений с базой данных
+func closeDB(db *gorm.DB, log *logrus.Logger) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Errorf("failed to get database connection pool: %v", err)
 		return
 	}
+	if err := sqlDB.Close(); err != nil {
+		log.Errorf("failed to close database connections: %v", err)
+	}
+}
+
+// Middleware ограничения частоты запросов на чтение и запись
+func rateLimits(cfg *config.Config, db *gorm.DB, tenants service.TenantService, group *workers.Group, log *logrus.Logger) (gin.HandlerFunc, gin.HandlerFunc) {
+	if !cfg.RateLimitEnabled {
+		noop := func(c *gin.Context) { c.Next() }
+		return noop, noop
+	}
+
+	var limiter ratelimit.Limiter = ratelimit.NewMemoryLimiter()
+	if cfg.RateLimitBackend == "postgres" {
+		pgLimiter := ratelimit.NewPostgresLimiter(db, log, cfg.DbQueryTimeout)
+		group.Go("rate-limit-cleanup", func(ctx context.Context) {
+			pgLimiter.Cleanup(ctx, time.Minute, time.Hour)
+		})
+		limiter = pgLimiter
+	}
 
-	log.Infof("Server has started on port:%s...", cfg.AppPort)
+	read := ratelimit.Policy{Name: ratelimit.PolicyRead, Rate: cfg.RateLimitReadRate, Burst: cfg.RateLimitReadBurst}
+	write := ratelimit.Policy{Name: ratelimit.PolicyWrite, Rate: cfg.RateLimitWriteRate, Burst: cfg.RateLimitWriteBurst}
+	return ratelimit.Middleware(limiter, read, tenants, log), ratelimit.Middleware(limiter, write, tenants, log)
 }
@@ -3,42 +3,391 @@ package config
 import (
 	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
 )
 
+// Переменная окружения с путем к файлу конфигурации (YAML или TOML).
+// Ключи файла совпадают с именами переменных окружения в нижнем регистре,
+// значения из окружения имеют приоритет над файлом.
+const configFileEnv = "CONFIG_FILE"
+
+// Поддерживаемые теги полей:
+//
+//	env      - имя переменной окружения
+//	default  - значение по умолчанию
+//	required - значение обязательно
+//	oneof    - список допустимых значений через запятую
+//	min, max - границы для целых чисел
+//
+// Даты (time.Time) задаются в формате YYYY-MM-DD или RFC 3339.
 type Config struct {
-	DbHost      string
-	DbPort      string
-	DbUser      string
-	DbPassword  string
-	DbName      string
-	AppPort     string
-	SSLmode     string
-	ExternalApi string
+	DbHost     string `env:"DB_HOST" required:"true"`
+	DbPort     int    `env:"DB_PORT" default:"5432" min:"1" max:"65535"`
+	DbUser     string `env:"DB_USER" required:"true"`
+	DbPassword string `env:"DB_PASSWORD"`
+	DbName     string `env:"DB_NAME" required:"true"`
+	SSLmode    string `env:"SSLMODE" default:"disable" oneof:"disable,allow,prefer,require,verify-ca,verify-full"`
+
+	// Пул соединений с базой данных
+	DbMaxOpenConns    int           `env:"DB_MAX_OPEN_CONNS" default:"10" min:"1"`
+	DbMaxIdleConns    int           `env:"DB_MAX_IDLE_CONNS" default:"5" min:"0"`
+	DbConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME" default:"30m"`
+	DbConnMaxIdleTime time.Duration `env:"DB_CONN_MAX_IDLE_TIME" default:"5m"`
+	DbQueryTimeout    time.Duration `env:"DB_QUERY_TIMEOUT" default:"5s"`
+
+	// Повторные попытки подключения к базе данных при запуске
+	DbConnectRetries    int           `env:"DB_CONNECT_RETRIES" default:"10" min:"0"`
+	DbConnectBackoff    time.Duration `env:"DB_CONNECT_BACKOFF" default:"1s"`
+	DbConnectMaxBackoff time.Duration `env:"DB_CONNECT_MAX_BACKOFF" default:"30s"`
+
+	AppPort   int    `env:"APP_PORT" default:"8080" min:"1" max:"65535"`
+	LogLevel  string `env:"LOG_LEVEL" default:"info" oneof:"panic,fatal,error,warn,info,debug,trace"`
+	LogFormat string `env:"LOG_FORMAT" default:"text" oneof:"text,json"`
+
+	// Таймауты HTTP-сервера и время на корректное завершение
+	HTTPReadTimeout       time.Duration `env:"HTTP_READ_TIMEOUT" default:"15s"`
+	HTTPReadHeaderTimeout time.Duration `env:"HTTP_READ_HEADER_TIMEOUT" default:"5s"`
+	HTTPWriteTimeout      time.Duration `env:"HTTP_WRITE_TIMEOUT" default:"30s"`
+	HTTPIdleTimeout       time.Duration `env:"HTTP_IDLE_TIMEOUT" default:"60s"`
+	ShutdownTimeout       time.Duration `env:"SHUTDOWN_TIMEOUT" default:"20s"`
+	HealthCheckTimeout    time.Duration `env:"HEALTH_CHECK_TIMEOUT" default:"2s"`
+
+	// Аутентификация по API-ключам; чтение можно оставить открытым
+	AuthEnabled    bool `env:"AUTH_ENABLED" default:"true"`
+	AuthPublicRead bool `env:"AUTH_PUBLIC_READ" default:"false"`
+
+	// Проверка JWT от SSO по ключам JWKS (файл или URL)
+	AuthJWKSURL          string        `env:"AUTH_JWKS_URL"`
+	AuthJWKSFile         string        `env:"AUTH_JWKS_FILE"`
+	AuthJWKSCacheTTL     time.Duration `env:"AUTH_JWKS_CACHE_TTL" default:"10m"`
+	AuthJWTIssuer        string        `env:"AUTH_JWT_ISSUER"`
+	AuthJWTAudience      []string      `env:"AUTH_JWT_AUDIENCE"`
+	AuthJWTRolesClaim    string        `env:"AUTH_JWT_ROLES_CLAIM" default:"roles"`
+	AuthJWTRoleMapping   []string      `env:"AUTH_JWT_ROLE_MAPPING"`
+	AuthJWTTenantClaim   string        `env:"AUTH_JWT_TENANT_CLAIM" default:"tenant"`
+	AuthJWTDefaultTenant string        `env:"AUTH_JWT_DEFAULT_TENANT"`
+
+	// Ограничение частоты запросов на клиента (корзина токенов): скорость в запросах в секунду и емкость.
+	// Бэкенд postgres разделяет лимиты между экземплярами приложения.
+	RateLimitEnabled    bool    `env:"RATE_LIMIT_ENABLED" default:"true"`
+	RateLimitBackend    string  `env:"RATE_LIMIT_BACKEND" default:"memory" oneof:"memory,postgres"`
+	RateLimitReadRate   float64 `env:"RATE_LIMIT_READ_RATE" default:"10"`
+	RateLimitReadBurst  int     `env:"RATE_LIMIT_READ_BURST" default:"20" min:"1"`
+	RateLimitWriteRate  float64 `env:"RATE_LIMIT_WRITE_RATE" default:"1"`
+	RateLimitWriteBurst int     `env:"RATE_LIMIT_WRITE_BURST" default:"5" min:"1"`
+
+	// Трассировка OpenTelemetry
+	TracingExporter     string  `env:"TRACING_EXPORTER" default:"none" oneof:"none,stdout,otlp"`
+	TracingOTLPEndpoint string  `env:"TRACING_OTLP_ENDPOINT" default:"localhost:4318"`
+	TracingOTLPInsecure bool    `env:"TRACING_OTLP_INSECURE" default:"false"`
+	TracingSampleRatio  float64 `env:"TRACING_SAMPLE_RATIO" default:"1"`
+	TracingServiceName  string  `env:"TRACING_SERVICE_NAME" default:"song_lib"`
+
+	// Устаревшие маршруты без версии и дата их отключения
+	LegacyRoutesEnabled bool      `env:"LEGACY_ROUTES_ENABLED" default:"true"`
+	LegacyRoutesSunset  time.Time `env:"LEGACY_ROUTES_SUNSET" default:"2027-06-30"`
+
+	// Требовать заголовок If-Match при изменении и удалении песен
+	RequireIfMatch bool `env:"REQUIRE_IF_MATCH" default:"false"`
+
+	ExternalApi        string        `env:"EXTERNAL_API" required:"true"`
+	ExternalApiTimeout time.Duration `env:"EXTERNAL_API_TIMEOUT" default:"10s"`
+
+	// Пакетные операции: одновременные запросы к внешнему API, размер пакета, время хранения
+	// результатов фоновых заданий и предел числа песен, затрагиваемых изменением или удалением
+	BulkConcurrency int           `env:"BULK_CONCURRENCY" default:"8" min:"1"`
+	BulkMaxItems    int           `env:"BULK_MAX_ITEMS" default:"5000" min:"1"`
+	BulkJobTTL      time.Duration `env:"BULK_JOB_TTL" default:"1h"`
+	BulkMaxAffected int           `env:"BULK_MAX_AFFECTED" default:"100" min:"1"`
+
+	// Ограничение времени выгрузки и импорта библиотеки
+	ExportTimeout time.Duration `env:"EXPORT_TIMEOUT" default:"10m"`
+	ImportTimeout time.Duration `env:"IMPORT_TIMEOUT" default:"10m"`
+
+	// Действия при нарушении правил валидации: ignore, flag, reject
+	ValidateReleaseDate string `env:"VALIDATE_RELEASE_DATE" default:"flag" oneof:"ignore,flag,reject"`
+	ValidateText        string `env:"VALIDATE_TEXT" default:"flag" oneof:"ignore,flag,reject"`
+	ValidateLink        string `env:"VALIDATE_LINK" default:"flag" oneof:"ignore,flag,reject"`
+}
+
+// Ошибка конфигурации со списком всех найденных проблем
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
 }
 
+// Загрузка конфигурации: значения по умолчанию, файл конфигурации, .env и переменные окружения
 func LoadConfig() (*Config, error) {
 	if err := godotenv.Load(); err != nil {
 		log.Printf("No .env file found")
 	}
 
-	config := &Config{
-		DbHost:      os.Getenv("DB_HOST"),
-		DbPort:      os.Getenv("DB_PORT"),
-		DbUser:      os.Getenv("DB_USER"),
-		DbPassword:  os.Getenv("DB_PASSWORD"),
-		DbName:      os.Getenv("DB_NAME"),
-		AppPort:     os.Getenv("APP_PORT"),
-		SSLmode:     os.Getenv("SSLMODE"),
-		ExternalApi: os.Getenv("EXTERNAL_API"),
+	fileValues, err := loadFile(os.Getenv(configFileEnv))
+	if err != nil {
+		return nil, err
+	}
+
+	lookup := func(key string) (string, bool) {
+		if value, ok := os.LookupEnv(key); ok {
+			return value, true
+		}
+		value, ok := fileValues[strings.ToLower(key)]
+		return value, ok
+	}
+
+	config := &Config{}
+	problems := unknownFileKeys(fileValues)
+	problems = append(problems, populate(config, lookup)...)
+	problems = append(problems, config.validate()...)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
 	}
 
 	return config, nil
 }
 
+// Чтение файла конфигурации в плоский набор строковых значений
+func loadFile(path string) (map[string]string, error) {
+	values := make(map[string]string)
+	if path == "" {
+		return values, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	raw := make(map[string]interface{})
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported config file format %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	for key, value := range raw {
+		if list, ok := value.([]interface{}); ok {
+			items := make([]string, len(list))
+			for i, item := range list {
+				items[i] = fmt.Sprint(item)
+			}
+			value = strings.Join(items, ",")
+		}
+		if t, ok := value.(time.Time); ok {
+			value = t.Format(time.RFC3339)
+		}
+		values[strings.ToLower(key)] = fmt.Sprint(value)
+	}
+	return values, nil
+}
+
+// Ключи файла конфигурации, которым не соответствует ни одно поле: опечатка в ключе
+// иначе молча оставила бы значение по умолчанию
+func unknownFileKeys(values map[string]string) []string {
+	known := make(map[string]bool)
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		if key := t.Field(i).Tag.Get("env"); key != "" {
+			known[strings.ToLower(key)] = true
+		}
+	}
+
+	var problems []string
+	for key := range values {
+		if !known[key] {
+			problems = append(problems, fmt.Sprintf("%s: unknown config file key %q", configFileEnv, key))
+		}
+	}
+	slices.Sort(problems)
+	return problems
+}
+
+// Заполнение полей конфигурации по тегам; возвращает список проблем
+func populate(config *Config, lookup func(string) (string, bool)) []string {
+	var problems []string
+
+	v := reflect.ValueOf(config).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("env")
+		if key == "" {
+			continue
+		}
+
+		value, ok := lookup(key)
+		value = strings.TrimSpace(value)
+		if !ok || value == "" {
+			if field.Tag.Get("required") == "true" {
+				problems = append(problems, fmt.Sprintf("%s is required", key))
+				continue
+			}
+			value = field.Tag.Get("default")
+		}
+		if value == "" {
+			continue
+		}
+
+		if err := setField(v.Field(i), field, value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+		}
+	}
+
+	return problems
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// Разбор значения в тип поля с проверкой ограничений из тегов
+func setField(v reflect.Value, field reflect.StructField, value string) error {
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		if d < 0 {
+			return fmt.Errorf("duration must not be negative, got %s", d)
+		}
+		v.SetInt(int64(d))
+
+	case v.Type() == timeType:
+		t, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			if t, err = time.Parse(time.RFC3339, value); err != nil {
+				return fmt.Errorf("invalid date %q, expected YYYY-MM-DD or RFC 3339", value)
+			}
+		}
+		v.Set(reflect.ValueOf(t))
+
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		if limit, ok := field.Tag.Lookup("min"); ok {
+			if min, _ := strconv.Atoi(limit); n < min {
+				return fmt.Errorf("must be at least %d, got %d", min, n)
+			}
+		}
+		if limit, ok := field.Tag.Lookup("max"); ok {
+			if max, _ := strconv.Atoi(limit); n > max {
+				return fmt.Errorf("must be at most %d, got %d", max, n)
+			}
+		}
+		v.SetInt(int64(n))
+
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		v.SetBool(b)
+
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		v.SetFloat(f)
+
+	case v.Kind() == reflect.String:
+		if oneof, ok := field.Tag.Lookup("oneof"); ok {
+			value = strings.ToLower(value)
+			if !contains(strings.Split(oneof, ","), value) {
+				return fmt.Errorf("must be one of [%s], got %q", oneof, value)
+			}
+		}
+		v.SetString(value)
+
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+
+	return nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Проверки, затрагивающие несколько полей или формат значений
+func (cfg *Config) validate() []string {
+	var problems []string
+
+	if cfg.ExternalApi != "" {
+		if u, err := url.Parse(cfg.ExternalApi); err != nil || u.Scheme == "" || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("EXTERNAL_API: must be an absolute URL, got %q", cfg.ExternalApi))
+		}
+	}
+	if cfg.AuthJWKSURL != "" && cfg.AuthJWKSFile != "" {
+		problems = append(problems, "AUTH_JWKS_URL and AUTH_JWKS_FILE are mutually exclusive")
+	}
+	if cfg.TracingSampleRatio < 0 || cfg.TracingSampleRatio > 1 {
+		problems = append(problems, fmt.Sprintf("TRACING_SAMPLE_RATIO: must be between 0 and 1, got %g", cfg.TracingSampleRatio))
+	}
+	if cfg.RateLimitReadRate <= 0 {
+		problems = append(problems, fmt.Sprintf("RATE_LIMIT_READ_RATE: must be positive, got %g", cfg.RateLimitReadRate))
+	}
+	if cfg.RateLimitWriteRate <= 0 {
+		problems = append(problems, fmt.Sprintf("RATE_LIMIT_WRITE_RATE: must be positive, got %g", cfg.RateLimitWriteRate))
+	}
+	if cfg.DbMaxIdleConns > cfg.DbMaxOpenConns {
+		problems = append(problems, fmt.Sprintf("DB_MAX_IDLE_CONNS (%d) must not exceed DB_MAX_OPEN_CONNS (%d)", cfg.DbMaxIdleConns, cfg.DbMaxOpenConns))
+	}
+
+	return problems
+}
+
 func (cfg *Config) DSN() string {
-	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
-		cfg.DbHost, cfg.DbUser, cfg.DbPassword, cfg.DbName, cfg.DbPort, cfg.SSLmode)
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s",
+		dsnValue(cfg.DbHost), dsnValue(cfg.DbUser), dsnValue(cfg.DbPassword), dsnValue(cfg.DbName), cfg.DbPort, dsnValue(cfg.SSLmode))
+}
+
+// Значение строки подключения key=value в кавычках: пробелы, кавычки и обратные косые черты
+// не разрывают значение и не добавляют параметров
+func dsnValue(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// Адрес HTTP-сервера
+func (cfg *Config) Addr() string {
+	return fmt.Sprintf(":%d", cfg.AppPort)
 }
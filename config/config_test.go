@@ -0,0 +1,439 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+)
+
+// Обязательные значения конфигурации
+var requiredValues = map[string]string{
+	"DB_HOST":      "db",
+	"DB_USER":      "song_lib",
+	"DB_NAME":      "songs",
+	"EXTERNAL_API": "http://music.example.com",
+}
+
+// Источник значений для populate: обязательные значения и values поверх них
+func lookupFrom(values map[string]string) func(string) (string, bool) {
+	merged := make(map[string]string, len(requiredValues)+len(values))
+	for key, value := range requiredValues {
+		merged[key] = value
+	}
+	for key, value := range values {
+		merged[key] = value
+	}
+	return func(key string) (string, bool) {
+		value, ok := merged[key]
+		return value, ok
+	}
+}
+
+func TestPopulateDefaults(t *testing.T) {
+	cfg := &Config{}
+	if problems := populate(cfg, lookupFrom(nil)); len(problems) > 0 {
+		t.Fatalf("populate() problems = %v", problems)
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{name: "int", got: cfg.DbPort, want: 5432},
+		{name: "string", got: cfg.LogFormat, want: "text"},
+		{name: "duration", got: cfg.DbQueryTimeout, want: 5 * time.Second},
+		{name: "bool true", got: cfg.AuthEnabled, want: true},
+		{name: "bool false", got: cfg.AuthPublicRead, want: false},
+		{name: "float", got: cfg.RateLimitReadRate, want: 10.0},
+		{name: "date", got: cfg.LegacyRoutesSunset, want: time.Date(2027, time.June, 30, 0, 0, 0, 0, time.UTC)},
+		{name: "list without default", got: cfg.AuthJWTAudience, want: []string(nil)},
+		{name: "string without default", got: cfg.DbPassword, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %#v, want %#v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPopulateValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]string
+		check  func(cfg *Config) bool
+	}{
+		{
+			name:   "trimmed int",
+			values: map[string]string{"DB_PORT": " 6432 "},
+			check:  func(cfg *Config) bool { return cfg.DbPort == 6432 },
+		},
+		{
+			name:   "oneof in any case",
+			values: map[string]string{"LOG_LEVEL": "DEBUG"},
+			check:  func(cfg *Config) bool { return cfg.LogLevel == "debug" },
+		},
+		{
+			name:   "empty value falls back to default",
+			values: map[string]string{"LOG_FORMAT": " "},
+			check:  func(cfg *Config) bool { return cfg.LogFormat == "text" },
+		},
+		{
+			name:   "list",
+			values: map[string]string{"AUTH_JWT_AUDIENCE": "song-lib, ,admin,"},
+			check:  func(cfg *Config) bool { return slices.Equal(cfg.AuthJWTAudience, []string{"song-lib", "admin"}) },
+		},
+		{
+			name:   "RFC 3339 date",
+			values: map[string]string{"LEGACY_ROUTES_SUNSET": "2027-01-02T03:04:05Z"},
+			check: func(cfg *Config) bool {
+				return cfg.LegacyRoutesSunset.Equal(time.Date(2027, time.January, 2, 3, 4, 5, 0, time.UTC))
+			},
+		},
+		{
+			name:   "min and max bounds are inclusive",
+			values: map[string]string{"DB_PORT": "65535", "DB_MAX_IDLE_CONNS": "0"},
+			check:  func(cfg *Config) bool { return cfg.DbPort == 65535 && cfg.DbMaxIdleConns == 0 },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{}
+			if problems := populate(cfg, lookupFrom(tt.values)); len(problems) > 0 {
+				t.Fatalf("populate() problems = %v", problems)
+			}
+			if !tt.check(cfg) {
+				t.Errorf("populate() = %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestPopulateProblems(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]string
+		want   []string
+	}{
+		{
+			name:   "required",
+			values: map[string]string{"DB_HOST": "", "EXTERNAL_API": " "},
+			want:   []string{"DB_HOST is required", "EXTERNAL_API is required"},
+		},
+		{
+			name:   "oneof",
+			values: map[string]string{"LOG_FORMAT": "xml"},
+			want:   []string{`LOG_FORMAT: must be one of [text,json], got "xml"`},
+		},
+		{
+			name:   "min",
+			values: map[string]string{"DB_PORT": "0"},
+			want:   []string{"DB_PORT: must be at least 1, got 0"},
+		},
+		{
+			name:   "max",
+			values: map[string]string{"APP_PORT": "65536"},
+			want:   []string{"APP_PORT: must be at most 65535, got 65536"},
+		},
+		{
+			name:   "invalid values",
+			values: map[string]string{"DB_PORT": "five", "AUTH_ENABLED": "sometimes", "DB_QUERY_TIMEOUT": "5", "RATE_LIMIT_READ_RATE": "fast", "LEGACY_ROUTES_SUNSET": "next year"},
+			want: []string{
+				`DB_PORT: invalid integer "five"`,
+				`DB_QUERY_TIMEOUT: invalid duration "5"`,
+				`AUTH_ENABLED: invalid boolean "sometimes"`,
+				`RATE_LIMIT_READ_RATE: invalid number "fast"`,
+				`LEGACY_ROUTES_SUNSET: invalid date "next year", expected YYYY-MM-DD or RFC 3339`,
+			},
+		},
+		{
+			name:   "negative duration",
+			values: map[string]string{"SHUTDOWN_TIMEOUT": "-1s"},
+			want:   []string{"SHUTDOWN_TIMEOUT: duration must not be negative, got -1s"},
+		},
+		{
+			name:   "all problems reported in field order",
+			values: map[string]string{"DB_HOST": "", "DB_PORT": "0", "LOG_LEVEL": "loud", "EXTERNAL_API": ""},
+			want: []string{
+				"DB_HOST is required",
+				"DB_PORT: must be at least 1, got 0",
+				`LOG_LEVEL: must be one of [panic,fatal,error,warn,info,debug,trace], got "loud"`,
+				"EXTERNAL_API is required",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := populate(&Config{}, lookupFrom(tt.values))
+			if !slices.Equal(problems, tt.want) {
+				t.Errorf("populate() problems =\n%s\nwant\n%s", strings.Join(problems, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]string
+		want   []string
+	}{
+		{name: "valid", values: nil, want: nil},
+		{
+			name:   "cross-field problems",
+			values: map[string]string{"EXTERNAL_API": "music.example.com", "AUTH_JWKS_URL": "https://sso.example.com/jwks", "AUTH_JWKS_FILE": "jwks.json", "DB_MAX_OPEN_CONNS": "2", "DB_MAX_IDLE_CONNS": "3"},
+			want: []string{
+				`EXTERNAL_API: must be an absolute URL, got "music.example.com"`,
+				"AUTH_JWKS_URL and AUTH_JWKS_FILE are mutually exclusive",
+				"DB_MAX_IDLE_CONNS (3) must not exceed DB_MAX_OPEN_CONNS (2)",
+			},
+		},
+		{
+			name:   "ranges",
+			values: map[string]string{"TRACING_SAMPLE_RATIO": "1.5", "RATE_LIMIT_READ_RATE": "0", "RATE_LIMIT_WRITE_RATE": "-1"},
+			want: []string{
+				"TRACING_SAMPLE_RATIO: must be between 0 and 1, got 1.5",
+				"RATE_LIMIT_READ_RATE: must be positive, got 0",
+				"RATE_LIMIT_WRITE_RATE: must be positive, got -1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{}
+			if problems := populate(cfg, lookupFrom(tt.values)); len(problems) > 0 {
+				t.Fatalf("populate() problems = %v", problems)
+			}
+			if problems := cfg.validate(); !slices.Equal(problems, tt.want) {
+				t.Errorf("validate() =\n%s\nwant\n%s", strings.Join(problems, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+// Переменные окружения теста: обязательные значения и values (пустое значение удаляет переменную);
+// остальные переменные конфигурации удаляются, чтобы окружение запуска тестов не влияло на результат
+func setConfigEnv(t *testing.T, values map[string]string) {
+	t.Helper()
+	for _, field := range reflect.VisibleFields(reflect.TypeOf(Config{})) {
+		if key := field.Tag.Get("env"); key != "" {
+			t.Setenv(key, "")
+			os.Unsetenv(key)
+		}
+	}
+	t.Setenv(configFileEnv, "")
+	for key, value := range requiredValues {
+		t.Setenv(key, value)
+	}
+	for key, value := range values {
+		t.Setenv(key, value)
+		if value == "" {
+			os.Unsetenv(key)
+		}
+	}
+}
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		data  string
+		env   map[string]string
+		check func(cfg *Config) bool
+	}{
+		{
+			name: "yaml values over defaults",
+			file: "config.yaml",
+			data: "db_port: 6432\nlog_format: json\nauth_jwt_audience: [song-lib, admin]\nlegacy_routes_sunset: 2027-01-31\n",
+			check: func(cfg *Config) bool {
+				return cfg.DbPort == 6432 && cfg.LogFormat == "json" && slices.Equal(cfg.AuthJWTAudience, []string{"song-lib", "admin"}) &&
+					cfg.LegacyRoutesSunset.Equal(time.Date(2027, time.January, 31, 0, 0, 0, 0, time.UTC))
+			},
+		},
+		{
+			name: "toml values over defaults",
+			file: "config.toml",
+			data: "db_port = 6432\nlog_format = \"json\"\nrate_limit_read_rate = 2.5\n",
+			check: func(cfg *Config) bool {
+				return cfg.DbPort == 6432 && cfg.LogFormat == "json" && cfg.RateLimitReadRate == 2.5
+			},
+		},
+		{
+			name:  "environment over file",
+			file:  "config.yaml",
+			data:  "db_port: 6432\nlog_format: json\n",
+			env:   map[string]string{"DB_PORT": "7432"},
+			check: func(cfg *Config) bool { return cfg.DbPort == 7432 && cfg.LogFormat == "json" },
+		},
+		{
+			name:  "required value from file",
+			file:  "config.yml",
+			data:  "db_host: db-from-file\n",
+			env:   map[string]string{"DB_HOST": ""},
+			check: func(cfg *Config) bool { return cfg.DbHost == "db-from-file" },
+		},
+		{
+			name:  "keys in any case",
+			file:  "config.yaml",
+			data:  "DB_PORT: 6432\n",
+			check: func(cfg *Config) bool { return cfg.DbPort == 6432 },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setConfigEnv(t, tt.env)
+			t.Setenv(configFileEnv, writeConfigFile(t, tt.file, tt.data))
+
+			cfg, err := LoadConfig()
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			if !tt.check(cfg) {
+				t.Errorf("LoadConfig() = %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestLoadConfigProblems(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data string
+		env  map[string]string
+		want []string
+	}{
+		{
+			name: "unknown file keys",
+			file: "config.yaml",
+			data: "db_prot: 6432\nlog_format: json\nlog_levle: debug\n",
+			want: []string{
+				`CONFIG_FILE: unknown config file key "db_prot"`,
+				`CONFIG_FILE: unknown config file key "log_levle"`,
+			},
+		},
+		{
+			name: "file, environment and cross-field problems together",
+			file: "config.toml",
+			data: "db_port = 0\ntracing_sample_ratio = 2\nretries = 3\n",
+			env:  map[string]string{"DB_NAME": "", "LOG_FORMAT": "xml"},
+			want: []string{
+				`CONFIG_FILE: unknown config file key "retries"`,
+				"DB_PORT: must be at least 1, got 0",
+				"DB_NAME is required",
+				`LOG_FORMAT: must be one of [text,json], got "xml"`,
+				"TRACING_SAMPLE_RATIO: must be between 0 and 1, got 2",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setConfigEnv(t, tt.env)
+			t.Setenv(configFileEnv, writeConfigFile(t, tt.file, tt.data))
+
+			_, err := LoadConfig()
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("LoadConfig() error = %v, want *ValidationError", err)
+			}
+			if !slices.Equal(validationErr.Problems, tt.want) {
+				t.Errorf("LoadConfig() problems =\n%s\nwant\n%s", strings.Join(validationErr.Problems, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestLoadConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		path func(t *testing.T) string
+		want string
+	}{
+		{name: "missing file", path: func(t *testing.T) string { return filepath.Join(t.TempDir(), "missing.yaml") }, want: "failed to read config file"},
+		{name: "unsupported format", path: func(t *testing.T) string { return writeConfigFile(t, "config.json", "{}") }, want: `unsupported config file format ".json"`},
+		{name: "malformed file", path: func(t *testing.T) string { return writeConfigFile(t, "config.yaml", "db_port: [6432") }, want: "failed to parse config file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setConfigEnv(t, nil)
+			t.Setenv(configFileEnv, tt.path(t))
+
+			_, err := LoadConfig()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadConfig() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExampleConfigFile(t *testing.T) {
+	values, err := loadFile("config.example.yaml")
+	if err != nil {
+		t.Fatalf("loadFile() error = %v", err)
+	}
+	if problems := unknownFileKeys(values); len(problems) > 0 {
+		t.Errorf("example config file has unknown keys: %v", problems)
+	}
+}
+
+func TestDSNQuotesValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+	}{
+		{"empty", ""},
+		{"space", "pass word"},
+		{"quote", "it's"},
+		{"backslash", `back\slash`},
+		{"parameter injection", "x sslmode=require"},
+		{"quote and backslash", `\'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{DbHost: "db", DbPort: 5432, DbUser: "song lib", DbPassword: tt.password, DbName: "songs", SSLmode: "disable"}
+
+			// Строку подключения разбирают оба драйвера: lib/pq для миграций и pgx для GORM
+			parsed, err := pgconn.ParseConfig(cfg.DSN())
+			if err != nil {
+				t.Fatalf("pgconn.ParseConfig(%q): %v", cfg.DSN(), err)
+			}
+			if parsed.Password != tt.password || parsed.User != "song lib" || parsed.Database != "songs" {
+				t.Errorf("pgx parsed user %q, password %q, database %q", parsed.User, parsed.Password, parsed.Database)
+			}
+			if parsed.TLSConfig != nil {
+				t.Errorf("pgx parsed sslmode other than disable from %q", cfg.DSN())
+			}
+
+			if _, err := pq.NewConnector(cfg.DSN()); err != nil {
+				t.Errorf("pq.NewConnector(%q): %v", cfg.DSN(), err)
+			}
+		})
+	}
+}
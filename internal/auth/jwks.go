@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"golang.org/x/sync/singleflight"
+)
+
+// Набор ключей не удалось загрузить: токен нельзя проверить, но он может быть действительным
+var ErrKeysUnavailable = errors.New("signing keys are unavailable")
+
+// Минимальный интервал между обновлениями набора ключей: и по истечении TTL, и при
+// неизвестном kid, чтобы поддельные токены и недоступный IdP не создавали нагрузку
+const minRefreshInterval = 30 * time.Second
+
+// Кэшируемый набор публичных ключей JWKS из файла или по URL
+type keySet struct {
+	url    string
+	file   string
+	ttl    time.Duration
+	client *http.Client
+
+	// Одновременные обновления объединяются в одну загрузку вне блокировки mu
+	refreshes singleflight.Group
+
+	mu          sync.Mutex
+	keys        jose.JSONWebKeySet
+	fetchedAt   time.Time
+	refreshedAt time.Time
+	// Ошибка последнего обновления; nil, если оно удалось
+	refreshErr error
+}
+
+func newKeySet(url, file string, ttl time.Duration, client *http.Client) *keySet {
+	return &keySet{
+		url:    url,
+		file:   file,
+		ttl:    ttl,
+		client: client,
+	}
+}
+
+func (s *keySet) fetch(ctx context.Context) (jose.JSONWebKeySet, error) {
+	var (
+		keys jose.JSONWebKeySet
+		data []byte
+		err  error
+	)
+
+	if s.file != "" {
+		data, err = os.ReadFile(s.file)
+	} else {
+		data, err = s.download(ctx)
+	}
+	if err != nil {
+		return keys, err
+	}
+
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return keys, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+	return keys, nil
+}
+
+func (s *keySet) download(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: unexpected status code: %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// Обновление набора ключей; при ошибке сохраняются ранее загруженные ключи.
+// Загрузка не зависит от отмены запроса, который ее начал: ее результат ждут и другие запросы.
+func (s *keySet) refresh(ctx context.Context) error {
+	_, err, _ := s.refreshes.Do("refresh", func() (interface{}, error) {
+		keys, err := s.fetch(context.WithoutCancel(ctx))
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.refreshedAt = time.Now()
+		s.refreshErr = err
+		if err != nil {
+			return nil, err
+		}
+		s.keys = keys
+		s.fetchedAt = s.refreshedAt
+		return nil, nil
+	})
+	return err
+}
+
+// Ключи для проверки подписи с заданным kid (все ключи, если kid пуст).
+// Если обновление не удалось, используются ранее загруженные ключи. Если ключа нет,
+// а последнее обновление не удалось, возвращается ErrKeysUnavailable, иначе ErrInvalidToken.
+func (s *keySet) lookup(ctx context.Context, kid string) ([]jose.JSONWebKey, error) {
+	s.mu.Lock()
+	keys := s.find(kid)
+	refresh := (len(keys) == 0 || time.Since(s.fetchedAt) > s.ttl) && time.Since(s.refreshedAt) >= minRefreshInterval
+	s.mu.Unlock()
+
+	if refresh {
+		if err := s.refresh(ctx); err == nil {
+			s.mu.Lock()
+			keys = s.find(kid)
+			s.mu.Unlock()
+		}
+	}
+	if len(keys) > 0 {
+		return keys, nil
+	}
+
+	s.mu.Lock()
+	refreshErr := s.refreshErr
+	s.mu.Unlock()
+	if refreshErr != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeysUnavailable, refreshErr)
+	}
+	return nil, fmt.Errorf("%w: no key found for kid %q", ErrInvalidToken, kid)
+}
+
+func (s *keySet) find(kid string) []jose.JSONWebKey {
+	if kid == "" {
+		return s.keys.Keys
+	}
+	return s.keys.Key(kid)
+}
@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+// Сервер JWKS, считающий загрузки; при failing отвечает 500
+type jwksServer struct {
+	*httptest.Server
+	hits    atomic.Int32
+	failing atomic.Bool
+	delay   time.Duration
+}
+
+func newJWKSServer(t *testing.T, set jose.JSONWebKeySet, delay time.Duration) *jwksServer {
+	t.Helper()
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &jwksServer{delay: delay}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.hits.Add(1)
+		time.Sleep(s.delay)
+		if s.failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func testKeySet(t *testing.T) jose.JSONWebKeySet {
+	t.Helper()
+	key := newTestKey(t)
+	return jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: testKid, Algorithm: string(jose.ES256)}}}
+}
+
+// Сдвиг времени последнего обновления, чтобы следующее не ограничивалось minRefreshInterval
+func expireRefresh(s *keySet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fetchedAt = s.fetchedAt.Add(-2 * minRefreshInterval)
+	s.refreshedAt = s.refreshedAt.Add(-2 * minRefreshInterval)
+}
+
+func TestKeySetConcurrentLookupsFetchOnce(t *testing.T) {
+	server := newJWKSServer(t, testKeySet(t), 50*time.Millisecond)
+	keys := newKeySet(server.URL, "", time.Hour, server.Client())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := keys.lookup(context.Background(), testKid); err != nil {
+				t.Errorf("lookup: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if hits := server.hits.Load(); hits != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", hits)
+	}
+}
+
+func TestKeySetServesStaleKeysAfterFailedRefresh(t *testing.T) {
+	server := newJWKSServer(t, testKeySet(t), 0)
+	keys := newKeySet(server.URL, "", time.Minute, server.Client())
+
+	if _, err := keys.lookup(context.Background(), testKid); err != nil {
+		t.Fatalf("lookup: %v", err)
+	}
+
+	server.failing.Store(true)
+	expireRefresh(keys)
+	found, err := keys.lookup(context.Background(), testKid)
+	if err != nil || len(found) != 1 {
+		t.Fatalf("lookup after failed refresh = %d keys, %v; want the stale key", len(found), err)
+	}
+	if hits := server.hits.Load(); hits != 2 {
+		t.Errorf("JWKS fetched %d times, want 2", hits)
+	}
+
+	// Неудачное обновление тоже ограничивает частоту следующих
+	if _, err := keys.lookup(context.Background(), testKid); err != nil {
+		t.Fatalf("lookup: %v", err)
+	}
+	if hits := server.hits.Load(); hits != 2 {
+		t.Errorf("JWKS fetched %d times after failed refresh, want 2", hits)
+	}
+}
+
+func TestKeySetThrottlesRefreshes(t *testing.T) {
+	server := newJWKSServer(t, testKeySet(t), 0)
+	// TTL меньше minRefreshInterval: обновления по TTL тоже ограничиваются
+	keys := newKeySet(server.URL, "", time.Millisecond, server.Client())
+
+	if _, err := keys.lookup(context.Background(), testKid); err != nil {
+		t.Fatalf("lookup: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	for i := 0; i < 5; i++ {
+		if _, err := keys.lookup(context.Background(), "unknown"); err == nil {
+			t.Error("lookup of unknown kid succeeded")
+		}
+		if _, err := keys.lookup(context.Background(), testKid); err != nil {
+			t.Errorf("lookup: %v", err)
+		}
+	}
+
+	if hits := server.hits.Load(); hits != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", hits)
+	}
+}
+
+func TestKeySetRefreshIgnoresCallerCancellation(t *testing.T) {
+	server := newJWKSServer(t, testKeySet(t), 0)
+	keys := newKeySet(server.URL, "", time.Hour, server.Client())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := keys.lookup(ctx, testKid); err != nil {
+		t.Errorf("lookup with canceled context: %v", err)
+	}
+}
+
+func TestKeySetUnavailableIsNotInvalidToken(t *testing.T) {
+	server := newJWKSServer(t, testKeySet(t), 0)
+	server.failing.Store(true)
+	keys := newKeySet(server.URL, "", time.Hour, server.Client())
+
+	// Ошибка загрузки сохраняется и для запросов, не обновлявших ключи из-за ограничения частоты
+	for i := 0; i < 2; i++ {
+		_, err := keys.lookup(context.Background(), testKid)
+		if !errors.Is(err, ErrKeysUnavailable) || errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("lookup with failing JWKS = %v, want ErrKeysUnavailable", err)
+		}
+	}
+
+	server.failing.Store(false)
+	expireRefresh(keys)
+	if _, err := keys.lookup(context.Background(), testKid); err != nil {
+		t.Fatalf("lookup after recovery: %v", err)
+	}
+	if _, err := keys.lookup(context.Background(), "unknown"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("lookup of unknown kid = %v, want ErrInvalidToken", err)
+	}
+}
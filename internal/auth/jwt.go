@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+
+	"github.com/ananikitina/song_lib/internal/identity"
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// Допустимое расхождение часов при проверке exp, nbf и iat
+const clockLeeway = time.Minute
+
+var signatureAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+}
+
+// Проверка JWT по ключам JWKS без обращения к провайдеру идентификации
+type jwtVerifier struct {
+	keys        *keySet
+	issuer      string
+	audience    []string
+	rolesClaim  []string
+	roleScopes  map[string][]string
+	tenantClaim string
+	// Арендатор токенов без утверждения tenantClaim; пустой - такие токены отклоняются
+	defaultTenant string
+}
+
+// Разбор соответствия ролей из токена ролям или областям доступа приложения:
+// "sso-role=editor" или "sso-role=songs:read+songs:write"
+func parseRoleMapping(mapping []string) (map[string][]string, error) {
+	roleScopes := make(map[string][]string, len(mapping))
+	for _, item := range mapping {
+		role, targets, found := strings.Cut(item, "=")
+		if !found || role == "" || targets == "" {
+			return nil, fmt.Errorf("invalid role mapping %q, expected role=target+target", item)
+		}
+		for _, target := range strings.Split(targets, "+") {
+			if scopes, ok := models.RoleScopes[target]; ok {
+				roleScopes[role] = append(roleScopes[role], scopes...)
+			} else {
+				roleScopes[role] = append(roleScopes[role], target)
+			}
+		}
+	}
+	return roleScopes, nil
+}
+
+func (v *jwtVerifier) verify(ctx context.Context, raw string) (*identity.Principal, error) {
+	token, err := jwt.ParseSigned(raw, signatureAlgorithms)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	var kid string
+	if len(token.Headers) > 0 {
+		kid = token.Headers[0].KeyID
+	}
+	keys, err := v.keys.lookup(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		claims jwt.Claims
+		custom map[string]interface{}
+	)
+	verified := false
+	for _, key := range keys {
+		if err = token.Claims(key.Key, &claims, &custom); err == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, fmt.Errorf("%w: signature verification failed", ErrInvalidToken)
+	}
+
+	if claims.Expiry == nil || claims.Subject == "" {
+		return nil, fmt.Errorf("%w: exp and sub claims are required", ErrInvalidToken)
+	}
+	expected := jwt.Expected{
+		Issuer:      v.issuer,
+		AnyAudience: v.audience,
+		Time:        time.Now(),
+	}
+	if err := claims.ValidateWithLeeway(expected, clockLeeway); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	tenantID, _ := custom[v.tenantClaim].(string)
+	if tenantID == "" {
+		if v.defaultTenant == "" {
+			return nil, fmt.Errorf("%w: %s claim is required", ErrInvalidToken, v.tenantClaim)
+		}
+		tenantID = v.defaultTenant
+	}
+
+	return &identity.Principal{
+		Subject: claims.Subject,
+		Method:  identity.MethodJWT,
+		Scopes:  v.scopes(custom),
+		Tenant:  tenantID,
+	}, nil
+}
+
+// Области доступа по ролям из утверждения токена (например, realm_access.roles)
+func (v *jwtVerifier) scopes(claims map[string]interface{}) []string {
+	var value interface{} = claims
+	for _, part := range v.rolesClaim {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[part]
+	}
+
+	var roles []string
+	switch value := value.(type) {
+	case string:
+		roles = strings.Fields(value)
+	case []interface{}:
+		for _, role := range value {
+			if role, ok := role.(string); ok {
+				roles = append(roles, role)
+			}
+		}
+	}
+
+	var scopes []string
+	for _, role := range roles {
+		scopes = append(scopes, v.roleScopes[role]...)
+	}
+	return scopes
+}
@@ -0,0 +1,225 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+
+	"github.com/ananikitina/song_lib/internal/identity"
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+const (
+	testIssuer   = "https://sso.example.com"
+	testAudience = "song-lib"
+	testKid      = "key-1"
+)
+
+// Ключ для подписи тестовых токенов
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// Файл JWKS с публичными ключами по kid
+func writeJWKS(t *testing.T, path string, keys map[string]*ecdsa.PrivateKey) {
+	t.Helper()
+	var set jose.JSONWebKeySet
+	for kid, key := range keys {
+		set.Keys = append(set.Keys, jose.JSONWebKey{Key: &key.PublicKey, KeyID: kid, Algorithm: string(jose.ES256), Use: "sig"})
+	}
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func signToken(t *testing.T, key *ecdsa.PrivateKey, kid string, claims jwt.Claims, custom map[string]interface{}) string {
+	t.Helper()
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", kid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := jwt.Signed(signer).Claims(claims).Claims(custom).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func validClaims() jwt.Claims {
+	now := time.Now()
+	return jwt.Claims{
+		Issuer:   testIssuer,
+		Subject:  "alice",
+		Audience: jwt.Audience{testAudience},
+		IssuedAt: jwt.NewNumericDate(now),
+		Expiry:   jwt.NewNumericDate(now.Add(time.Hour)),
+	}
+}
+
+func newTestVerifier(t *testing.T, key *ecdsa.PrivateKey) *jwtVerifier {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, file, map[string]*ecdsa.PrivateKey{testKid: key})
+
+	roleScopes, err := parseRoleMapping([]string{"library-editor=editor", "library-auditor=songs:read+changes:review"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &jwtVerifier{
+		keys:        newKeySet("", file, time.Hour, nil),
+		issuer:      testIssuer,
+		audience:    []string{testAudience},
+		rolesClaim:  []string{"realm_access", "roles"},
+		roleScopes:  roleScopes,
+		tenantClaim: "tenant",
+	}
+}
+
+func TestVerifyValidToken(t *testing.T) {
+	key := newTestKey(t)
+	v := newTestVerifier(t, key)
+
+	raw := signToken(t, key, testKid, validClaims(), map[string]interface{}{
+		"tenant":       "radio-one",
+		"realm_access": map[string]interface{}{"roles": []string{"library-editor", "unknown"}},
+	})
+	principal, err := v.verify(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	if principal.Subject != "alice" || principal.Method != identity.MethodJWT || principal.Tenant != "radio-one" {
+		t.Errorf("principal = %+v", principal)
+	}
+	if !slices.Equal(principal.Scopes, models.RoleScopes[models.RoleEditor]) {
+		t.Errorf("scopes = %v, want %v", principal.Scopes, models.RoleScopes[models.RoleEditor])
+	}
+}
+
+func TestVerifyRoleMapping(t *testing.T) {
+	key := newTestKey(t)
+	v := newTestVerifier(t, key)
+
+	tests := []struct {
+		name  string
+		roles interface{}
+		want  []string
+	}{
+		{"role", []string{"library-editor"}, models.RoleScopes[models.RoleEditor]},
+		{"scopes", []string{"library-auditor"}, []string{models.ScopeSongsRead, models.ScopeChangesReview}},
+		{"space separated", "library-auditor other", []string{models.ScopeSongsRead, models.ScopeChangesReview}},
+		{"unmapped", []string{"other"}, nil},
+		{"missing", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			custom := map[string]interface{}{"tenant": "radio-one"}
+			if tt.roles != nil {
+				custom["realm_access"] = map[string]interface{}{"roles": tt.roles}
+			}
+			principal, err := v.verify(context.Background(), signToken(t, key, testKid, validClaims(), custom))
+			if err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			if !slices.Equal(principal.Scopes, tt.want) {
+				t.Errorf("scopes = %v, want %v", principal.Scopes, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectedTokens(t *testing.T) {
+	key := newTestKey(t)
+	other := newTestKey(t)
+	v := newTestVerifier(t, key)
+	tenantClaim := map[string]interface{}{"tenant": "radio-one"}
+
+	expired := validClaims()
+	expired.IssuedAt = jwt.NewNumericDate(time.Now().Add(-2 * time.Hour))
+	expired.Expiry = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+
+	wrongAudience := validClaims()
+	wrongAudience.Audience = jwt.Audience{"other-service"}
+
+	wrongIssuer := validClaims()
+	wrongIssuer.Issuer = "https://evil.example.com"
+
+	noSubject := validClaims()
+	noSubject.Subject = ""
+
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"expired", signToken(t, key, testKid, expired, tenantClaim)},
+		{"wrong audience", signToken(t, key, testKid, wrongAudience, tenantClaim)},
+		{"wrong issuer", signToken(t, key, testKid, wrongIssuer, tenantClaim)},
+		{"missing subject", signToken(t, key, testKid, noSubject, tenantClaim)},
+		{"unknown kid", signToken(t, key, "key-2", validClaims(), tenantClaim)},
+		{"wrong key", signToken(t, other, testKid, validClaims(), tenantClaim)},
+		{"missing tenant", signToken(t, key, testKid, validClaims(), nil)},
+		{"malformed", "not.a.token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := v.verify(context.Background(), tt.raw)
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("verify = %+v, %v; want ErrInvalidToken", principal, err)
+			}
+		})
+	}
+}
+
+func TestVerifyDefaultTenant(t *testing.T) {
+	key := newTestKey(t)
+	v := newTestVerifier(t, key)
+	v.defaultTenant = "radio-one"
+
+	principal, err := v.verify(context.Background(), signToken(t, key, testKid, validClaims(), nil))
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if principal.Tenant != "radio-one" {
+		t.Errorf("tenant = %q, want %q", principal.Tenant, "radio-one")
+	}
+
+	principal, err = v.verify(context.Background(), signToken(t, key, testKid, validClaims(), map[string]interface{}{"tenant": "radio-two"}))
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if principal.Tenant != "radio-two" {
+		t.Errorf("tenant = %q, want the claim %q", principal.Tenant, "radio-two")
+	}
+}
+
+func TestParseRoleMappingInvalid(t *testing.T) {
+	for _, mapping := range []string{"editor", "=editor", "role=", ""} {
+		if _, err := parseRoleMapping([]string{mapping}); err == nil || !strings.Contains(err.Error(), "invalid role mapping") {
+			t.Errorf("parseRoleMapping(%q) error = %v", mapping, err)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/config"
+	"github.com/ananikitina/song_lib/internal/identity"
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/service"
+	"github.com/ananikitina/song_lib/internal/service/domain"
+	"github.com/ananikitina/song_lib/internal/tenant"
+)
+
+type Authenticator struct {
+	apiKeys    service.APIKeyService
+	tenants    service.TenantService
+	jwt        *jwtVerifier
+	logger     *logrus.Logger
+	enabled    bool
+	publicRead bool
+}
+
+func NewAuthenticator(cfg *config.Config, apiKeys service.APIKeyService, tenants service.TenantService, logger *logrus.Logger) (*Authenticator, error) {
+	a := &Authenticator{
+		apiKeys:    apiKeys,
+		tenants:    tenants,
+		logger:     logger,
+		enabled:    cfg.AuthEnabled,
+		publicRead: cfg.AuthPublicRead,
+	}
+
+	// Проверка JWT включается, если задан источник JWKS
+	if cfg.AuthJWKSURL != "" || cfg.AuthJWKSFile != "" {
+		roleScopes, err := parseRoleMapping(cfg.AuthJWTRoleMapping)
+		if err != nil {
+			return nil, err
+		}
+		a.jwt = &jwtVerifier{
+			keys:          newKeySet(cfg.AuthJWKSURL, cfg.AuthJWKSFile, cfg.AuthJWKSCacheTTL, &http.Client{Timeout: 10 * time.Second}),
+			issuer:        cfg.AuthJWTIssuer,
+			audience:      cfg.AuthJWTAudience,
+			rolesClaim:    strings.Split(cfg.AuthJWTRolesClaim, "."),
+			roleScopes:    roleScopes,
+			tenantClaim:   cfg.AuthJWTTenantClaim,
+			defaultTenant: cfg.AuthJWTDefaultTenant,
+		}
+	}
+
+	return a, nil
+}
+
+// Разбор заголовка Authorization: Bearer <token>
+func bearerToken(c *gin.Context) (string, bool) {
+	header := c.GetHeader("Authorization")
+	if header == "" {
+		return "", false
+	}
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// Ключ gin.Context с ошибкой аутентификации, чтобы Require не проверял учетные данные повторно
+const authErrorKey = "auth.error"
+
+// Аутентификация клиента; результат сохраняется в контексте запроса
+func (a *Authenticator) authenticate(c *gin.Context) (*identity.Principal, error) {
+	if principal := identity.PrincipalFromContext(c.Request.Context()); principal != nil {
+		return principal, nil
+	}
+	if err, ok := c.Get(authErrorKey); ok {
+		return nil, err.(error)
+	}
+
+	token, ok := bearerToken(c)
+	if !ok {
+		return nil, nil
+	}
+
+	var principal *identity.Principal
+	if a.jwt != nil && !domain.IsAPIKey(token) {
+		var err error
+		if principal, err = a.jwt.verify(c.Request.Context(), token); err != nil {
+			c.Set(authErrorKey, err)
+			return nil, err
+		}
+	} else {
+		apiKey, err := a.apiKeys.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			c.Set(authErrorKey, err)
+			return nil, err
+		}
+		principal = &identity.Principal{
+			Subject: fmt.Sprintf("api-key:%d", apiKey.ID),
+			Method:  identity.MethodAPIKey,
+			Scopes:  apiKey.Scopes,
+			Tenant:  apiKey.TenantID,
+		}
+	}
+
+	c.Request = c.Request.WithContext(identity.WithPrincipal(c.Request.Context(), principal))
+	return principal, nil
+}
+
+// Администратор арендатора по умолчанию управляет всеми арендаторами
+func isPlatformAdmin(principal *identity.Principal) bool {
+	return principal.Tenant == tenant.Default && principal.HasScope(models.ScopeAdmin)
+}
+
+// Определение арендатора запроса: по клиенту, а для анонимных запросов и
+// администраторов платформы - по заголовку X-Tenant-ID
+func (a *Authenticator) resolveTenant(c *gin.Context, principal *identity.Principal) bool {
+	if tenant.FromContext(c.Request.Context()) != nil {
+		return true
+	}
+
+	requested := strings.TrimSpace(c.GetHeader(tenant.Header))
+	id := tenant.Default
+	switch {
+	case principal == nil:
+		if requested != "" {
+			id = requested
+		}
+	case requested == "" || requested == principal.Tenant:
+		id = principal.Tenant
+	case isPlatformAdmin(principal):
+		id = requested
+	default:
+		a.logger.WithContext(c.Request.Context()).Warnf("resolveTenant: %s of tenant %s requested tenant %s", principal.Subject, principal.Tenant, requested)
+		abort(c, http.StatusForbidden, fmt.Sprintf("access to tenant %s denied", requested))
+		return false
+	}
+
+	t, err := a.tenants.GetTenant(c.Request.Context(), id)
+	switch {
+	case errors.Is(err, domain.ErrTenantNotFound):
+		abort(c, http.StatusBadRequest, fmt.Sprintf("unknown tenant %s", id))
+		return false
+	case err != nil:
+		a.logger.WithContext(c.Request.Context()).Errorf("resolveTenant: failed to get tenant %s: %v", id, err)
+		abort(c, http.StatusInternalServerError, "failed to resolve tenant")
+		return false
+	}
+
+	c.Request = c.Request.WithContext(tenant.WithTenant(c.Request.Context(), t))
+	return true
+}
+
+// Middleware, определяющий клиента до ограничения частоты запросов, чтобы лимит считался по нему.
+// Запрос не прерывается: клиент с неверными учетными данными ограничивается как анонимный
+// и отклоняется следующим Require.
+func (a *Authenticator) Identify() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if a.enabled {
+			if _, err := a.authenticate(c); err != nil {
+				a.logger.WithContext(c.Request.Context()).Debugf("Identify: failed to authenticate request: %v", err)
+			}
+		}
+		c.Next()
+	}
+}
+
+// Middleware для управления арендаторами; идет после Require(admin)
+func (a *Authenticator) RequirePlatformAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !a.enabled {
+			c.Next()
+			return
+		}
+		principal := identity.PrincipalFromContext(c.Request.Context())
+		if principal == nil || !isPlatformAdmin(principal) {
+			abort(c, http.StatusForbidden, "platform administrator required")
+			return
+		}
+		c.Next()
+	}
+}
+
+func abort(c *gin.Context, status int, message string) {
+	if status == http.StatusUnauthorized {
+		c.Header("WWW-Authenticate", `Bearer realm="song_lib"`)
+	}
+	c.AbortWithStatusJSON(status, gin.H{"error": message})
+}
+
+// Middleware, требующий области доступа scope.
+// Чтение может быть открыто для анонимных клиентов настройкой AUTH_PUBLIC_READ.
+func (a *Authenticator) Require(scope string) gin.HandlerFunc {
+	return a.RequireAny(scope)
+}
+
+// Middleware, требующий хотя бы одну из областей доступа
+func (a *Authenticator) RequireAny(scopes ...string) gin.HandlerFunc {
+	publicRead := false
+	for _, scope := range scopes {
+		publicRead = publicRead || (scope == models.ScopeSongsRead && a.publicRead)
+	}
+
+	return func(c *gin.Context) {
+		if !a.enabled {
+			if a.resolveTenant(c, nil) {
+				c.Next()
+			}
+			return
+		}
+
+		principal, err := a.authenticate(c)
+		switch {
+		case errors.Is(err, domain.ErrInvalidAPIKey), errors.Is(err, ErrInvalidToken):
+			a.logger.WithContext(c.Request.Context()).Debugf("Require: rejected credentials: %v", err)
+			abort(c, http.StatusUnauthorized, "invalid credentials")
+			return
+		case errors.Is(err, ErrKeysUnavailable):
+			// Токен может быть действительным: клиент должен повторить запрос, а не сбросить токен
+			a.logger.WithContext(c.Request.Context()).Errorf("Require: failed to verify token: %v", err)
+			c.Header("Retry-After", strconv.Itoa(int(minRefreshInterval.Seconds())))
+			abort(c, http.StatusServiceUnavailable, "identity provider is unavailable")
+			return
+		case err != nil:
+			a.logger.WithContext(c.Request.Context()).Errorf("Require: failed to authenticate request: %v", err)
+			abort(c, http.StatusInternalServerError, "failed to authenticate request")
+			return
+		case principal == nil:
+			if publicRead && a.resolveTenant(c, nil) {
+				c.Next()
+				return
+			}
+			if c.IsAborted() {
+				return
+			}
+			abort(c, http.StatusUnauthorized, "authentication required")
+			return
+		case !principal.HasAnyScope(scopes...):
+			a.logger.WithContext(c.Request.Context()).Warnf("Require: %s lacks scope %s", principal.Subject, strings.Join(scopes, " or "))
+			abort(c, http.StatusForbidden, fmt.Sprintf("scope %s required", strings.Join(scopes, " or ")))
+			return
+		}
+
+		if a.resolveTenant(c, principal) {
+			c.Next()
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/service"
+	"github.com/ananikitina/song_lib/internal/service/domain"
+)
+
+type APIKeyHandler struct {
+	apiKeyService service.APIKeyService
+	logger        *logrus.Logger
+}
+
+func NewAPIKeyHandler(apiKeyService service.APIKeyService, logger *logrus.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+		logger:        logger,
+	}
+}
+
+func (h *APIKeyHandler) log(c *gin.Context) *logrus.Entry {
+	return h.logger.WithContext(c.Request.Context())
+}
+
+func (h *APIKeyHandler) parseKeyID(c *gin.Context) (uint, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		h.log(c).Debugf("parseKeyID: invalid key ID: %v", err)
+		return 0, err
+	}
+	return uint(id), nil
+}
+
+// HTTP-статус для ошибок работы с ключами
+func apiKeyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrAPIKeyNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrAPIKeyRevoked):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrUnknownScope), errors.Is(err, domain.ErrUnknownRole), errors.Is(err, domain.ErrInvalidExpiry):
+		return http.StatusBadRequest
+	default:
+		return errorStatus(err)
+	}
+}
+
+// @Summary Create API key
+// @Description Create a new API key with the given scopes and/or roles; the key is shown only once
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreateAPIKeyRequest true "Create API key request"
+// @Success 201 {object} models.APIKeyWithSecret "API key created"
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/admin/api-keys [post]
+func (h *APIKeyHandler) CreateKeyHandler(c *gin.Context) {
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Debugf("CreateKeyHandler: invalid request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, err := h.apiKeyService.CreateKey(c.Request.Context(), req)
+	if err != nil {
+		h.log(c).Debugf("CreateKeyHandler: failed to create API key: %v", err)
+		c.JSON(apiKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": key})
+}
+
+// @Summary List API keys
+// @Description List all API keys without their secret values
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.APIKey "List of API keys"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/admin/api-keys [get]
+func (h *APIKeyHandler) ListKeysHandler(c *gin.Context) {
+	keys, err := h.apiKeyService.ListKeys(c.Request.Context())
+	if err != nil {
+		h.log(c).Debugf("ListKeysHandler: failed to list API keys: %v", err)
+		c.JSON(apiKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"keys": keys})
+}
+
+// @Summary Rotate API key
+// @Description Replace the secret of an API key keeping its scopes; the new key is shown only once
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "API key ID"
+// @Success 200 {object} models.APIKeyWithSecret "API key rotated"
+// @Failure 400 {object} map[string]interface{} "Invalid API key ID"
+// @Failure 404 {object} map[string]interface{} "API key not found"
+// @Failure 409 {object} map[string]interface{} "API key is revoked"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/admin/api-keys/{id}/rotate [post]
+func (h *APIKeyHandler) RotateKeyHandler(c *gin.Context) {
+	id, err := h.parseKeyID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	key, err := h.apiKeyService.RotateKey(c.Request.Context(), id)
+	if err != nil {
+		h.log(c).Debugf("RotateKeyHandler: failed to rotate API key: %v", err)
+		c.JSON(apiKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": key})
+}
+
+// @Summary Revoke API key
+// @Description Revoke an API key so it can no longer be used
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "API key ID"
+// @Success 200 {object} map[string]interface{} "API key revoked"
+// @Failure 400 {object} map[string]interface{} "Invalid API key ID"
+// @Failure 404 {object} map[string]interface{} "API key not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/admin/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeKeyHandler(c *gin.Context) {
+	id, err := h.parseKeyID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	if err := h.apiKeyService.RevokeKey(c.Request.Context(), id); err != nil {
+		h.log(c).Debugf("RevokeKeyHandler: failed to revoke API key: %v", err)
+		c.JSON(apiKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/service"
+	"github.com/ananikitina/song_lib/internal/service/domain"
+)
+
+// Поток JSON-объектов, по одному в строке
+const contentTypeNDJSON = "application/x-ndjson"
+
+type BulkSongHandler struct {
+	bulkService service.BulkSongService
+	maxItems    int
+	logger      *logrus.Logger
+}
+
+func NewBulkSongHandler(bulkService service.BulkSongService, maxItems int, logger *logrus.Logger) *BulkSongHandler {
+	return &BulkSongHandler{
+		bulkService: bulkService,
+		maxItems:    maxItems,
+		logger:      logger,
+	}
+}
+
+func (h *BulkSongHandler) log(c *gin.Context) *logrus.Entry {
+	return h.logger.WithContext(c.Request.Context())
+}
+
+// HTTP-статус для ошибок пакетных операций
+func bulkErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrBulkEmpty), errors.Is(err, domain.ErrInvalidMatch):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrTooManySongs):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, domain.ErrBulkTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, domain.ErrBulkJobNotFound):
+		return http.StatusNotFound
+	default:
+		return errorStatus(err)
+	}
+}
+
+// Чтение песен из JSON-массива или NDJSON; читается не больше limit+1 песни,
+// чтобы превышение лимита определил сервис
+func decodeBulkSongs(body io.Reader, ndjson bool, limit int) ([]models.AddSongRequest, error) {
+	decoder := json.NewDecoder(body)
+	if !ndjson {
+		if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+			return nil, fmt.Errorf("request body must be a JSON array of songs")
+		}
+	}
+
+	var songs []models.AddSongRequest
+	for len(songs) <= limit {
+		if !ndjson && !decoder.More() {
+			break
+		}
+		var song models.AddSongRequest
+		err := decoder.Decode(&song)
+		if ndjson && err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid song at index %d: %w", len(songs), err)
+		}
+		songs = append(songs, song)
+	}
+	return songs, nil
+}
+
+// @Summary Add songs in bulk
+// @Description Add many songs at once. The body is a JSON array of {"group", "song"} objects or an NDJSON
+// @Description stream (Content-Type: application/x-ndjson). Songs are enriched from the external API with
+// @Description bounded concurrency; every song gets its own status: created, duplicate, invalid, rejected,
+// @Description enrichment_failed or failed. With Accept: application/x-ndjson results are streamed as they
+// @Description complete. With async=true the songs are added by a background job and 202 is returned.
+// @Tags songs
+// @Security BearerAuth
+// @Accept json
+// @Accept application/x-ndjson
+// @Produce json
+// @Produce application/x-ndjson
+// @Param async query bool false "Run as a background job"
+// @Param songs body []models.AddSongRequest true "Songs to add"
+// @Success 200 {array} models.BulkAddResult "Per-song results ordered by index"
+// @Success 202 {object} models.BulkJob "Job started"
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 413 {object} map[string]interface{} "Too many songs"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/songs/bulk [post]
+func (h *BulkSongHandler) AddSongsHandler(c *gin.Context) {
+	async, err := strconv.ParseBool(c.DefaultQuery("async", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "async must be a boolean"})
+		return
+	}
+
+	songs, err := decodeBulkSongs(c.Request.Body, c.ContentType() == contentTypeNDJSON, h.maxItems)
+	if err != nil {
+		h.log(c).Debugf("AddSongsHandler: invalid request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if async {
+		job, err := h.bulkService.StartAddSongsJob(c.Request.Context(), songs)
+		if err != nil {
+			h.log(c).Debugf("AddSongsHandler: failed to start job: %v", err)
+			c.JSON(bulkErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Location", fmt.Sprintf("/api/v1/songs/bulk/jobs/%s", job.ID))
+		c.JSON(http.StatusAccepted, gin.H{"data": job})
+		return
+	}
+
+	// Потоковый ответ: результаты отправляются по мере обработки песен
+	if c.NegotiateFormat(gin.MIMEJSON, contentTypeNDJSON) == contentTypeNDJSON {
+		encoder := json.NewEncoder(c.Writer)
+		err := h.bulkService.AddSongs(c.Request.Context(), songs, func(result models.BulkAddResult) {
+			if !c.Writer.Written() {
+				c.Header("Content-Type", contentTypeNDJSON)
+				c.Status(http.StatusOK)
+			}
+			if err := encoder.Encode(result); err != nil {
+				h.log(c).Debugf("AddSongsHandler: failed to write result: %v", err)
+				return
+			}
+			c.Writer.Flush()
+		})
+		if err != nil && !c.Writer.Written() {
+			h.log(c).Debugf("AddSongsHandler: failed to add songs: %v", err)
+			c.JSON(bulkErrorStatus(err), gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	results := make([]models.BulkAddResult, 0, len(songs))
+	err = h.bulkService.AddSongs(c.Request.Context(), songs, func(result models.BulkAddResult) {
+		results = append(results, result)
+	})
+	if err != nil {
+		h.log(c).Debugf("AddSongsHandler: failed to add songs: %v", err)
+		c.JSON(bulkErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Index < results[j].Index })
+	c.JSON(http.StatusOK, gin.H{"data": results, "summary": models.SummarizeBulk(results)})
+}
+
+// @Summary Get bulk job
+// @Description Status, progress and per-song results of a background bulk add job.
+// @Description Finished jobs are kept for BULK_JOB_TTL on the instance that ran them.
+// @Tags songs
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} models.BulkJob "Job"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Job not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /api/v1/songs/bulk/jobs/{id} [get]
+func (h *BulkSongHandler) GetJobHandler(c *gin.Context) {
+	job, err := h.bulkService.GetJob(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.log(c).Debugf("GetJobHandler: failed to get job: %v", err)
+		c.JSON(bulkErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	sort.Slice(job.Results, func(i, j int) bool { return job.Results[i].Index < job.Results[j].Index })
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
+
+// @Summary Update songs in bulk
+// @Description Apply the same changes (JSON Merge Patch rules: null clears a field) to songs selected by
+// @Description ids or by filter (the same fields as GET /songs, e.g. {"createdBy": "api-key:5"}) in one
+// @Description transaction. dryRun only reports how many songs would change. Changes affecting more than
+// @Description BULK_MAX_AFFECTED songs are rejected unless force is set.
+// @Tags songs
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.BulkUpdateRequest true "Songs and changes"
+// @Success 200 {object} models.BulkChangeResult "Number of affected songs"
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 409 {object} map[string]interface{} "Song with this group and name already exists"
+// @Failure 422 {object} map[string]interface{} "Changes failed validation or too many songs affected"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/songs/bulk-update [post]
+func (h *BulkSongHandler) UpdateSongsHandler(c *gin.Context) {
+	var req models.BulkUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Debugf("UpdateSongsHandler: invalid request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.bulkService.UpdateSongs(c.Request.Context(), req)
+	var validationErr *domain.ValidationError
+	if errors.As(err, &validationErr) {
+		h.log(c).Debugf("UpdateSongsHandler: changes rejected: %v", err)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "validation": validationErr.Report})
+		return
+	}
+	if err != nil {
+		h.log(c).Debugf("UpdateSongsHandler: failed to update songs: %v", err)
+		c.JSON(bulkErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// @Summary Delete songs in bulk
+// @Description Delete songs selected by ids or by filter (the same fields as GET /songs) in one transaction.
+// @Description dryRun only reports how many songs would be deleted. Deleting more than BULK_MAX_AFFECTED
+// @Description songs is rejected unless force is set.
+// @Tags songs
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.BulkDeleteRequest true "Songs to delete"
+// @Success 200 {object} models.BulkChangeResult "Number of deleted songs"
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 422 {object} map[string]interface{} "Too many songs affected"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/songs/bulk-delete [post]
+func (h *BulkSongHandler) DeleteSongsHandler(c *gin.Context) {
+	var req models.BulkDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Debugf("DeleteSongsHandler: invalid request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.bulkService.DeleteSongs(c.Request.Context(), req)
+	if err != nil {
+		h.log(c).Debugf("DeleteSongsHandler: failed to delete songs: %v", err)
+		c.JSON(bulkErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/service"
+	"github.com/ananikitina/song_lib/internal/service/domain"
+)
+
+type ChangeRequestHandler struct {
+	changeRequestService service.ChangeRequestService
+	logger               *logrus.Logger
+}
+
+func NewChangeRequestHandler(changeRequestService service.ChangeRequestService, logger *logrus.Logger) *ChangeRequestHandler {
+	return &ChangeRequestHandler{
+		changeRequestService: changeRequestService,
+		logger:               logger,
+	}
+}
+
+func (h *ChangeRequestHandler) log(c *gin.Context) *logrus.Entry {
+	return h.logger.WithContext(c.Request.Context())
+}
+
+func (h *ChangeRequestHandler) parseRequestID(c *gin.Context) (uint, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		h.log(c).Debugf("parseRequestID: invalid change request ID: %v", err)
+		return 0, err
+	}
+	return uint(id), nil
+}
+
+// HTTP-статус для ошибок рассмотрения запросов
+func changeRequestErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrChangeRequestNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrChangeRequestReviewed), errors.Is(err, domain.ErrChangeRequestStale):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrUnknownChangeStatus):
+		return http.StatusBadRequest
+	default:
+		return errorStatus(err)
+	}
+}
+
+// @Summary List change requests
+// @Description List proposed song changes by status, oldest first
+// @Tags review
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Status: pending (default), approved or rejected"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {array} models.ChangeRequest "List of change requests"
+// @Failure 400 {object} map[string]interface{} "Invalid status"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/change-requests [get]
+func (h *ChangeRequestHandler) ListChangeRequestsHandler(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	requests, err := h.changeRequestService.ListChangeRequests(c.Request.Context(), c.Query("status"), page, pageSize)
+	if err != nil {
+		h.log(c).Debugf("ListChangeRequestsHandler: failed to list change requests: %v", err)
+		c.JSON(changeRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"changeRequests": requests})
+}
+
+// Общая часть одобрения и отклонения
+func (h *ChangeRequestHandler) review(c *gin.Context, decide func(id uint, comment string) (*models.ChangeRequest, error)) {
+	id, err := h.parseRequestID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid change request ID"})
+		return
+	}
+
+	var req models.ReviewChangeRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.log(c).Debugf("review: invalid request: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	request, err := decide(id, req.Comment)
+	if err != nil {
+		h.log(c).Debugf("review: failed to review change request %d: %v", id, err)
+		c.JSON(changeRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": request})
+}
+
+// @Summary Approve change request
+// @Description Approve a pending change request and apply it to the song
+// @Tags review
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Change request ID"
+// @Param request body models.ReviewChangeRequest false "Review comment"
+// @Success 200 {object} models.ChangeRequest "Change request approved"
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Change request or song not found"
+// @Failure 409 {object} map[string]interface{} "Change request is already reviewed or the song was changed after it was proposed"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/change-requests/{id}/approve [post]
+func (h *ChangeRequestHandler) ApproveChangeRequestHandler(c *gin.Context) {
+	h.review(c, func(id uint, comment string) (*models.ChangeRequest, error) {
+		return h.changeRequestService.ApproveChangeRequest(c.Request.Context(), id, comment)
+	})
+}
+
+// @Summary Reject change request
+// @Description Reject a pending change request with an optional comment
+// @Tags review
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Change request ID"
+// @Param request body models.ReviewChangeRequest false "Review comment"
+// @Success 200 {object} models.ChangeRequest "Change request rejected"
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Change request not found"
+// @Failure 409 {object} map[string]interface{} "Change request is already reviewed"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/change-requests/{id}/reject [post]
+func (h *ChangeRequestHandler) RejectChangeRequestHandler(c *gin.Context) {
+	h.review(c, func(id uint, comment string) (*models.ChangeRequest, error) {
+		return h.changeRequestService.RejectChangeRequest(c.Request.Context(), id, comment)
+	})
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/service"
+	"github.com/ananikitina/song_lib/internal/service/domain"
+)
+
+type CreditHandler struct {
+	creditService service.CreditService
+	logger        *logrus.Logger
+}
+
+func NewCreditHandler(creditService service.CreditService, logger *logrus.Logger) *CreditHandler {
+	return &CreditHandler{
+		creditService: creditService,
+		logger:        logger,
+	}
+}
+
+func (h *CreditHandler) log(c *gin.Context) *logrus.Entry {
+	return h.logger.WithContext(c.Request.Context())
+}
+
+// HTTP-статус для ошибок участников
+func creditErrorStatus(err error) int {
+	if errors.Is(err, domain.ErrInvalidCredit) {
+		return http.StatusBadRequest
+	}
+	return errorStatus(err)
+}
+
+// Ответ с участниками песни
+func (h *CreditHandler) respond(c *gin.Context, handler string, credits []models.SongCredit, err error) {
+	if err != nil {
+		h.log(c).Debugf("%s: %v", handler, err)
+		c.JSON(creditErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"credits": credits})
+}
+
+// @Summary Get song credits
+// @Description Credited people of a song ordered by role (artist, featuring, composer, lyricist) and position
+// @Tags credits
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {array} models.SongCredit "Credits"
+// @Failure 400 {object} map[string]interface{} "Invalid song ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Song not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/songs/{id}/credits [get]
+func (h *CreditHandler) GetSongCreditsHandler(c *gin.Context) {
+	id, ok := songIDParam(c, h.log(c))
+	if !ok {
+		return
+	}
+	credits, err := h.creditService.GetSongCredits(c.Request.Context(), id)
+	h.respond(c, "GetSongCreditsHandler", credits, err)
+}
+
+// @Summary Replace song credits
+// @Description Replace all credits of a song. Each credit has a name and a role: artist, featuring, composer or
+// @Description lyricist; the order of the list sets the position within each role. An empty list removes all credits.
+// @Tags credits
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param credits body models.SongCreditsRequest true "New credits"
+// @Success 200 {array} models.SongCredit "Credits"
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Song not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/songs/{id}/credits [put]
+func (h *CreditHandler) SetSongCreditsHandler(c *gin.Context) {
+	id, ok := songIDParam(c, h.log(c))
+	if !ok {
+		return
+	}
+	var req models.SongCreditsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Debugf("SetSongCreditsHandler: invalid request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	credits, err := h.creditService.SetSongCredits(c.Request.Context(), id, req.Credits)
+	h.respond(c, "SetSongCreditsHandler", credits, err)
+}
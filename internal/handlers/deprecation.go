@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware для устаревших маршрутов: заголовки Deprecation (RFC 9745), Sunset (RFC 8594)
+// и ссылка на маршрут-преемник; ":id" в successor заменяется параметром запроса
+func Deprecated(successor string, deprecatedAt, sunset time.Time) gin.HandlerFunc {
+	deprecation := fmt.Sprintf("@%d", deprecatedAt.Unix())
+	sunsetDate := sunset.UTC().Format(http.TimeFormat)
+
+	return func(c *gin.Context) {
+		link := successor
+		for _, param := range c.Params {
+			link = strings.ReplaceAll(link, ":"+param.Key, param.Value)
+		}
+
+		c.Header("Deprecation", deprecation)
+		c.Header("Sunset", sunsetDate)
+		c.Header("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, link))
+		c.Next()
+	}
+}
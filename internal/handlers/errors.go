@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/ananikitina/song_lib/internal/service/domain"
+)
+
+// Нестандартный код ответа для запросов, отмененных клиентом
+const statusClientClosedRequest = 499
+
+// HTTP-статус для ошибки сервиса
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrSongNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrInvalidID), errors.Is(err, domain.ErrEmptyParameters), errors.Is(err, domain.ErrInvalidFilter):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrSongQuotaExceeded):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, domain.ErrSongExists):
+		return http.StatusConflict
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		return statusClientClosedRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/service/domain"
+)
+
+// Сильный ETag по версии записи
+func versionETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// Сильный ETag песни по ее версии
+func songETag(song *models.Song) string {
+	return versionETag(song.Version)
+}
+
+// Версия песни из заголовка If-Match: 0, если заголовка нет или он равен "*".
+// Слабый или чужой ETag не совпадает ни с одной версией: ошибка domain.ErrVersionMismatch.
+func parseIfMatch(header string) (int, error) {
+	header = strings.TrimSpace(header)
+	switch {
+	case header == "" || header == "*":
+		return 0, nil
+	case strings.Contains(header, ","):
+		return 0, fmt.Errorf("If-Match must contain a single entity tag")
+	case strings.HasPrefix(header, `W/"`):
+		// Для If-Match слабые ETag никогда не совпадают
+		return 0, fmt.Errorf("%w: weak entity tag %s never matches", domain.ErrVersionMismatch, header)
+	}
+
+	value, err := strconv.Unquote(header)
+	if err != nil || !strings.HasPrefix(header, `"`) {
+		return 0, fmt.Errorf("invalid If-Match entity tag %s", header)
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("%w: entity tag %s is not a version", domain.ErrVersionMismatch, header)
+	}
+	return version, nil
+}
+
+// Совпадение ETag с заголовком If-None-Match (слабое сравнение)
+func matchesIfNoneMatch(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// Ошибка изменения записи с учетом If-Match: "*" совпадает только с существующей записью,
+// поэтому ее отсутствие - несовпадение условия (412), а не 404
+func ifMatchError(c *gin.Context, err error, notFound error) error {
+	if errors.Is(err, notFound) && strings.TrimSpace(c.GetHeader("If-Match")) == "*" {
+		return fmt.Errorf("%w: %v", domain.ErrVersionMismatch, err)
+	}
+	return err
+}
+
+// Ожидаемая версия записи из If-Match; при ошибке ответ уже отправлен
+func ifMatchVersion(c *gin.Context, required bool) (int, bool) {
+	header := c.GetHeader("If-Match")
+	if header == "" && required {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required"})
+		return 0, false
+	}
+	version, err := parseIfMatch(header)
+	if errors.Is(err, domain.ErrVersionMismatch) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+		return 0, false
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return 0, false
+	}
+	return version, true
+}
+
+// Ожидаемая версия песни для изменения; при ошибке ответ уже отправлен
+func (h *SongHandler) expectedVersion(c *gin.Context) (int, bool) {
+	return ifMatchVersion(c, h.requireIfMatch)
+}
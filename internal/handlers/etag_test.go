@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/service"
+	"github.com/ananikitina/song_lib/internal/service/domain"
+)
+
+// Сервис песен, запоминающий версии, с которыми вызывались изменение и удаление
+type versionRecorder struct {
+	service.SongService
+	versions []int
+}
+
+func (s *versionRecorder) GetSongById(ctx context.Context, id uint) (*models.Song, error) {
+	return &models.Song{ID: id, GroupName: "Muse", SongName: "Uprising", Version: 1}, nil
+}
+
+func (s *versionRecorder) UpdateSong(ctx context.Context, songId uint, patch models.SongPatch, version int) (*models.Song, error) {
+	s.versions = append(s.versions, version)
+	return &models.Song{ID: songId, GroupName: "Muse", SongName: "Uprising", Version: 2}, nil
+}
+
+func (s *versionRecorder) DeleteSong(ctx context.Context, id uint, version int) error {
+	s.versions = append(s.versions, version)
+	return nil
+}
+
+func newVersionRouter(songs service.SongService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	h := NewSongHandler(songs, nil, false, logger)
+	router := gin.New()
+	router.PUT("/songs/:id", h.ReplaceSongHandler)
+	router.PATCH("/songs/:id", h.PatchSongHandler)
+	router.DELETE("/songs/:id", h.DeleteSongHandler)
+	return router
+}
+
+func TestIfMatchUnmatchedTagRejected(t *testing.T) {
+	requests := []struct {
+		method, contentType, body string
+	}{
+		{http.MethodPut, gin.MIMEJSON, `{"group":"Muse","song":"Uprising"}`},
+		{http.MethodPatch, contentTypeMergePatch, `{"song":"Uprising"}`},
+		{http.MethodDelete, "", ""},
+	}
+	tags := []string{`W/"1"`, `"abc"`, `"0"`, `"-1"`}
+
+	for _, r := range requests {
+		for _, tag := range tags {
+			t.Run(r.method+" "+tag, func(t *testing.T) {
+				songs := &versionRecorder{}
+				req := httptest.NewRequest(r.method, "/songs/1", strings.NewReader(r.body))
+				if r.contentType != "" {
+					req.Header.Set("Content-Type", r.contentType)
+				}
+				req.Header.Set("If-Match", tag)
+				w := httptest.NewRecorder()
+				newVersionRouter(songs).ServeHTTP(w, req)
+
+				if w.Code != http.StatusPreconditionFailed {
+					t.Errorf("status = %d, want %d; body %s", w.Code, http.StatusPreconditionFailed, w.Body)
+				}
+				if len(songs.versions) != 0 {
+					t.Errorf("song changed with versions %v", songs.versions)
+				}
+			})
+		}
+	}
+}
+
+func TestIfMatchVersionPassed(t *testing.T) {
+	tests := []struct {
+		header string
+		want   int
+	}{
+		{"", 0},
+		{"*", 0},
+		{`"3"`, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			songs := &versionRecorder{}
+			req := httptest.NewRequest(http.MethodDelete, "/songs/1", nil)
+			if tt.header != "" {
+				req.Header.Set("If-Match", tt.header)
+			}
+			w := httptest.NewRecorder()
+			newVersionRouter(songs).ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, http.StatusOK, w.Body)
+			}
+			if len(songs.versions) != 1 || songs.versions[0] != tt.want {
+				t.Errorf("versions = %v, want [%d]", songs.versions, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONPatchExpectsSnapshotVersion(t *testing.T) {
+	tests := []struct {
+		header     string
+		wantStatus int
+		want       []int
+	}{
+		{"", http.StatusOK, []int{1}},
+		{`"1"`, http.StatusOK, []int{1}},
+		{`"2"`, http.StatusPreconditionFailed, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			songs := &versionRecorder{}
+			req := httptest.NewRequest(http.MethodPatch, "/songs/1", strings.NewReader(`[{"op":"replace","path":"/song","value":"Starlight"}]`))
+			req.Header.Set("Content-Type", contentTypeJSONPatch)
+			if tt.header != "" {
+				req.Header.Set("If-Match", tt.header)
+			}
+			w := httptest.NewRecorder()
+			newVersionRouter(songs).ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if !slices.Equal(songs.versions, tt.want) {
+				t.Errorf("versions = %v, want %v", songs.versions, tt.want)
+			}
+		})
+	}
+}
+
+// Сервис песен без песен
+type missingSongs struct {
+	service.SongService
+}
+
+func (s *missingSongs) GetSongById(ctx context.Context, id uint) (*models.Song, error) {
+	return nil, domain.ErrSongNotFound
+}
+
+func (s *missingSongs) UpdateSong(ctx context.Context, songId uint, patch models.SongPatch, version int) (*models.Song, error) {
+	return nil, domain.ErrSongNotFound
+}
+
+func (s *missingSongs) DeleteSong(ctx context.Context, id uint, version int) error {
+	return domain.ErrSongNotFound
+}
+
+func TestIfMatchAnyOnMissingSong(t *testing.T) {
+	requests := []struct {
+		method, contentType, body string
+	}{
+		{http.MethodPut, gin.MIMEJSON, `{"group":"Muse","song":"Uprising"}`},
+		{http.MethodPatch, contentTypeMergePatch, `{"song":"Uprising"}`},
+		{http.MethodPatch, contentTypeJSONPatch, `[{"op":"replace","path":"/song","value":"Uprising"}]`},
+		{http.MethodDelete, "", ""},
+	}
+	tests := []struct {
+		header     string
+		wantStatus int
+	}{
+		{"", http.StatusNotFound},
+		{"*", http.StatusPreconditionFailed},
+	}
+
+	for _, r := range requests {
+		for _, tt := range tests {
+			t.Run(r.method+" "+r.contentType+" "+tt.header, func(t *testing.T) {
+				req := httptest.NewRequest(r.method, "/songs/1", strings.NewReader(r.body))
+				if r.contentType != "" {
+					req.Header.Set("Content-Type", r.contentType)
+				}
+				if tt.header != "" {
+					req.Header.Set("If-Match", tt.header)
+				}
+				w := httptest.NewRecorder()
+				newVersionRouter(&missingSongs{}).ServeHTTP(w, req)
+
+				if w.Code != tt.wantStatus {
+					t.Errorf("status = %d, want %d; body %s", w.Code, tt.wantStatus, w.Body)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/service"
+)
+
+// Число песен, после которого выгружаемые данные отправляются клиенту
+const exportFlushEvery = 100
+
+// Запись песен выгрузки в одном из форматов
+type songEncoder interface {
+	begin() error
+	encode(song *models.Song) error
+	// Передача буферизованных данных в ответ
+	flush() error
+	end() error
+}
+
+// Колонки выгрузки CSV
+var exportColumns = []string{
+	"id", "group", "song", "releaseDate", "text", "link", "needsReview",
+	"createdBy", "updatedBy", "createdAt", "updatedAt", "version",
+}
+
+type csvSongEncoder struct {
+	writer *csv.Writer
+}
+
+func (e *csvSongEncoder) begin() error {
+	return e.writer.Write(exportColumns)
+}
+
+func (e *csvSongEncoder) encode(song *models.Song) error {
+	return e.writer.Write([]string{
+		strconv.FormatUint(uint64(song.ID), 10),
+		song.GroupName,
+		song.SongName,
+		song.ReleaseDate,
+		song.Text,
+		song.Link,
+		strconv.FormatBool(song.NeedsReview),
+		song.CreatedBy,
+		song.UpdatedBy,
+		song.CreatedAt.UTC().Format(time.RFC3339),
+		song.UpdatedAt.UTC().Format(time.RFC3339),
+		strconv.Itoa(song.Version),
+	})
+}
+
+func (e *csvSongEncoder) flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvSongEncoder) end() error {
+	return e.flush()
+}
+
+// JSON Lines: по песне в строке
+type jsonlSongEncoder struct {
+	encoder *json.Encoder
+}
+
+func (e *jsonlSongEncoder) begin() error { return nil }
+
+func (e *jsonlSongEncoder) encode(song *models.Song) error {
+	return e.encoder.Encode(song)
+}
+
+func (e *jsonlSongEncoder) flush() error { return nil }
+
+func (e *jsonlSongEncoder) end() error { return nil }
+
+// JSON-массив, который записывается по элементу
+type jsonSongEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonSongEncoder) begin() error {
+	_, err := io.WriteString(e.w, "[")
+	return err
+}
+
+func (e *jsonSongEncoder) encode(song *models.Song) error {
+	data, err := json.Marshal(song)
+	if err != nil {
+		return err
+	}
+	if e.count > 0 {
+		if _, err := io.WriteString(e.w, ","); err != nil {
+			return err
+		}
+	}
+	e.count++
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *jsonSongEncoder) flush() error { return nil }
+
+func (e *jsonSongEncoder) end() error {
+	_, err := io.WriteString(e.w, "]\n")
+	return err
+}
+
+// Кодировщик, тип содержимого и расширение файла для формата выгрузки
+func newSongEncoder(format string, w io.Writer) (songEncoder, string, string, bool) {
+	switch format {
+	case "csv":
+		return &csvSongEncoder{writer: csv.NewWriter(w)}, "text/csv; charset=utf-8", "csv", true
+	case "jsonl":
+		return &jsonlSongEncoder{encoder: json.NewEncoder(w)}, contentTypeNDJSON, "jsonl", true
+	case "json":
+		return &jsonSongEncoder{w: w}, gin.MIMEJSON, "json", true
+	default:
+		return nil, "", "", false
+	}
+}
+
+type ExportHandler struct {
+	songService service.SongService
+	timeout     time.Duration
+	logger      *logrus.Logger
+}
+
+func NewExportHandler(songService service.SongService, timeout time.Duration, logger *logrus.Logger) *ExportHandler {
+	return &ExportHandler{
+		songService: songService,
+		timeout:     timeout,
+		logger:      logger,
+	}
+}
+
+func (h *ExportHandler) log(c *gin.Context) *logrus.Entry {
+	return h.logger.WithContext(c.Request.Context())
+}
+
+// @Summary Export songs
+// @Description Stream all songs matching the filters (the same fields as GET /songs) as CSV, JSON Lines or a
+// @Description JSON array, ordered by ID. Rows are read with a database cursor and written as they arrive,
+// @Description so the response is never buffered as a whole. The export is limited by EXPORT_TIMEOUT; if it
+// @Description fails midway the connection is aborted, so the client gets a transfer error. The list parameters
+// @Description page, pageSize, fields and include are ignored, so a URL copied from the list exports all matches.
+// @Tags songs
+// @Security BearerAuth
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce json
+// @Param format query string false "csv, jsonl or json" default(csv)
+// @Param filters query string false "Filters by song fields"
+// @Param tag query []string false "Tags, repeated or comma-separated" collectionFormat(multi)
+// @Param tagMatch query string false "Match any (default) or all of the tags" Enums(any, all)
+// @Param credit query string false "Credited person, case-insensitive"
+// @Param creditRole query string false "Role of the credited person" Enums(artist, featuring, composer, lyricist)
+// @Success 200 {array} models.Song "Songs"
+// @Failure 400 {object} map[string]interface{} "Invalid format or filter"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/songs/export [get]
+func (h *ExportHandler) ExportSongsHandler(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	// Параметры списка пропускаются, чтобы работал адрес, скопированный из списка песен
+	filters := songFilters(c, append([]string{"format"}, songListParams...)...)
+
+	encoder, contentType, extension, ok := newSongEncoder(format, c.Writer)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown export format %q, expected csv, jsonl or json", format)})
+		return
+	}
+
+	// Выгрузка может длиться дольше общего таймаута записи ответа
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(h.timeout)); err != nil {
+		h.log(c).Debugf("ExportSongsHandler: failed to extend write deadline: %v", err)
+	}
+
+	// Заголовки отправляются с первой песней, чтобы ошибку фильтра можно было вернуть статусом
+	started := false
+	start := func() error {
+		started = true
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="songs-%s.%s"`, time.Now().UTC().Format("20060102"), extension))
+		c.Status(http.StatusOK)
+		return encoder.begin()
+	}
+
+	var count int
+	err := h.songService.ExportSongs(ctx, filters, func(song *models.Song) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := encoder.encode(song); err != nil {
+			return err
+		}
+		count++
+		if count%exportFlushEvery == 0 {
+			if err := encoder.flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil && !started {
+		h.log(c).Debugf("ExportSongsHandler: failed to export songs: %v", err)
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		// Статус уже отправлен: соединение обрывается, чтобы клиент не принял часть выгрузки за всю
+		h.log(c).Errorf("ExportSongsHandler: export interrupted after %d songs: %v", count, err)
+		panic(http.ErrAbortHandler)
+	}
+
+	if !started {
+		if err := start(); err != nil {
+			h.log(c).Debugf("ExportSongsHandler: failed to write export: %v", err)
+			return
+		}
+	}
+	if err := encoder.end(); err != nil {
+		h.log(c).Debugf("ExportSongsHandler: failed to write export: %v", err)
+		return
+	}
+	h.log(c).Infof("ExportSongsHandler: exported %d songs", count)
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/internal/logging"
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/service"
+)
+
+// Сервис песен, запоминающий фильтры выгрузки
+type exportRecorder struct {
+	service.SongService
+	filters map[string]interface{}
+}
+
+func (s *exportRecorder) ExportSongs(ctx context.Context, filters map[string]interface{}, fn func(*models.Song) error) error {
+	s.filters = filters
+	return fn(&models.Song{ID: 1, GroupName: "Muse", SongName: "Uprising", Version: 1})
+}
+
+func TestExportSkipsListParameters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	songs := &exportRecorder{}
+	h := NewExportHandler(songs, time.Minute, logger)
+	router := gin.New()
+	router.GET("/songs/export", h.ExportSongsHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/songs/export?format=jsonl&group=Muse&page=2&pageSize=20&fields=id,song&include=versesCount", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	want := map[string]interface{}{"group": "Muse"}
+	if !maps.Equal(songs.filters, want) {
+		t.Errorf("filters = %v, want %v", songs.filters, want)
+	}
+}
+
+// Сервис песен, выгрузка которого прерывается ошибкой после songs песен
+type failingExport struct {
+	service.SongService
+	songs int
+}
+
+func (s *failingExport) ExportSongs(ctx context.Context, filters map[string]interface{}, fn func(*models.Song) error) error {
+	for i := 1; i <= s.songs; i++ {
+		if err := fn(&models.Song{ID: uint(i), GroupName: "Muse", SongName: fmt.Sprintf("Song %d", i), Version: 1}); err != nil {
+			return err
+		}
+	}
+	return errors.New("connection reset by database")
+}
+
+func TestExportAbortsResponseOnFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	for _, songs := range []int{1, exportFlushEvery + 1} {
+		for _, format := range []string{"csv", "jsonl", "json"} {
+			t.Run(fmt.Sprintf("%s after %d songs", format, songs), func(t *testing.T) {
+				h := NewExportHandler(&failingExport{songs: songs}, time.Minute, logger)
+				router := gin.New()
+				router.Use(logging.RecoveryMiddleware(logger))
+				router.GET("/songs/export", h.ExportSongsHandler)
+				server := httptest.NewServer(router)
+				defer server.Close()
+
+				resp, err := server.Client().Get(server.URL + "/songs/export?format=" + format)
+				if err != nil {
+					// Соединение оборвано до отправки заголовков
+					return
+				}
+				defer resp.Body.Close()
+				if _, err := io.ReadAll(resp.Body); err == nil {
+					t.Errorf("response with status %d ended normally, want a transfer error", resp.StatusCode)
+				}
+			})
+		}
+	}
+}
+
+func TestExportFailureBeforeFirstSong(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	h := NewExportHandler(&failingExport{}, time.Minute, logger)
+	router := gin.New()
+	router.Use(logging.RecoveryMiddleware(logger))
+	router.GET("/songs/export", h.ExportSongsHandler)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/songs/export", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+}
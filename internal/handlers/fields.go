@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+var errInvalidFieldSelection = errors.New("invalid field selection")
+
+// Поле песни в ответе: индекс в структуре и признак omitempty JSON-тега
+type songField struct {
+	index     int
+	omitEmpty bool
+}
+
+// Поля песни, доступные в fields=, по JSON-тегам модели; поля вне таблицы песен не выбираются
+var songFieldNames, songFields = jsonFields(reflect.TypeOf(models.Song{}))
+
+// Связанные данные, доступные в include=, и поля песни, из которых они вычисляются
+var songIncludes = map[string]struct {
+	fields []string
+	value  func(song models.Song) interface{}
+}{
+	"versesCount": {
+		fields: []string{"text"},
+		value: func(song models.Song) interface{} {
+			if song.Text == "" {
+				return 0
+			}
+			return len(song.Verses())
+		},
+	},
+}
+
+func jsonFields(t reflect.Type) ([]string, map[string]songField) {
+	var names []string
+	fields := make(map[string]songField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("gorm") == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names = append(names, name)
+			fields[name] = songField{index: i, omitEmpty: opts == "omitempty"}
+		}
+	}
+	return names, fields
+}
+
+// Выбор полей ответа: fields= (sparse fieldset) и include= (связанные данные)
+type songSelection struct {
+	fields  map[string]bool
+	include []string
+}
+
+// Разбор параметров fields и include; nil, если ни один не задан
+func parseSongSelection(c *gin.Context) (*songSelection, error) {
+	fieldsParam, includeParam := c.Query("fields"), c.Query("include")
+	if fieldsParam == "" && includeParam == "" {
+		return nil, nil
+	}
+
+	selection := &songSelection{}
+	if fieldsParam != "" {
+		selection.fields = map[string]bool{"id": true}
+		for _, field := range strings.Split(fieldsParam, ",") {
+			field = strings.TrimSpace(field)
+			if _, ok := songFields[field]; !ok {
+				return nil, fmt.Errorf("%w: unknown field %q", errInvalidFieldSelection, field)
+			}
+			selection.fields[field] = true
+		}
+	}
+	for _, include := range strings.Split(includeParam, ",") {
+		include = strings.TrimSpace(include)
+		if include == "" {
+			continue
+		}
+		if _, ok := songIncludes[include]; !ok {
+			return nil, fmt.Errorf("%w: unknown include %q", errInvalidFieldSelection, include)
+		}
+		selection.include = append(selection.include, include)
+	}
+	return selection, nil
+}
+
+// Поля песни, которые нужно прочитать из базы для ответа; nil, если нужны все
+func (s *songSelection) columns() []string {
+	if s == nil || s.fields == nil {
+		return nil
+	}
+	columns := make([]string, 0, len(s.fields))
+	for _, name := range songFieldNames {
+		if s.fields[name] {
+			columns = append(columns, name)
+		}
+	}
+	for _, include := range s.include {
+		for _, field := range songIncludes[include].fields {
+			if !s.fields[field] && !slices.Contains(columns, field) {
+				columns = append(columns, field)
+			}
+		}
+	}
+	return columns
+}
+
+// Представление песни с выбранными полями и связанными данными; пустые поля с omitempty
+// опускаются, как при сериализации самой песни
+func (s *songSelection) apply(song models.Song) map[string]interface{} {
+	value := reflect.ValueOf(song)
+	result := make(map[string]interface{})
+	for _, name := range songFieldNames {
+		if s.fields != nil && !s.fields[name] {
+			continue
+		}
+		field := songFields[name]
+		v := value.Field(field.index)
+		if field.omitEmpty && (v.IsZero() || v.Kind() == reflect.Slice && v.Len() == 0) {
+			continue
+		}
+		result[name] = v.Interface()
+	}
+	for _, include := range s.include {
+		result[include] = songIncludes[include].value(song)
+	}
+	return result
+}
+
+// Ответ со списком песен с учетом выбора полей
+func (s *songSelection) applyAll(songs []models.Song) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(songs))
+	for _, song := range songs {
+		result = append(result, s.apply(song))
+	}
+	return result
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/service"
+)
+
+// Сервис песен, запоминающий поля, запрошенные у списка песен
+type fieldsRecorder struct {
+	service.SongService
+	fields []string
+}
+
+func (s *fieldsRecorder) GetSongsWithFiltersAndPagination(ctx context.Context, filters map[string]interface{}, fields []string, page int, pageSize int) ([]models.Song, error) {
+	s.fields = fields
+	return []models.Song{{ID: 1, GroupName: "Muse", SongName: "Uprising", Text: "They will not force us\nThey will stop degrading us"}}, nil
+}
+
+func TestGetAllSongsSelectsColumns(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantFields []string
+		wantBody   string
+	}{
+		{
+			name:       "all fields",
+			query:      "",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "selected fields",
+			query:      "?fields=song,group",
+			wantStatus: http.StatusOK,
+			wantFields: []string{"id", "group", "song"},
+			wantBody:   `{"songs":[{"group":"Muse","id":1,"song":"Uprising"}]}`,
+		},
+		{
+			name:       "include reads its fields",
+			query:      "?fields=song&include=versesCount",
+			wantStatus: http.StatusOK,
+			wantFields: []string{"id", "song", "text"},
+			wantBody:   `{"songs":[{"id":1,"song":"Uprising","versesCount":2}]}`,
+		},
+		{
+			name:       "include without fields",
+			query:      "?include=versesCount",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "field outside the songs table",
+			query:      "?fields=credits",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			songs := &fieldsRecorder{}
+			h := NewSongHandler(songs, nil, false, logger)
+			router := gin.New()
+			router.GET("/songs", h.GetAllSongsHandler)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/songs"+tt.query, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if !slices.Equal(songs.fields, tt.wantFields) {
+				t.Errorf("fields = %q, want %q", songs.fields, tt.wantFields)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %s, want %s", w.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestSongSelectionApplyMatchesSong(t *testing.T) {
+	songs := []models.Song{
+		{ID: 1, GroupName: "Muse", SongName: "Uprising"},
+		{
+			ID: 2, GroupName: "Muse", SongName: "Uprising", ReleaseDate: "16.07.2006", Text: "They will not force us",
+			Link: "https://example.com", NeedsReview: true, ValidationReport: &models.ValidationReport{},
+			CreatedBy: "alice", UpdatedBy: "bob", CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			UpdatedAt: time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC), Version: 3,
+		},
+	}
+
+	selection := &songSelection{}
+	for _, song := range songs {
+		if got, want := decodeJSON(t, selection.apply(song)), decodeJSON(t, song); !reflect.DeepEqual(got, want) {
+			t.Errorf("apply() = %v, want %v", got, want)
+		}
+	}
+}
+
+// JSON-представление значения в виде map для сравнения без учета порядка ключей
+func decodeJSON(t *testing.T, v interface{}) map[string]interface{} {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
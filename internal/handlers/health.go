@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/internal/health"
+	"github.com/ananikitina/song_lib/internal/version"
+)
+
+type HealthHandler struct {
+	checks []health.Check
+	logger *logrus.Logger
+}
+
+func NewHealthHandler(checks []health.Check, logger *logrus.Logger) *HealthHandler {
+	return &HealthHandler{
+		checks: checks,
+		logger: logger,
+	}
+}
+
+// @Summary Liveness probe
+// @Description Report that the process is alive
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Process is alive"
+// @Router /healthz [get]
+func (h *HealthHandler) LivenessHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusUp})
+}
+
+// @Summary Readiness probe
+// @Description Check database, schema version and external API availability
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]interface{} "All checks passed"
+// @Failure 503 {object} map[string]interface{} "Some checks failed"
+// @Router /readyz [get]
+func (h *HealthHandler) ReadinessHandler(c *gin.Context) {
+	results, healthy := health.RunChecks(c.Request.Context(), h.checks)
+	if !healthy {
+		h.logger.WithContext(c.Request.Context()).Warnf("ReadinessHandler: not ready: %v", results)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": health.StatusDown, "checks": results})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusUp, "checks": results})
+}
+
+// @Summary Build information
+// @Description Report version, commit and build date of the running binary
+// @Tags health
+// @Produce json
+// @Success 200 {object} version.Info "Build information"
+// @Router /version [get]
+func (h *HealthHandler) VersionHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, version.Get())
+}
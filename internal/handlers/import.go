@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/internal/importer"
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/service"
+)
+
+// Отчет об отклоненных строках импорта
+const contentTypeCSV = "text/csv"
+
+// Запас времени на отправку ответа после истечения таймаута импорта
+const importResponseGrace = 5 * time.Second
+
+type ImportHandler struct {
+	importService service.ImportService
+	maxRows       int
+	timeout       time.Duration
+	logger        *logrus.Logger
+}
+
+func NewImportHandler(importService service.ImportService, maxRows int, timeout time.Duration, logger *logrus.Logger) *ImportHandler {
+	return &ImportHandler{
+		importService: importService,
+		maxRows:       maxRows,
+		timeout:       timeout,
+		logger:        logger,
+	}
+}
+
+func (h *ImportHandler) log(c *gin.Context) *logrus.Entry {
+	return h.logger.WithContext(c.Request.Context())
+}
+
+// @Summary Import songs
+// @Description Import an existing catalogue from CSV with a header row or from JSON Lines. CSV columns named
+// @Description group, song, releaseDate, text and link (or group_name, song_name, release_date) are imported,
+// @Description other columns are ignored; map renames columns, e.g. map=Artist=group,Title=song. Rows are
+// @Description matched with existing songs by normalized group and song: matching songs get the non-empty
+// @Description fields of the row, others are created. With enrich=true missing fields are fetched from the
+// @Description external API. Every row gets a status: created, updated, unchanged, rejected, enrichment_failed
+// @Description or failed. With Accept: text/csv the response is the report of rows that were not imported.
+// @Tags songs
+// @Security BearerAuth
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Produce text/csv
+// @Param format query string false "csv or jsonl; detected from Content-Type when omitted"
+// @Param enrich query bool false "Fetch missing fields from the external API"
+// @Param map query string false "CSV column mapping, e.g. Artist=group,Title=song"
+// @Param catalogue body string true "CSV or JSON Lines"
+// @Success 200 {object} models.ImportReport "Row counts by status and rows that were not imported"
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 413 {object} map[string]interface{} "Too many rows"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Failure 504 {object} map[string]interface{} "Import did not finish within IMPORT_TIMEOUT"
+// @Router /api/v1/songs/import [post]
+func (h *ImportHandler) ImportSongsHandler(c *gin.Context) {
+	format := c.Query("format")
+	if format == "" {
+		format = importer.DetectFormat(c.ContentType())
+	}
+
+	enrich, err := strconv.ParseBool(c.DefaultQuery("enrich", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "enrich must be a boolean"})
+		return
+	}
+
+	mapping, err := importer.ParseMapping(c.Query("map"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Загрузка файла и импорт с обогащением могут длиться дольше общих таймаутов чтения и записи
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+	deadline := time.Now().Add(h.timeout + importResponseGrace)
+	controller := http.NewResponseController(c.Writer)
+	if err := controller.SetReadDeadline(deadline); err != nil {
+		h.log(c).Debugf("ImportSongsHandler: failed to extend read deadline: %v", err)
+	}
+	if err := controller.SetWriteDeadline(deadline); err != nil {
+		h.log(c).Debugf("ImportSongsHandler: failed to extend write deadline: %v", err)
+	}
+
+	rows, err := importer.Read(c.Request.Body, format, mapping, h.maxRows)
+	if err != nil {
+		h.log(c).Debugf("ImportSongsHandler: invalid request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report := models.NewImportReport()
+	err = h.importService.ImportSongs(ctx, rows, models.ImportOptions{Enrich: enrich}, report.Add)
+	if err != nil {
+		h.log(c).Debugf("ImportSongsHandler: failed to import songs: %v", err)
+		c.JSON(bulkErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.NegotiateFormat(gin.MIMEJSON, contentTypeCSV) == contentTypeCSV {
+		c.Header("Content-Type", contentTypeCSV)
+		c.Status(http.StatusOK)
+		if err := importer.WriteReport(c.Writer, report.Rejected); err != nil {
+			h.log(c).Debugf("ImportSongsHandler: failed to write report: %v", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
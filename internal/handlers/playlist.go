@@ -0,0 +1,526 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/service"
+	"github.com/ananikitina/song_lib/internal/service/domain"
+)
+
+// Форматы выгрузки плейлиста
+const (
+	contentTypeM3U  = "audio/x-mpegurl"
+	contentTypeXSPF = "application/xspf+xml"
+)
+
+type PlaylistHandler struct {
+	playlistService service.PlaylistService
+	requireIfMatch  bool
+	logger          *logrus.Logger
+}
+
+func NewPlaylistHandler(playlistService service.PlaylistService, requireIfMatch bool, logger *logrus.Logger) *PlaylistHandler {
+	return &PlaylistHandler{
+		playlistService: playlistService,
+		requireIfMatch:  requireIfMatch,
+		logger:          logger,
+	}
+}
+
+func (h *PlaylistHandler) log(c *gin.Context) *logrus.Entry {
+	return h.logger.WithContext(c.Request.Context())
+}
+
+// ID из параметра пути name; при ошибке ответ уже отправлен
+func (h *PlaylistHandler) parseID(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 64)
+	if err != nil || id == 0 {
+		h.log(c).Debugf("parseID: invalid %s: %q", name, c.Param(name))
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid %s", name)})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// HTTP-статус для ошибок плейлистов
+func playlistErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrPlaylistNotFound), errors.Is(err, domain.ErrPlaylistItemNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrInvalidPosition), errors.Is(err, domain.ErrInvalidReorder):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrSongNotFound):
+		// Песня указана в теле запроса, а не в пути
+		return http.StatusUnprocessableEntity
+	default:
+		return errorStatus(err)
+	}
+}
+
+// Ответ с плейлистом и его ETag
+func (h *PlaylistHandler) respond(c *gin.Context, handler string, status int, playlist *models.Playlist, err error) {
+	if err = ifMatchError(c, err, domain.ErrPlaylistNotFound); err != nil {
+		h.log(c).Debugf("%s: %v", handler, err)
+		c.JSON(playlistErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("ETag", versionETag(playlist.Version))
+	c.JSON(status, gin.H{"data": playlist})
+}
+
+// @Summary Create playlist
+// @Description Create an empty playlist
+// @Tags playlists
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param playlist body models.PlaylistRequest true "Playlist"
+// @Success 201 {object} models.Playlist "Playlist created"
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/playlists [post]
+func (h *PlaylistHandler) CreatePlaylistHandler(c *gin.Context) {
+	var req models.PlaylistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Debugf("CreatePlaylistHandler: invalid request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	playlist, err := h.playlistService.CreatePlaylist(c.Request.Context(), req)
+	if err == nil {
+		c.Header("Location", fmt.Sprintf("/api/v1/playlists/%d", playlist.ID))
+	}
+	h.respond(c, "CreatePlaylistHandler", http.StatusCreated, playlist, err)
+}
+
+// @Summary List playlists
+// @Description List playlists without their songs, oldest first
+// @Tags playlists
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param pageSize query int false "Number of items per page" default(10)
+// @Success 200 {array} models.Playlist "Playlists"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/playlists [get]
+func (h *PlaylistHandler) ListPlaylistsHandler(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	playlists, err := h.playlistService.ListPlaylists(c.Request.Context(), page, pageSize)
+	if err != nil {
+		h.log(c).Debugf("ListPlaylistsHandler: failed to list playlists: %v", err)
+		c.JSON(playlistErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"playlists": playlists})
+}
+
+// @Summary Get playlist
+// @Description Playlist with its songs in order
+// @Tags playlists
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Playlist ID"
+// @Param If-None-Match header string false "ETag of a cached playlist version"
+// @Success 200 {object} models.Playlist "Playlist"
+// @Success 304 "Playlist not modified"
+// @Failure 400 {object} map[string]interface{} "Invalid playlist ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Playlist not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/playlists/{id} [get]
+func (h *PlaylistHandler) GetPlaylistHandler(c *gin.Context) {
+	id, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	playlist, err := h.playlistService.GetPlaylist(c.Request.Context(), id)
+	if err == nil {
+		etag := versionETag(playlist.Version)
+		if header := c.GetHeader("If-None-Match"); header != "" && matchesIfNoneMatch(header, etag) {
+			c.Header("ETag", etag)
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+	h.respond(c, "GetPlaylistHandler", http.StatusOK, playlist, err)
+}
+
+// @Summary Update playlist
+// @Description Replace the name and description of a playlist
+// @Tags playlists
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Playlist ID"
+// @Param If-Match header string false "ETag of the playlist version to change"
+// @Param playlist body models.PlaylistRequest true "Playlist"
+// @Success 200 {object} models.Playlist "Playlist updated"
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Playlist not found"
+// @Failure 412 {object} map[string]interface{} "Playlist version does not match If-Match or the playlist is missing with If-Match: *"
+// @Failure 428 {object} map[string]interface{} "If-Match required"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/playlists/{id} [put]
+func (h *PlaylistHandler) UpdatePlaylistHandler(c *gin.Context) {
+	id, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(c, h.requireIfMatch)
+	if !ok {
+		return
+	}
+
+	var req models.PlaylistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Debugf("UpdatePlaylistHandler: invalid request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	playlist, err := h.playlistService.UpdatePlaylist(c.Request.Context(), id, req, version)
+	h.respond(c, "UpdatePlaylistHandler", http.StatusOK, playlist, err)
+}
+
+// @Summary Delete playlist
+// @Description Delete a playlist; its songs stay in the library
+// @Tags playlists
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Playlist ID"
+// @Param If-Match header string false "ETag of the playlist version to delete"
+// @Success 200 {object} map[string]interface{} "Playlist deleted"
+// @Failure 400 {object} map[string]interface{} "Invalid playlist ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Playlist not found"
+// @Failure 412 {object} map[string]interface{} "Playlist version does not match If-Match or the playlist is missing with If-Match: *"
+// @Failure 428 {object} map[string]interface{} "If-Match required"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/playlists/{id} [delete]
+func (h *PlaylistHandler) DeletePlaylistHandler(c *gin.Context) {
+	id, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(c, h.requireIfMatch)
+	if !ok {
+		return
+	}
+
+	err := ifMatchError(c, h.playlistService.DeletePlaylist(c.Request.Context(), id, version), domain.ErrPlaylistNotFound)
+	if err != nil {
+		h.log(c).Debugf("DeletePlaylistHandler: %v", err)
+		c.JSON(playlistErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Playlist deleted successfully"})
+}
+
+// @Summary Add song to playlist
+// @Description Insert a library song at position (1-based); without position the song is appended.
+// @Description Songs after the position move down by one. A song can be added more than once.
+// @Tags playlists
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Playlist ID"
+// @Param If-Match header string false "ETag of the playlist version to change"
+// @Param item body models.AddPlaylistItemRequest true "Song and position"
+// @Success 200 {object} models.Playlist "Playlist with songs"
+// @Failure 400 {object} map[string]interface{} "Invalid input or position"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Playlist not found"
+// @Failure 412 {object} map[string]interface{} "Playlist version does not match If-Match or the playlist is missing with If-Match: *"
+// @Failure 422 {object} map[string]interface{} "Song not found"
+// @Failure 428 {object} map[string]interface{} "If-Match required"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/playlists/{id}/items [post]
+func (h *PlaylistHandler) AddItemHandler(c *gin.Context) {
+	id, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(c, h.requireIfMatch)
+	if !ok {
+		return
+	}
+
+	var req models.AddPlaylistItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Debugf("AddItemHandler: invalid request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	playlist, err := h.playlistService.AddItem(c.Request.Context(), id, req, version)
+	h.respond(c, "AddItemHandler", http.StatusOK, playlist, err)
+}
+
+// @Summary Remove song from playlist
+// @Description Remove a playlist item; songs after it move up by one
+// @Tags playlists
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Playlist ID"
+// @Param itemId path int true "Playlist item ID"
+// @Param If-Match header string false "ETag of the playlist version to change"
+// @Success 200 {object} models.Playlist "Playlist with songs"
+// @Failure 400 {object} map[string]interface{} "Invalid ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Playlist or item not found"
+// @Failure 412 {object} map[string]interface{} "Playlist version does not match If-Match or the playlist is missing with If-Match: *"
+// @Failure 428 {object} map[string]interface{} "If-Match required"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/playlists/{id}/items/{itemId} [delete]
+func (h *PlaylistHandler) RemoveItemHandler(c *gin.Context) {
+	id, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+	itemId, ok := h.parseID(c, "itemId")
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(c, h.requireIfMatch)
+	if !ok {
+		return
+	}
+
+	playlist, err := h.playlistService.RemoveItem(c.Request.Context(), id, itemId, version)
+	h.respond(c, "RemoveItemHandler", http.StatusOK, playlist, err)
+}
+
+// @Summary Move song within playlist
+// @Description Move a playlist item to position (1-based); songs in between shift by one
+// @Tags playlists
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Playlist ID"
+// @Param itemId path int true "Playlist item ID"
+// @Param If-Match header string false "ETag of the playlist version to change"
+// @Param move body models.MovePlaylistItemRequest true "New position"
+// @Success 200 {object} models.Playlist "Playlist with songs"
+// @Failure 400 {object} map[string]interface{} "Invalid input or position"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Playlist or item not found"
+// @Failure 412 {object} map[string]interface{} "Playlist version does not match If-Match or the playlist is missing with If-Match: *"
+// @Failure 428 {object} map[string]interface{} "If-Match required"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/playlists/{id}/items/{itemId}/move [post]
+func (h *PlaylistHandler) MoveItemHandler(c *gin.Context) {
+	id, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+	itemId, ok := h.parseID(c, "itemId")
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(c, h.requireIfMatch)
+	if !ok {
+		return
+	}
+
+	var req models.MovePlaylistItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Debugf("MoveItemHandler: invalid request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	playlist, err := h.playlistService.MoveItem(c.Request.Context(), id, itemId, req.Position, version)
+	h.respond(c, "MoveItemHandler", http.StatusOK, playlist, err)
+}
+
+// @Summary Reorder playlist
+// @Description Set the order of all songs at once; itemIds must list every item of the playlist exactly once
+// @Tags playlists
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Playlist ID"
+// @Param If-Match header string false "ETag of the playlist version to change"
+// @Param order body models.ReorderPlaylistRequest true "Item IDs in the new order"
+// @Success 200 {object} models.Playlist "Playlist with songs"
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Playlist not found"
+// @Failure 412 {object} map[string]interface{} "Playlist version does not match If-Match or the playlist is missing with If-Match: *"
+// @Failure 428 {object} map[string]interface{} "If-Match required"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/playlists/{id}/items [put]
+func (h *PlaylistHandler) ReorderItemsHandler(c *gin.Context) {
+	id, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(c, h.requireIfMatch)
+	if !ok {
+		return
+	}
+
+	var req models.ReorderPlaylistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Debugf("ReorderItemsHandler: invalid request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	playlist, err := h.playlistService.ReorderItems(c.Request.Context(), id, req.ItemIDs, version)
+	h.respond(c, "ReorderItemsHandler", http.StatusOK, playlist, err)
+}
+
+// Переводы строк разорвали бы строку M3U
+var m3uLine = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+// Название трека для плейлиста: "Группа - Песня"
+func trackTitle(song *models.Song) string {
+	return m3uLine.Replace(song.GroupName + " - " + song.SongName)
+}
+
+// Запись плейлиста в формате extended M3U; песни без ссылки пропускаются
+func writeM3U(w io.Writer, playlist *models.Playlist) error {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	fmt.Fprintf(&b, "#PLAYLIST:%s\n", m3uLine.Replace(playlist.Name))
+	for _, item := range playlist.Items {
+		if item.Song == nil || item.Song.Link == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "#EXTINF:-1,%s\n%s\n", trackTitle(item.Song), item.Song.Link)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Документ XSPF (https://xspf.org/spec)
+type xspfPlaylist struct {
+	XMLName    xml.Name    `xml:"http://xspf.org/ns/0/ playlist"`
+	Version    string      `xml:"version,attr"`
+	Title      string      `xml:"title"`
+	Annotation string      `xml:"annotation,omitempty"`
+	Tracks     []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location string `xml:"location,omitempty"`
+	Creator  string `xml:"creator"`
+	Title    string `xml:"title"`
+}
+
+// Запись плейлиста в формате XSPF; у песен без ссылки нет location
+func writeXSPF(w io.Writer, playlist *models.Playlist) error {
+	doc := xspfPlaylist{Version: "1", Title: playlist.Name, Annotation: playlist.Description, Tracks: []xspfTrack{}}
+	for _, item := range playlist.Items {
+		if item.Song == nil {
+			continue
+		}
+		doc.Tracks = append(doc.Tracks, xspfTrack{Location: item.Song.Link, Creator: item.Song.GroupName, Title: item.Song.SongName})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// @Summary Export playlist
+// @Description Export a playlist for media players using each song's link. M3U skips songs without a link,
+// @Description XSPF lists them without a location.
+// @Tags playlists
+// @Security BearerAuth
+// @Produce audio/x-mpegurl
+// @Produce application/xspf+xml
+// @Param id path int true "Playlist ID"
+// @Param format query string false "m3u or xspf" default(m3u)
+// @Success 200 {string} string "Playlist file"
+// @Failure 400 {object} map[string]interface{} "Invalid playlist ID or format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Playlist not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/playlists/{id}/export [get]
+func (h *PlaylistHandler) ExportPlaylistHandler(c *gin.Context) {
+	id, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var write func(io.Writer, *models.Playlist) error
+	var contentType string
+	format := c.DefaultQuery("format", "m3u")
+	switch format {
+	case "m3u":
+		write, contentType = writeM3U, contentTypeM3U
+	case "xspf":
+		write, contentType = writeXSPF, contentTypeXSPF
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown playlist format %q, expected m3u or xspf", format)})
+		return
+	}
+
+	playlist, err := h.playlistService.GetPlaylist(c.Request.Context(), id)
+	if err != nil {
+		h.log(c).Debugf("ExportPlaylistHandler: %v", err)
+		c.JSON(playlistErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", contentType+"; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="playlist-%d.%s"`, playlist.ID, format))
+	c.Header("ETag", versionETag(playlist.Version))
+	c.Status(http.StatusOK)
+	if err := write(c.Writer, playlist); err != nil {
+		h.log(c).Debugf("ExportPlaylistHandler: failed to write playlist: %v", err)
+	}
+}
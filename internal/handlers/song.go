@@ -1,37 +1,69 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 
 	"github.com/ananikitina/song_lib/internal/models"
 	"github.com/ananikitina/song_lib/internal/service"
+	"github.com/ananikitina/song_lib/internal/service/domain"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
 type SongHandler struct {
-	songService service.SongService
-	logger      *logrus.Logger
+	songService          service.SongService
+	changeRequestService service.ChangeRequestService
+	logger               *logrus.Logger
+	requireIfMatch       bool
 }
 
-func NewSongHandler(songService service.SongService, logger *logrus.Logger) *SongHandler {
+func NewSongHandler(songService service.SongService, changeRequestService service.ChangeRequestService, requireIfMatch bool, logger *logrus.Logger) *SongHandler {
 	return &SongHandler{
-		songService: songService,
-		logger:      logger,
+		songService:          songService,
+		changeRequestService: changeRequestService,
+		logger:               logger,
+		requireIfMatch:       requireIfMatch,
 	}
 }
 
+// Логгер с контекстом запроса (request ID, trace ID)
+func (h *SongHandler) log(c *gin.Context) *logrus.Entry {
+	return h.logger.WithContext(c.Request.Context())
+}
+
 // Получение ID песни
 func (h *SongHandler) parseSongID(c *gin.Context) (uint, error) {
 	songID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		h.logger.Debugf("parseSongID: invalid song ID: %v", err)
+		h.log(c).Debugf("parseSongID: invalid song ID: %v", err)
 		return 0, err
 	}
 	return uint(songID), nil
 }
 
+// Параметры списка песен, не являющиеся фильтрами
+var songListParams = []string{"page", "pageSize", "fields", "include"}
+
+// Фильтры песен из параметров запроса, кроме skip; tag можно указать несколько раз
+func songFilters(c *gin.Context, skip ...string) map[string]interface{} {
+	filters := make(map[string]interface{})
+	for key, values := range c.Request.URL.Query() {
+		if slices.Contains(skip, key) {
+			continue
+		}
+		if key == "tag" {
+			filters[key] = values
+			continue
+		}
+		filters[key] = values[0]
+	}
+	return filters
+}
+
 // Получение параметров пагинации
 func (h *SongHandler) getPaginationParams(c *gin.Context) (int, int) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
@@ -50,44 +82,78 @@ func (h *SongHandler) getPaginationParams(c *gin.Context) (int, int) {
 // @Summary Add new song
 // @Description Add a new song with a group
 // @Tags songs
+// @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param request body models.AddSongRequest true "Add song request"
 // @Success 201 {object} models.Song "Song added"
 // @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 409 {object} map[string]interface{} "Song with this group and name already exists"
+// @Failure 422 {object} map[string]interface{} "Song details failed validation"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
 // @Failure 500 {object} map[string]interface{} "Internal Server Error"
-// @Router /add-song [post]
+// @Router /api/v1/songs [post]
 func (h *SongHandler) AddSongHandler(c *gin.Context) {
 	var req models.AddSongRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Debugf("AddSongHandler: invalid request: %v", err)
+		h.log(c).Debugf("AddSongHandler: invalid request: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	h.logger.Infof("AddSongHandler: adding song: %s, and group: %s", req.Song, req.Group)
+	h.log(c).Infof("AddSongHandler: adding song: %s, and group: %s", req.Song, req.Group)
 	song, err := h.songService.AddSong(c.Request.Context(), req.Group, req.Song)
+	var validationErr *domain.ValidationError
+	if errors.As(err, &validationErr) {
+		h.log(c).Debugf("AddSongHandler: song details rejected: %v", err)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "validation": validationErr.Report})
+		return
+	}
 	if err != nil {
-		h.logger.Debugf("AddSongHandler: failed to add song: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.log(c).Debugf("AddSongHandler: failed to add song: %v", err)
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	h.logger.Infof("AddSongHandler: song added successfully")
+	h.log(c).Infof("AddSongHandler: song added successfully")
+	c.Header("Location", fmt.Sprintf("/api/v1/songs/%d", song.ID))
+	c.Header("ETag", songETag(song))
 	c.JSON(http.StatusCreated, gin.H{"data": song})
 }
 
-// @Summary Update song
-// @Description Update song details by ID
+// Обновление непустых полей песни для устаревшего маршрута PUT /update-song/{id};
+// новые клиенты используют PUT и PATCH /api/v1/songs/{id}
+//
+// @Summary Update song (deprecated)
+// @Description Deprecated: use PUT or PATCH /api/v1/songs/{id}, removed after LEGACY_ROUTES_SUNSET.
+// @Description Changes only the non-empty fields of a song.
+// @Description Clients without songs:write but with changes:propose create a pending change request.
 // @Tags songs
+// @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param id path int true "Song ID"
-// @Param song body models.Song true "Song details to update"
+// @Param If-Match header string false "ETag of the song version to change"
+// @Param song body models.SongFieldsUpdate true "Song fields to change"
 // @Success 200 {object} models.Song "Song updated"
+// @Success 202 {object} models.ChangeRequest "Change request created"
 // @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Song not found"
+// @Failure 409 {object} map[string]interface{} "Song with this group and name already exists"
+// @Failure 412 {object} map[string]interface{} "Song version does not match If-Match or the song is missing with If-Match: *"
+// @Failure 422 {object} map[string]interface{} "Song fields failed validation"
+// @Failure 428 {object} map[string]interface{} "If-Match required"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
 // @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Header all {string} Deprecation "Date the route was deprecated (RFC 9745)"
+// @Header all {string} Sunset "Date after which the route is removed (RFC 8594)"
+// @Header all {string} Link "Successor route with rel=successor-version"
+// @Deprecated
 // @Router /update-song/{id} [put]
 func (h *SongHandler) UpdateSongHandler(c *gin.Context) {
 	songID, err := h.parseSongID(c)
@@ -96,34 +162,38 @@ func (h *SongHandler) UpdateSongHandler(c *gin.Context) {
 		return
 	}
 
-	var updateReq models.Song
-	if err := c.ShouldBindJSON(&updateReq); err != nil {
-		h.logger.Debugf("UpdateSongHandler: invalid request: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	version, ok := h.expectedVersion(c)
+	if !ok {
 		return
 	}
 
-	h.logger.Infof("UpdateSongHandler: updating song with ID: %d", songID)
-	updatedSong, err := h.songService.UpdateSong(songID, updateReq)
-	if err != nil {
-		h.logger.Debugf("UpdateSongHandler: failed to update song: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var updateReq models.SongFieldsUpdate
+	if err := c.ShouldBindJSON(&updateReq); err != nil {
+		h.log(c).Debugf("UpdateSongHandler: invalid request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	h.logger.Infof("UpdateSongHandler: song updated with ID: %d", songID)
-	c.JSON(http.StatusOK, gin.H{"data": updatedSong})
+	h.updateSong(c, "UpdateSongHandler", songID, updateReq.Patch(), version)
 }
 
 // @Summary Delete song
 // @Description Delete song by ID
 // @Tags songs
+// @Security BearerAuth
 // @Produce json
 // @Param id path int true "Song ID"
+// @Param If-Match header string false "ETag of the song version to delete"
 // @Success 200 {object} map[string]interface{} "Song deleted"
 // @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Song not found"
+// @Failure 412 {object} map[string]interface{} "Song version does not match If-Match or the song is missing with If-Match: *"
+// @Failure 428 {object} map[string]interface{} "If-Match required"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
 // @Failure 500 {object} map[string]interface{} "Internal Server Error"
-// @Router /delete-song/{id} [delete]
+// @Router /api/v1/songs/{id} [delete]
 func (h *SongHandler) DeleteSongHandler(c *gin.Context) {
 	songID, err := h.parseSongID(c)
 	if err != nil {
@@ -131,59 +201,140 @@ func (h *SongHandler) DeleteSongHandler(c *gin.Context) {
 		return
 	}
 
-	h.logger.Infof("DeleteSongHandler: deleting song with ID: %d", songID)
-	err = h.songService.DeleteSong(songID)
+	version, ok := h.expectedVersion(c)
+	if !ok {
+		return
+	}
+
+	h.log(c).Infof("DeleteSongHandler: deleting song with ID: %d", songID)
+	err = ifMatchError(c, h.songService.DeleteSong(c.Request.Context(), songID, version), domain.ErrSongNotFound)
 	if err != nil {
-		h.logger.Errorf("DeleteSongHandler: failed to delete song: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.log(c).Errorf("DeleteSongHandler: failed to delete song: %v", err)
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	h.logger.Infof("DeleteSongHandler: song deleted with ID: %d", songID)
+	h.log(c).Infof("DeleteSongHandler: song deleted with ID: %d", songID)
 	c.JSON(http.StatusOK, gin.H{"message": "Song deleted successfully"})
 }
 
+// @Summary Get song
+// @Description Retrieve a song by ID. Use fields= to return only some fields (id is always returned)
+// @Description and include= to embed related data.
+// @Tags songs
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param fields query string false "Comma-separated fields to return, e.g. id,group,song"
+// @Param include query string false "Comma-separated related data: versesCount"
+// @Param If-None-Match header string false "ETag of a cached song version"
+// @Success 200 {object} models.Song "Song"
+// @Success 304 "Song not modified"
+// @Failure 400 {object} map[string]interface{} "Invalid song ID or field selection"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Song not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/songs/{id} [get]
+func (h *SongHandler) GetSongHandler(c *gin.Context) {
+	songID, err := h.parseSongID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid song ID"})
+		return
+	}
+
+	selection, err := parseSongSelection(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	song, err := h.songService.GetSongById(c.Request.Context(), songID)
+	if err != nil {
+		h.log(c).Debugf("GetSongHandler: failed to get song: %v", err)
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	etag := songETag(song)
+	c.Header("ETag", etag)
+	if header := c.GetHeader("If-None-Match"); header != "" && matchesIfNoneMatch(header, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	if selection == nil {
+		c.JSON(http.StatusOK, gin.H{"data": song})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": selection.apply(*song)})
+}
+
 // @Summary Get all songs
 // @Description Retrieve a list of all songs with optional filters and pagination
 // @Tags songs
+// @Security BearerAuth
 // @Produce json
 // @Param page query int false "Page number" default(1)
 // @Param pageSize query int false "Number of items per page" default(10)
 // @Param filters query string false "Additional filters"
+// @Param tag query []string false "Tags, repeated or comma-separated" collectionFormat(multi)
+// @Param tagMatch query string false "Match any (default) or all of the tags" Enums(any, all)
+// @Param credit query string false "Credited person, case-insensitive"
+// @Param creditRole query string false "Role of the credited person" Enums(artist, featuring, composer, lyricist)
+// @Param fields query string false "Comma-separated fields to return, e.g. id,group,song"
+// @Param include query string false "Comma-separated related data: versesCount"
 // @Success 200 {array} models.Song "List of songs"
+// @Failure 400 {object} map[string]interface{} "Invalid field selection"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
 // @Failure 500 {object} map[string]interface{} "Internal Server Error"
-// @Router /songs [get]
+// @Router /api/v1/songs [get]
 func (h *SongHandler) GetAllSongsHandler(c *gin.Context) {
-	h.logger.Info("GetAllSongsHandler: fetching all songs")
-	filters := make(map[string]interface{})
-	for key, values := range c.Request.URL.Query() {
-		filters[key] = values[0]
+	h.log(c).Info("GetAllSongsHandler: fetching all songs")
+	selection, err := parseSongSelection(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
+	filters := songFilters(c, songListParams...)
+
 	page, pageSize := h.getPaginationParams(c)
 
-	songs, err := h.songService.GetSongsWithFiltersAndPagination(filters, page, pageSize)
+	songs, err := h.songService.GetSongsWithFiltersAndPagination(c.Request.Context(), filters, selection.columns(), page, pageSize)
 	if err != nil {
-		h.logger.Debugf("GetAllSongsHandler: failed to fetch songs: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.log(c).Debugf("GetAllSongsHandler: failed to fetch songs: %v", err)
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	h.logger.Infof("GetAllSongsHandler: fetched %d songs", len(songs))
-	c.JSON(http.StatusOK, gin.H{"songs": songs})
+	h.log(c).Infof("GetAllSongsHandler: fetched %d songs", len(songs))
+	if selection == nil {
+		c.JSON(http.StatusOK, gin.H{"songs": songs})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"songs": selection.applyAll(songs)})
 }
 
 // @Summary Get song verses with pagination
 // @Description Retrieve a paginated list of verses for a specific song by ID
 // @Tags songs
+// @Security BearerAuth
 // @Produce json
 // @Param id path int true "Song ID"
 // @Param page query int false "Page number" default(1)
 // @Param pageSize query int false "Number of items per page" default(10)
 // @Success 200 {object} map[string]interface{} "Verses data"
 // @Failure 400 {object} map[string]interface{} "Invalid song ID"
+// @Failure 404 {object} map[string]interface{} "Song not found"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
 // @Failure 500 {object} map[string]interface{} "Internal Server Error"
-// @Router /songs/{id}/verses [get]
+// @Router /api/v1/songs/{id}/verses [get]
 func (h *SongHandler) GetSongVersesWithPaginationHandler(c *gin.Context) {
 	songID, err := h.parseSongID(c)
 	if err != nil {
@@ -193,14 +344,119 @@ func (h *SongHandler) GetSongVersesWithPaginationHandler(c *gin.Context) {
 
 	page, pageSize := h.getPaginationParams(c)
 
-	h.logger.Infof("GetSongVersesWithPaginationHandler: fetching verses for song ID: %d", songID)
-	verses, err := h.songService.GetSongVersesWithPagination(songID, page, pageSize)
+	h.log(c).Infof("GetSongVersesWithPaginationHandler: fetching verses for song ID: %d", songID)
+	verses, err := h.songService.GetSongVersesWithPagination(c.Request.Context(), songID, page, pageSize)
 	if err != nil {
-		h.logger.Debugf("GetSongVersesWithPaginationHandler: failed to fetch verses: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.log(c).Debugf("GetSongVersesWithPaginationHandler: failed to fetch verses: %v", err)
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	h.logger.Infof("GetSongVersesWithPaginationHandler: fetched %d verses for song ID: %d", len(verses), songID)
+	h.log(c).Infof("GetSongVersesWithPaginationHandler: fetched %d verses for song ID: %d", len(verses), songID)
 	c.JSON(http.StatusOK, gin.H{"verses": verses})
 }
+
+// Устаревшие маршруты без версии: обработчики /api/v1 под прежними путями,
+// описанные отдельно, чтобы документация отмечала их устаревшими
+
+// @Summary Add new song (deprecated)
+// @Description Deprecated alias of POST /api/v1/songs, removed after LEGACY_ROUTES_SUNSET.
+// @Tags songs
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.AddSongRequest true "Add song request"
+// @Success 201 {object} models.Song "Song added"
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 409 {object} map[string]interface{} "Song with this group and name already exists"
+// @Failure 422 {object} map[string]interface{} "Song details failed validation"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Header all {string} Deprecation "Date the route was deprecated (RFC 9745)"
+// @Header all {string} Sunset "Date after which the route is removed (RFC 8594)"
+// @Header all {string} Link "Successor route with rel=successor-version"
+// @Deprecated
+// @Router /add-song [post]
+func (h *SongHandler) LegacyAddSongHandler(c *gin.Context) {
+	h.AddSongHandler(c)
+}
+
+// @Summary Delete song (deprecated)
+// @Description Deprecated alias of DELETE /api/v1/songs/{id}, removed after LEGACY_ROUTES_SUNSET.
+// @Tags songs
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param If-Match header string false "ETag of the song version to delete"
+// @Success 200 {object} map[string]interface{} "Song deleted"
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Song not found"
+// @Failure 412 {object} map[string]interface{} "Song version does not match If-Match or the song is missing with If-Match: *"
+// @Failure 428 {object} map[string]interface{} "If-Match required"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Header all {string} Deprecation "Date the route was deprecated (RFC 9745)"
+// @Header all {string} Sunset "Date after which the route is removed (RFC 8594)"
+// @Header all {string} Link "Successor route with rel=successor-version"
+// @Deprecated
+// @Router /delete-song/{id} [delete]
+func (h *SongHandler) LegacyDeleteSongHandler(c *gin.Context) {
+	h.DeleteSongHandler(c)
+}
+
+// @Summary Get all songs (deprecated)
+// @Description Deprecated alias of GET /api/v1/songs, removed after LEGACY_ROUTES_SUNSET.
+// @Tags songs
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param pageSize query int false "Number of items per page" default(10)
+// @Param filters query string false "Additional filters"
+// @Param tag query []string false "Tags, repeated or comma-separated" collectionFormat(multi)
+// @Param tagMatch query string false "Match any (default) or all of the tags" Enums(any, all)
+// @Param credit query string false "Credited person, case-insensitive"
+// @Param creditRole query string false "Role of the credited person" Enums(artist, featuring, composer, lyricist)
+// @Param fields query string false "Comma-separated fields to return, e.g. id,group,song"
+// @Param include query string false "Comma-separated related data: versesCount"
+// @Success 200 {array} models.Song "List of songs"
+// @Failure 400 {object} map[string]interface{} "Invalid field selection"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Header all {string} Deprecation "Date the route was deprecated (RFC 9745)"
+// @Header all {string} Sunset "Date after which the route is removed (RFC 8594)"
+// @Header all {string} Link "Successor route with rel=successor-version"
+// @Deprecated
+// @Router /songs [get]
+func (h *SongHandler) LegacyGetAllSongsHandler(c *gin.Context) {
+	h.GetAllSongsHandler(c)
+}
+
+// @Summary Get song verses with pagination (deprecated)
+// @Description Deprecated alias of GET /api/v1/songs/{id}/verses, removed after LEGACY_ROUTES_SUNSET.
+// @Tags songs
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param page query int false "Page number" default(1)
+// @Param pageSize query int false "Number of items per page" default(10)
+// @Success 200 {object} map[string]interface{} "Verses data"
+// @Failure 400 {object} map[string]interface{} "Invalid song ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Song not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Header all {string} Deprecation "Date the route was deprecated (RFC 9745)"
+// @Header all {string} Sunset "Date after which the route is removed (RFC 8594)"
+// @Header all {string} Link "Successor route with rel=successor-version"
+// @Deprecated
+// @Router /songs/{id}/verses [get]
+func (h *SongHandler) LegacyGetSongVersesHandler(c *gin.Context) {
+	h.GetSongVersesWithPaginationHandler(c)
+}
@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/gin-gonic/gin"
+
+	"github.com/ananikitina/song_lib/internal/identity"
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/service/domain"
+)
+
+// Форматы тела запроса PATCH
+const (
+	contentTypeMergePatch = "application/merge-patch+json"
+	contentTypeJSONPatch  = "application/json-patch+json"
+)
+
+// Ошибка разбора изменения с HTTP-статусом ответа
+type patchError struct {
+	status int
+	err    error
+}
+
+func (e *patchError) Error() string {
+	return e.err.Error()
+}
+
+// Разбор JSON Merge Patch (RFC 7396): отсутствующие поля не меняются, null очищает поле
+func decodeMergePatch(body []byte) (models.SongPatch, error) {
+	var patch models.SongPatch
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patch); err != nil {
+		return patch, &patchError{status: http.StatusBadRequest, err: fmt.Errorf("invalid merge patch: %w", err)}
+	}
+	return patch, nil
+}
+
+// Изменяемые поля песни в виде JSON-документа; пустые поля представлены null
+func songDocument(song *models.Song) map[string]interface{} {
+	doc := make(map[string]interface{}, len(models.SongPatchFields))
+	for name, value := range map[string]string{
+		"group":       song.GroupName,
+		"song":        song.SongName,
+		"releaseDate": song.ReleaseDate,
+		"text":        song.Text,
+		"link":        song.Link,
+	} {
+		if value == "" {
+			doc[name] = nil
+		} else {
+			doc[name] = value
+		}
+	}
+	return doc
+}
+
+// Применение JSON Patch (RFC 6902) к изменяемым полям песни.
+// Результат приводится к merge patch из измененных полей; удаленное поле очищается.
+func decodeJSONPatch(body []byte, song *models.Song) (models.SongPatch, error) {
+	operations, err := jsonpatch.DecodePatch(body)
+	if err != nil {
+		return models.SongPatch{}, &patchError{status: http.StatusBadRequest, err: fmt.Errorf("invalid json patch: %w", err)}
+	}
+
+	original := songDocument(song)
+	doc, err := json.Marshal(original)
+	if err != nil {
+		return models.SongPatch{}, err
+	}
+	patched, err := operations.Apply(doc)
+	switch {
+	case errors.Is(err, jsonpatch.ErrTestFailed):
+		return models.SongPatch{}, &patchError{status: http.StatusConflict, err: err}
+	case err != nil:
+		return models.SongPatch{}, &patchError{status: http.StatusUnprocessableEntity, err: fmt.Errorf("failed to apply json patch: %w", err)}
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(patched, &result); err != nil {
+		return models.SongPatch{}, &patchError{status: http.StatusUnprocessableEntity, err: fmt.Errorf("failed to apply json patch: %w", err)}
+	}
+
+	// Только измененные поля, чтобы не затрагивать остальные
+	changed := make(map[string]interface{})
+	for name, value := range result {
+		if _, ok := original[name]; !ok {
+			return models.SongPatch{}, &patchError{status: http.StatusBadRequest, err: fmt.Errorf("invalid json patch: unknown field %q", name)}
+		}
+		if value != original[name] {
+			changed[name] = value
+		}
+	}
+	for name := range original {
+		if _, ok := result[name]; !ok && original[name] != nil {
+			changed[name] = nil
+		}
+	}
+
+	mergePatch, err := json.Marshal(changed)
+	if err != nil {
+		return models.SongPatch{}, err
+	}
+	return decodeMergePatch(mergePatch)
+}
+
+// Чтение изменения песни в формате, указанном в Content-Type. Для JSON Patch возвращается
+// также версия песни, к которой применены операции, иначе 0.
+func (h *SongHandler) readSongPatch(c *gin.Context, songID uint) (models.SongPatch, int, error) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return models.SongPatch{}, 0, &patchError{status: http.StatusBadRequest, err: fmt.Errorf("failed to read request body: %w", err)}
+	}
+
+	switch contentType := c.ContentType(); contentType {
+	case contentTypeMergePatch, gin.MIMEJSON, "":
+		patch, err := decodeMergePatch(body)
+		return patch, 0, err
+	case contentTypeJSONPatch:
+		song, err := h.songService.GetSongById(c.Request.Context(), songID)
+		if err != nil {
+			return models.SongPatch{}, 0, err
+		}
+		patch, err := decodeJSONPatch(body, song)
+		return patch, song.Version, err
+	default:
+		return models.SongPatch{}, 0, &patchError{
+			status: http.StatusUnsupportedMediaType,
+			err:    fmt.Errorf("unsupported content type %q, expected %s or %s", contentType, contentTypeMergePatch, contentTypeJSONPatch),
+		}
+	}
+}
+
+// Применение изменения или, для участников без права записи, создание запроса на изменение
+func (h *SongHandler) updateSong(c *gin.Context, handler string, songID uint, patch models.SongPatch, version int) {
+	if principal := identity.PrincipalFromContext(c.Request.Context()); principal != nil && !principal.HasScope(models.ScopeSongsWrite) {
+		h.log(c).Infof("%s: proposing changes to song with ID: %d", handler, songID)
+		request, err := h.changeRequestService.ProposeChange(c.Request.Context(), songID, models.SongChangesFrom(patch), version)
+		err = ifMatchError(c, err, domain.ErrSongNotFound)
+		if err != nil {
+			h.log(c).Debugf("%s: failed to propose changes: %v", handler, err)
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"data": request})
+		return
+	}
+
+	h.log(c).Infof("%s: updating song with ID: %d", handler, songID)
+	updatedSong, err := h.songService.UpdateSong(c.Request.Context(), songID, patch, version)
+	err = ifMatchError(c, err, domain.ErrSongNotFound)
+	var validationErr *domain.ValidationError
+	if errors.As(err, &validationErr) {
+		h.log(c).Debugf("%s: update rejected: %v", handler, err)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "validation": validationErr.Report})
+		return
+	}
+	if err != nil {
+		h.log(c).Debugf("%s: failed to update song: %v", handler, err)
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.log(c).Infof("%s: song updated with ID: %d", handler, songID)
+	c.Header("ETag", songETag(updatedSong))
+	c.JSON(http.StatusOK, gin.H{"data": updatedSong})
+}
+
+// @Summary Patch song
+// @Description Partially update a song. Accepts JSON Merge Patch (RFC 7396, application/merge-patch+json
+// @Description or application/json), where null clears a field, and JSON Patch (RFC 6902,
+// @Description application/json-patch+json) over the fields group, song, releaseDate, text and link.
+// @Description Group and song cannot be cleared. Clients without songs:write but with changes:propose
+// @Description create a pending change request that an editor has to approve.
+// @Tags songs
+// @Security BearerAuth
+// @Accept json
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param If-Match header string false "ETag of the song version to change"
+// @Param patch body models.SongPatch true "Merge patch or JSON Patch operations"
+// @Success 200 {object} models.Song "Song updated"
+// @Success 202 {object} models.ChangeRequest "Change request created"
+// @Failure 400 {object} map[string]interface{} "Invalid patch"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Song not found"
+// @Failure 409 {object} map[string]interface{} "JSON Patch test operation failed or song with this group and name already exists"
+// @Failure 412 {object} map[string]interface{} "Song version does not match If-Match or the song is missing with If-Match: *"
+// @Failure 415 {object} map[string]interface{} "Unsupported content type"
+// @Failure 422 {object} map[string]interface{} "Patch cannot be applied or failed validation"
+// @Failure 428 {object} map[string]interface{} "If-Match required"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/songs/{id} [patch]
+func (h *SongHandler) PatchSongHandler(c *gin.Context) {
+	songID, err := h.parseSongID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid song ID"})
+		return
+	}
+
+	version, ok := h.expectedVersion(c)
+	if !ok {
+		return
+	}
+
+	patch, snapshotVersion, err := h.readSongPatch(c, songID)
+	err = ifMatchError(c, err, domain.ErrSongNotFound)
+	var patchErr *patchError
+	if errors.As(err, &patchErr) {
+		h.log(c).Debugf("PatchSongHandler: invalid request: %v", err)
+		c.JSON(patchErr.status, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.log(c).Debugf("PatchSongHandler: failed to read patch: %v", err)
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// JSON Patch вычислен по прочитанной версии песни: без If-Match она и ожидается,
+	// чтобы изменение, сделанное после чтения, не перезаписывалось
+	switch {
+	case version == 0:
+		version = snapshotVersion
+	case snapshotVersion != 0 && snapshotVersion != version:
+		h.log(c).Debugf("PatchSongHandler: song version %d does not match If-Match %d", snapshotVersion, version)
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": domain.ErrVersionMismatch.Error()})
+		return
+	}
+
+	h.updateSong(c, "PatchSongHandler", songID, patch, version)
+}
+
+// @Summary Replace song
+// @Description Replace all editable fields of a song; omitted or empty optional fields are cleared.
+// @Description Clients without songs:write but with changes:propose create a pending change request.
+// @Tags songs
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param If-Match header string false "ETag of the song version to change"
+// @Param song body models.SongUpdate true "Song fields"
+// @Success 200 {object} models.Song "Song updated"
+// @Success 202 {object} models.ChangeRequest "Change request created"
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Song not found"
+// @Failure 409 {object} map[string]interface{} "Song with this group and name already exists"
+// @Failure 412 {object} map[string]interface{} "Song version does not match If-Match or the song is missing with If-Match: *"
+// @Failure 422 {object} map[string]interface{} "Song fields failed validation"
+// @Failure 428 {object} map[string]interface{} "If-Match required"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/songs/{id} [put]
+func (h *SongHandler) ReplaceSongHandler(c *gin.Context) {
+	songID, err := h.parseSongID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid song ID"})
+		return
+	}
+
+	version, ok := h.expectedVersion(c)
+	if !ok {
+		return
+	}
+
+	var req models.SongUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Debugf("ReplaceSongHandler: invalid request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.updateSong(c, "ReplaceSongHandler", songID, req.Patch(), version)
+}
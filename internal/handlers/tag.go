@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/service"
+	"github.com/ananikitina/song_lib/internal/service/domain"
+)
+
+type TagHandler struct {
+	tagService service.TagService
+	logger     *logrus.Logger
+}
+
+func NewTagHandler(tagService service.TagService, logger *logrus.Logger) *TagHandler {
+	return &TagHandler{
+		tagService: tagService,
+		logger:     logger,
+	}
+}
+
+func (h *TagHandler) log(c *gin.Context) *logrus.Entry {
+	return h.logger.WithContext(c.Request.Context())
+}
+
+// ID песни из пути; при ошибке ответ уже отправлен
+func songIDParam(c *gin.Context, log *logrus.Entry) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		log.Debugf("songIDParam: invalid song ID: %q", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid song ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// HTTP-статус для ошибок тегов
+func tagErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrInvalidTag):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrTagNotFound):
+		return http.StatusNotFound
+	default:
+		return errorStatus(err)
+	}
+}
+
+// Ответ с тегами песни
+func (h *TagHandler) respond(c *gin.Context, handler string, tags []string, err error) {
+	if err != nil {
+		h.log(c).Debugf("%s: %v", handler, err)
+		c.JSON(tagErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// @Summary List tags
+// @Description Tags used by at least one song, in alphabetical order, with the number of tagged songs
+// @Tags tags
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.TagCount "Tags"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/tags [get]
+func (h *TagHandler) ListTagsHandler(c *gin.Context) {
+	tags, err := h.tagService.ListTags(c.Request.Context())
+	if err != nil {
+		h.log(c).Debugf("ListTagsHandler: failed to list tags: %v", err)
+		c.JSON(tagErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// @Summary Get song tags
+// @Description Tags of a song in alphabetical order
+// @Tags tags
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {array} string "Tags"
+// @Failure 400 {object} map[string]interface{} "Invalid song ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Song not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/songs/{id}/tags [get]
+func (h *TagHandler) GetSongTagsHandler(c *gin.Context) {
+	id, ok := songIDParam(c, h.log(c))
+	if !ok {
+		return
+	}
+	tags, err := h.tagService.GetSongTags(c.Request.Context(), id)
+	h.respond(c, "GetSongTagsHandler", tags, err)
+}
+
+// @Summary Tag song
+// @Description Add tags to a song; missing tags are created. Tags are stored in lower case with single spaces.
+// @Tags tags
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param tags body models.SongTagsRequest true "Tags to add"
+// @Success 200 {array} string "All tags of the song"
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Song not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/songs/{id}/tags [post]
+func (h *TagHandler) AddSongTagsHandler(c *gin.Context) {
+	id, ok := songIDParam(c, h.log(c))
+	if !ok {
+		return
+	}
+	var req models.SongTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Debugf("AddSongTagsHandler: invalid request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tags, err := h.tagService.AddSongTags(c.Request.Context(), id, req.Tags)
+	h.respond(c, "AddSongTagsHandler", tags, err)
+}
+
+// @Summary Replace song tags
+// @Description Replace all tags of a song; an empty list removes every tag
+// @Tags tags
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param tags body models.SongTagsRequest true "New tags"
+// @Success 200 {array} string "All tags of the song"
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Song not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/songs/{id}/tags [put]
+func (h *TagHandler) SetSongTagsHandler(c *gin.Context) {
+	id, ok := songIDParam(c, h.log(c))
+	if !ok {
+		return
+	}
+	var req models.SongTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Debugf("SetSongTagsHandler: invalid request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tags, err := h.tagService.SetSongTags(c.Request.Context(), id, req.Tags)
+	h.respond(c, "SetSongTagsHandler", tags, err)
+}
+
+// @Summary Untag song
+// @Description Remove a tag from a song
+// @Tags tags
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param tag path string true "Tag"
+// @Success 200 {array} string "Remaining tags of the song"
+// @Failure 400 {object} map[string]interface{} "Invalid song ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Song or tag not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/songs/{id}/tags/{tag} [delete]
+func (h *TagHandler) RemoveSongTagHandler(c *gin.Context) {
+	id, ok := songIDParam(c, h.log(c))
+	if !ok {
+		return
+	}
+	tags, err := h.tagService.RemoveSongTag(c.Request.Context(), id, c.Param("tag"))
+	h.respond(c, "RemoveSongTagHandler", tags, err)
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/service"
+	"github.com/ananikitina/song_lib/internal/service/domain"
+)
+
+type TenantHandler struct {
+	tenantService service.TenantService
+	logger        *logrus.Logger
+}
+
+func NewTenantHandler(tenantService service.TenantService, logger *logrus.Logger) *TenantHandler {
+	return &TenantHandler{
+		tenantService: tenantService,
+		logger:        logger,
+	}
+}
+
+func (h *TenantHandler) log(c *gin.Context) *logrus.Entry {
+	return h.logger.WithContext(c.Request.Context())
+}
+
+// HTTP-статус для ошибок работы с арендаторами
+func tenantErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrTenantNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrTenantExists):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrInvalidTenant):
+		return http.StatusBadRequest
+	default:
+		return errorStatus(err)
+	}
+}
+
+// @Summary Create tenant
+// @Description Create a tenant with optional external API endpoint, song quota and rate limits
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param tenant body models.Tenant true "Tenant"
+// @Success 201 {object} models.Tenant "Tenant created"
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 409 {object} map[string]interface{} "Tenant already exists"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/admin/tenants [post]
+func (h *TenantHandler) CreateTenantHandler(c *gin.Context) {
+	var req models.Tenant
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Debugf("CreateTenantHandler: invalid request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	t, err := h.tenantService.CreateTenant(c.Request.Context(), req)
+	if err != nil {
+		h.log(c).Debugf("CreateTenantHandler: failed to create tenant: %v", err)
+		c.JSON(tenantErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": t})
+}
+
+// @Summary List tenants
+// @Description List all tenants with their settings
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Tenant "List of tenants"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/admin/tenants [get]
+func (h *TenantHandler) ListTenantsHandler(c *gin.Context) {
+	tenants, err := h.tenantService.ListTenants(c.Request.Context())
+	if err != nil {
+		h.log(c).Debugf("ListTenantsHandler: failed to list tenants: %v", err)
+		c.JSON(tenantErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tenants": tenants})
+}
+
+// @Summary Update tenant
+// @Description Replace tenant settings; omitted settings fall back to the service configuration
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Tenant ID"
+// @Param tenant body models.Tenant true "Tenant settings"
+// @Success 200 {object} models.Tenant "Tenant updated"
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Tenant not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/v1/admin/tenants/{id} [put]
+func (h *TenantHandler) UpdateTenantHandler(c *gin.Context) {
+	var req models.Tenant
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Debugf("UpdateTenantHandler: invalid request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	t, err := h.tenantService.UpdateTenant(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		h.log(c).Debugf("UpdateTenantHandler: failed to update tenant: %v", err)
+		c.JSON(tenantErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": t})
+}
@@ -0,0 +1,119 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Проверка зависимости приложения
+type Check struct {
+	Name    string
+	Timeout time.Duration
+	Run     func(ctx context.Context) error
+}
+
+type Result struct {
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Параллельный запуск проверок; каждая ограничена своим таймаутом
+func RunChecks(ctx context.Context, checks []Check) (map[string]Result, bool) {
+	results := make(map[string]Result, len(checks))
+	healthy := true
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, check.Timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.Run(checkCtx)
+			result := Result{Status: StatusUp, Duration: time.Since(start).String()}
+			if err != nil {
+				result.Status = StatusDown
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[check.Name] = result
+			if err != nil {
+				healthy = false
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	return results, healthy
+}
+
+// Проверка доступности базы данных
+func DatabaseCheck(db *sql.DB, timeout time.Duration) Check {
+	return Check{
+		Name:    "database",
+		Timeout: timeout,
+		Run:     db.PingContext,
+	}
+}
+
+// Проверка соответствия версии схемы ожидаемой
+func MigrationsCheck(version func(ctx context.Context) (uint, bool, error), expected uint, timeout time.Duration) Check {
+	return Check{
+		Name:    "migrations",
+		Timeout: timeout,
+		Run: func(ctx context.Context) error {
+			current, dirty, err := version(ctx)
+			if err != nil {
+				return err
+			}
+			if dirty {
+				return fmt.Errorf("schema version %d is dirty", current)
+			}
+			if current != expected {
+				return fmt.Errorf("schema version %d, expected %d", current, expected)
+			}
+			return nil
+		},
+	}
+}
+
+// Проверка доступности HTTP-сервиса: любой ответ без ошибки 5xx
+func HTTPCheck(name, url string, client *http.Client, timeout time.Duration) Check {
+	return Check{
+		Name:    name,
+		Timeout: timeout,
+		Run: func(ctx context.Context) error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return err
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode >= http.StatusInternalServerError {
+				return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,62 @@
+package identity
+
+import (
+	"context"
+
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+// Способы аутентификации
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+	MethodCLI    = "cli"
+)
+
+// Аутентифицированный клиент
+type Principal struct {
+	Subject string
+	Method  string
+	Scopes  []string
+	Tenant  string
+}
+
+// Проверка наличия области доступа; admin включает все остальные
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope || s == models.ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Проверка наличия хотя бы одной из областей доступа
+func (p *Principal) HasAnyScope(scopes ...string) bool {
+	for _, scope := range scopes {
+		if p.HasScope(scope) {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// Клиент из контекста запроса; nil для анонимных запросов
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey{}).(*Principal)
+	return principal
+}
+
+// Идентификатор клиента для полей created_by/updated_by; пустой для анонимных запросов
+func Subject(ctx context.Context) string {
+	if principal := PrincipalFromContext(ctx); principal != nil {
+		return principal.Subject
+	}
+	return ""
+}
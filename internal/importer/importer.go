@@ -0,0 +1,224 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+// Форматы импортируемого каталога
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+var (
+	ErrUnknownFormat  = errors.New("unknown import format")
+	ErrInvalidMapping = errors.New("invalid column mapping")
+	ErrInvalidHeader  = errors.New("invalid CSV header")
+)
+
+// Поля песни, которые можно импортировать
+var fields = []string{"group", "song", "releaseDate", "text", "link"}
+
+// Колонки CSV, соответствующие полям песни без явного сопоставления
+var columnAliases = map[string]string{
+	"group":        "group",
+	"group_name":   "group",
+	"song":         "song",
+	"song_name":    "song",
+	"releasedate":  "releaseDate",
+	"release_date": "releaseDate",
+	"text":         "text",
+	"link":         "link",
+}
+
+// Формат по расширению файла или Content-Type; пустая строка, если формат не определен
+func DetectFormat(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".csv"), strings.Contains(name, "text/csv"):
+		return FormatCSV
+	case strings.HasSuffix(name, ".jsonl"), strings.HasSuffix(name, ".ndjson"),
+		strings.Contains(name, "application/x-ndjson"), strings.Contains(name, "application/jsonl"):
+		return FormatJSONL
+	default:
+		return ""
+	}
+}
+
+// Разбор сопоставления колонок CSV полям песни вида "Artist=group,Title=song"
+func ParseMapping(s string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	if strings.TrimSpace(s) == "" {
+		return mapping, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		column, field, ok := strings.Cut(pair, "=")
+		column, field = strings.TrimSpace(column), strings.TrimSpace(field)
+		if !ok || column == "" || !knownField(field) {
+			return nil, fmt.Errorf("%w: %q, expected column=field with field one of %s", ErrInvalidMapping, pair, strings.Join(fields, ", "))
+		}
+		mapping[strings.ToLower(column)] = field
+	}
+	return mapping, nil
+}
+
+func knownField(field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Чтение строк каталога; читается не больше limit+1 строки, чтобы превышение лимита определил вызывающий.
+// Ошибки разбора отдельных строк не прерывают чтение и записываются в ImportRow.Error.
+func Read(r io.Reader, format string, mapping map[string]string, limit int) ([]models.ImportRow, error) {
+	switch format {
+	case FormatCSV:
+		return ReadCSV(r, mapping, limit)
+	case FormatJSONL:
+		return ReadJSONL(r, limit)
+	default:
+		return nil, fmt.Errorf("%w %q, expected %s or %s", ErrUnknownFormat, format, FormatCSV, FormatJSONL)
+	}
+}
+
+// Чтение CSV с заголовком; колонки сопоставляются полям песни по mapping, а поля без сопоставления -
+// по имени колонки без учета регистра; прочие колонки игнорируются
+func ReadCSV(r io.Reader, mapping map[string]string, limit int) ([]models.ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidHeader)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+	}
+
+	// Поля, явно сопоставленные колонкам, не ищутся по имени колонки
+	mapped := make(map[string]bool, len(mapping))
+	for _, field := range mapping {
+		mapped[field] = true
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		field, ok := mapping[name]
+		if !ok {
+			field, ok = columnAliases[name]
+			ok = ok && !mapped[field]
+		}
+		if !ok {
+			continue
+		}
+		if _, dup := columns[field]; dup {
+			return nil, fmt.Errorf("%w: several columns map to %s", ErrInvalidHeader, field)
+		}
+		columns[field] = i
+	}
+	for _, required := range []string{"group", "song"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%w: no column for %s", ErrInvalidHeader, required)
+		}
+	}
+
+	value := func(record []string, field string) string {
+		if i, ok := columns[field]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	var rows []models.ImportRow
+	for len(rows) <= limit {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rows = append(rows, models.ImportRow{Line: parseErr.StartLine, Error: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, models.ImportRow{
+			Line:        line,
+			GroupName:   value(record, "group"),
+			SongName:    value(record, "song"),
+			ReleaseDate: value(record, "releaseDate"),
+			Text:        value(record, "text"),
+			Link:        value(record, "link"),
+		})
+	}
+	return rows, nil
+}
+
+// Чтение JSON Lines: по объекту песни в строке, пустые строки пропускаются
+func ReadJSONL(r io.Reader, limit int) ([]models.ImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var rows []models.ImportRow
+	for line := 1; len(rows) <= limit && scanner.Scan(); line++ {
+		data := strings.TrimSpace(scanner.Text())
+		if data == "" {
+			continue
+		}
+		var song struct {
+			GroupName   string `json:"group"`
+			SongName    string `json:"song"`
+			ReleaseDate string `json:"releaseDate"`
+			Text        string `json:"text"`
+			Link        string `json:"link"`
+		}
+		if err := json.Unmarshal([]byte(data), &song); err != nil {
+			rows = append(rows, models.ImportRow{Line: line, Error: fmt.Sprintf("invalid JSON: %v", err)})
+			continue
+		}
+		rows = append(rows, models.ImportRow{
+			Line:        line,
+			GroupName:   song.GroupName,
+			SongName:    song.SongName,
+			ReleaseDate: song.ReleaseDate,
+			Text:        song.Text,
+			Link:        song.Link,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Запись отчета об отклоненных строках в CSV
+func WriteReport(w io.Writer, results []models.ImportResult) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"line", "group", "song", "status", "error"}); err != nil {
+		return err
+	}
+	for _, result := range results {
+		record := []string{strconv.Itoa(result.Line), result.Group, result.Song, result.Status, result.Error}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,194 @@
+package importer
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		mapping string
+		want    []models.ImportRow
+		wantErr error
+	}{
+		{
+			name: "field names",
+			csv:  "group,song,releaseDate,text,link\nMuse,Uprising,2009-09-07,They will not force us,https://example.com/uprising\n",
+			want: []models.ImportRow{
+				{Line: 2, GroupName: "Muse", SongName: "Uprising", ReleaseDate: "2009-09-07", Text: "They will not force us", Link: "https://example.com/uprising"},
+			},
+		},
+		{
+			name: "snake case names in any case and order",
+			csv:  "Song_Name, GROUP_NAME ,Release_Date\nUprising,Muse,2009-09-07\n",
+			want: []models.ImportRow{{Line: 2, GroupName: "Muse", SongName: "Uprising", ReleaseDate: "2009-09-07"}},
+		},
+		{
+			name: "byte order mark",
+			csv:  "\ufeffgroup,song\nMuse,Uprising\n",
+			want: []models.ImportRow{{Line: 2, GroupName: "Muse", SongName: "Uprising"}},
+		},
+		{
+			name: "extra columns ignored",
+			csv:  "id,group,album,song\n7,Muse,The Resistance,Uprising\n",
+			want: []models.ImportRow{{Line: 2, GroupName: "Muse", SongName: "Uprising"}},
+		},
+		{
+			name:    "mapped columns",
+			csv:     "Artist,Title,Lyrics\nMuse,Uprising,They will not force us\n",
+			mapping: "artist=group, TITLE=song,Lyrics=text",
+			want:    []models.ImportRow{{Line: 2, GroupName: "Muse", SongName: "Uprising", Text: "They will not force us"}},
+		},
+		{
+			name:    "mapping replaces column with field name",
+			csv:     "group,song,performer\nMuse (band),Uprising,Muse\n",
+			mapping: "performer=group",
+			want:    []models.ImportRow{{Line: 2, GroupName: "Muse", SongName: "Uprising"}},
+		},
+		{
+			name:    "mapping takes precedence over column name",
+			csv:     "group_name,group,title\nUprising,Muse,Resistance\n",
+			mapping: "group_name=song",
+			want:    []models.ImportRow{{Line: 2, GroupName: "Muse", SongName: "Uprising"}},
+		},
+		{
+			name:    "two mapped columns for one field",
+			csv:     "artist,band,song\nMuse,Muse,Uprising\n",
+			mapping: "artist=group,band=group",
+			wantErr: ErrInvalidHeader,
+		},
+		{
+			name:    "two columns for one field",
+			csv:     "group,group_name,song\nMuse,Muse,Uprising\n",
+			wantErr: ErrInvalidHeader,
+		},
+		{
+			name:    "missing group",
+			csv:     "artist,song\nMuse,Uprising\n",
+			wantErr: ErrInvalidHeader,
+		},
+		{
+			name:    "missing song",
+			csv:     "group,text\nMuse,They will not force us\n",
+			wantErr: ErrInvalidHeader,
+		},
+		{
+			name:    "empty file",
+			csv:     "",
+			wantErr: ErrInvalidHeader,
+		},
+		{
+			name: "header only",
+			csv:  "group,song\n",
+			want: nil,
+		},
+		{
+			name: "empty and whitespace only fields kept for the service",
+			csv:  "group,song,text,link\nMuse,Uprising,,\" \"\n  , \t,\" \r\n\",\n",
+			want: []models.ImportRow{
+				{Line: 2, GroupName: "Muse", SongName: "Uprising", Link: " "},
+				{Line: 3, GroupName: "  ", SongName: " \t", Text: " \n"},
+			},
+		},
+		{
+			name: "short and long records",
+			csv:  "group,song,link\nMuse\nMuse,Uprising,https://example.com/uprising,extra\n",
+			want: []models.ImportRow{
+				{Line: 2, GroupName: "Muse"},
+				{Line: 3, GroupName: "Muse", SongName: "Uprising", Link: "https://example.com/uprising"},
+			},
+		},
+		{
+			name: "multiline text keeps line numbers",
+			csv:  "group,song,text\nMuse,Uprising,\"They will not force us\nThey will stop degrading us\"\nMuse,Resistance,\n",
+			want: []models.ImportRow{
+				{Line: 2, GroupName: "Muse", SongName: "Uprising", Text: "They will not force us\nThey will stop degrading us"},
+				{Line: 4, GroupName: "Muse", SongName: "Resistance"},
+			},
+		},
+		{
+			name: "malformed record reported in its row",
+			csv:  "group,song\nMuse,Up\"rising\nMuse,Resistance\n",
+			want: []models.ImportRow{
+				{Line: 2, Error: `bare " in non-quoted-field`},
+				{Line: 3, GroupName: "Muse", SongName: "Resistance"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping, err := ParseMapping(tt.mapping)
+			if err != nil {
+				t.Fatalf("ParseMapping() error = %v", err)
+			}
+			got, err := ReadCSV(strings.NewReader(tt.csv), mapping, 10)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReadCSV() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadCSV() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadCSVLimit(t *testing.T) {
+	csv := "group,song\nMuse,Uprising\nMuse,Resistance\nMuse,Undisclosed Desires\n"
+
+	tests := []struct {
+		limit    int
+		wantRows int
+	}{
+		{limit: 1, wantRows: 2},
+		{limit: 2, wantRows: 3},
+		{limit: 3, wantRows: 3},
+		{limit: 10, wantRows: 3},
+	}
+
+	for _, tt := range tests {
+		got, err := ReadCSV(strings.NewReader(csv), nil, tt.limit)
+		if err != nil {
+			t.Fatalf("ReadCSV() error = %v", err)
+		}
+		if len(got) != tt.wantRows {
+			t.Errorf("ReadCSV() with limit %d read %d rows, want %d", tt.limit, len(got), tt.wantRows)
+		}
+	}
+}
+
+func TestParseMapping(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    map[string]string
+		wantErr error
+	}{
+		{in: "", want: map[string]string{}},
+		{in: "  ", want: map[string]string{}},
+		{in: "Artist=group, Title = song", want: map[string]string{"artist": "group", "title": "song"}},
+		{in: "Released=releaseDate", want: map[string]string{"released": "releaseDate"}},
+		{in: "Artist", wantErr: ErrInvalidMapping},
+		{in: "=group", wantErr: ErrInvalidMapping},
+		{in: "Artist=band", wantErr: ErrInvalidMapping},
+		{in: "Artist=Group", wantErr: ErrInvalidMapping},
+		{in: "Artist=group,", wantErr: ErrInvalidMapping},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseMapping(tt.in)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseMapping(%q) error = %v, want %v", tt.in, err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseMapping(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/config"
+)
+
+// Форматы логов
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Создание логгера по конфигурации: уровень, формат, request ID и маскирование данных
+func NewLogger(cfg *config.Config) (*logrus.Logger, error) {
+	log := logrus.New()
+	log.Out = os.Stdout
+
+	level, err := logrus.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log level: %w", err)
+	}
+	log.SetLevel(level)
+
+	switch cfg.LogFormat {
+	case FormatJSON:
+		log.SetFormatter(&logrus.JSONFormatter{})
+	case FormatText:
+		log.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	default:
+		return nil, fmt.Errorf("unknown log format %q", cfg.LogFormat)
+	}
+
+	log.AddHook(RequestIDHook{})
+	log.AddHook(RedactHook{})
+
+	return log, nil
+}
@@ -0,0 +1,23 @@
+package logging
+
+import (
+	"errors"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// Middleware восстановления после паники с ответом 500 через logrus.
+// Паника http.ErrAbortHandler передается net/http: так обработчик обрывает соединение,
+// когда ответ уже начат и его нельзя завершить корректно.
+func RecoveryMiddleware(logger *logrus.Logger) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, recovered any) {
+		if err, ok := recovered.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+			panic(recovered)
+		}
+		logger.WithContext(c.Request.Context()).Errorf("panic recovered: %v\n%s", recovered, debug.Stack())
+		c.AbortWithStatus(http.StatusInternalServerError)
+	})
+}
@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const redacted = "[REDACTED]"
+
+// Поля с текстами песен, значения которых не попадают в лог
+var lyricsKeys = map[string]bool{"text": true, "lyrics": true, "verses": true}
+
+// Фрагменты имен полей с секретами
+var secretKeys = []string{"password", "secret", "token", "authorization", "api_key", "apikey"}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	if lyricsKeys[key] {
+		return true
+	}
+	for _, secret := range secretKeys {
+		if strings.Contains(key, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// Копия набора значений с замаскированными чувствительными полями
+func Redact(values map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		if isSensitive(key) {
+			value = redacted
+		}
+		result[key] = value
+	}
+	return result
+}
+
+// Хук logrus, маскирующий чувствительные поля записи
+type RedactHook struct{}
+
+func (RedactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (RedactHook) Fire(entry *logrus.Entry) error {
+	for key := range entry.Data {
+		if isSensitive(key) {
+			entry.Data[key] = redacted
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package logging
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]interface{}
+		want   map[string]interface{}
+	}{
+		{
+			name:   "lyrics",
+			values: map[string]interface{}{"group": "Muse", "text": "They will not force us", "Lyrics": "We will be victorious", "verses": []string{"They will not force us"}},
+			want:   map[string]interface{}{"group": "Muse", "text": redacted, "Lyrics": redacted, "verses": redacted},
+		},
+		{
+			name:   "secrets",
+			values: map[string]interface{}{"password": "hunter2", "clientSecret": "s3cr3t", "access_token": "t0ken", "Authorization": "Bearer abc", "x_api_key": "k3y", "apiKey": "k3y"},
+			want:   map[string]interface{}{"password": redacted, "clientSecret": redacted, "access_token": redacted, "Authorization": redacted, "x_api_key": redacted, "apiKey": redacted},
+		},
+		{
+			name:   "other fields kept",
+			values: map[string]interface{}{"song": "Uprising", "page": 2, "textual": "kept"},
+			want:   map[string]interface{}{"song": "Uprising", "page": 2, "textual": "kept"},
+		},
+		{
+			name:   "empty",
+			values: nil,
+			want:   map[string]interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Redact(tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Redact() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactDoesNotChangeValues(t *testing.T) {
+	values := map[string]interface{}{"text": "They will not force us"}
+	Redact(values)
+	if values["text"] != "They will not force us" {
+		t.Errorf("Redact() changed the original values: %v", values)
+	}
+}
+
+func TestRedactHook(t *testing.T) {
+	var out bytes.Buffer
+	log := logrus.New()
+	log.SetOutput(&out)
+	log.SetFormatter(&logrus.JSONFormatter{})
+	log.AddHook(RedactHook{})
+
+	log.WithFields(logrus.Fields{
+		"group":         "Muse",
+		"text":          "They will not force us",
+		"lyrics":        "We will be victorious",
+		"verses":        []string{"They will not force us"},
+		"authorization": "Bearer abc",
+		"api_key":       "k3y",
+	}).Info("song fetched")
+
+	line := out.String()
+	for _, secret := range []string{"They will not force us", "We will be victorious", "Bearer abc", "k3y"} {
+		if strings.Contains(line, secret) {
+			t.Errorf("log entry contains %q: %s", secret, line)
+		}
+	}
+	if !strings.Contains(line, `"group":"Muse"`) {
+		t.Errorf("log entry lost a regular field: %s", line)
+	}
+	if got := strings.Count(line, redacted); got != 5 {
+		t.Errorf("log entry has %d redacted fields, want 5: %s", got, line)
+	}
+}
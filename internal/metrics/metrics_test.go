@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestGinMiddlewareLabels(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantRoute  string
+		wantMethod string
+		wantStatus string
+	}{
+		{name: "matched route", method: http.MethodGet, path: "/songs/7", wantRoute: "/songs/:id", wantMethod: http.MethodGet, wantStatus: "200"},
+		{name: "unmatched route", method: http.MethodGet, path: "/random/path/1234", wantRoute: unmatchedRoute, wantMethod: http.MethodGet, wantStatus: "404"},
+		{name: "non-standard method", method: "PROPFIND", path: "/songs/7", wantRoute: unmatchedRoute, wantMethod: otherMethod, wantStatus: "404"},
+		{name: "lowercase method", method: "get", path: "/songs/7", wantRoute: unmatchedRoute, wantMethod: otherMethod, wantStatus: "404"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMetrics()
+			router := gin.New()
+			router.Use(m.GinMiddleware())
+			router.GET("/songs/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+
+			if got := testutil.ToFloat64(m.httpRequests.WithLabelValues(tt.wantRoute, tt.wantMethod, tt.wantStatus)); got != 1 {
+				t.Errorf("requests with labels %s %s %s = %v, want 1", tt.wantRoute, tt.wantMethod, tt.wantStatus, got)
+			}
+			if got := testutil.CollectAndCount(m.httpRequests); got != 1 {
+				t.Errorf("request series = %d, want 1", got)
+			}
+		})
+	}
+}
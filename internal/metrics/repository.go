@@ -0,0 +1,419 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/repository"
+)
+
+// Репозиторий с замером длительности запросов по каждому методу
+type songRepository struct {
+	next    repository.SongRepository
+	metrics *Metrics
+}
+
+func (m *Metrics) InstrumentSongRepository(next repository.SongRepository) repository.SongRepository {
+	return &songRepository{
+		next:    next,
+		metrics: m,
+	}
+}
+
+// Замер длительности запроса метода method репозитория repo
+func (m *Metrics) observeQuery(repo, method string, start time.Time, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	m.dbQueryDuration.WithLabelValues(repo, method, outcome).Observe(time.Since(start).Seconds())
+}
+
+func (r *songRepository) observe(method string, start time.Time, err error) {
+	r.metrics.observeQuery("songs", method, start, err)
+}
+
+func (r *songRepository) Add(ctx context.Context, song *models.Song) error {
+	start := time.Now()
+	err := r.next.Add(ctx, song)
+	r.observe("Add", start, err)
+	return err
+}
+
+func (r *songRepository) GetAll(ctx context.Context) ([]models.Song, error) {
+	start := time.Now()
+	songs, err := r.next.GetAll(ctx)
+	r.observe("GetAll", start, err)
+	return songs, err
+}
+
+func (r *songRepository) GetById(ctx context.Context, id uint) (*models.Song, error) {
+	start := time.Now()
+	song, err := r.next.GetById(ctx, id)
+	r.observe("GetById", start, err)
+	return song, err
+}
+
+func (r *songRepository) GetByName(ctx context.Context, groupName, songName string) (*models.Song, error) {
+	start := time.Now()
+	song, err := r.next.GetByName(ctx, groupName, songName)
+	r.observe("GetByName", start, err)
+	return song, err
+}
+
+func (r *songRepository) Count(ctx context.Context) (int64, error) {
+	start := time.Now()
+	count, err := r.next.Count(ctx)
+	r.observe("Count", start, err)
+	return count, err
+}
+
+func (r *songRepository) GetWithFiltersAndPagination(ctx context.Context, filters map[string]interface{}, fields []string, page int, pageSize int) ([]models.Song, error) {
+	start := time.Now()
+	songs, err := r.next.GetWithFiltersAndPagination(ctx, filters, fields, page, pageSize)
+	r.observe("GetWithFiltersAndPagination", start, err)
+	return songs, err
+}
+
+func (r *songRepository) Update(ctx context.Context, song *models.Song) error {
+	start := time.Now()
+	err := r.next.Update(ctx, song)
+	r.observe("Update", start, err)
+	return err
+}
+
+func (r *songRepository) Delete(ctx context.Context, id uint, version int) error {
+	start := time.Now()
+	err := r.next.Delete(ctx, id, version)
+	r.observe("Delete", start, err)
+	return err
+}
+
+func (r *songRepository) GetVersesWithPagination(ctx context.Context, id uint, page int, pageSize int) ([]string, error) {
+	start := time.Now()
+	verses, err := r.next.GetVersesWithPagination(ctx, id, page, pageSize)
+	r.observe("GetVersesWithPagination", start, err)
+	return verses, err
+}
+
+func (r *songRepository) Export(ctx context.Context, filters map[string]interface{}, fn func(*models.Song) error) error {
+	start := time.Now()
+	err := r.next.Export(ctx, filters, fn)
+	r.observe("Export", start, err)
+	return err
+}
+
+func (r *songRepository) CountMatching(ctx context.Context, match models.SongMatch) (int64, error) {
+	start := time.Now()
+	count, err := r.next.CountMatching(ctx, match)
+	r.observe("CountMatching", start, err)
+	return count, err
+}
+
+func (r *songRepository) UpdateMatching(ctx context.Context, match models.SongMatch, changes map[string]interface{}, maxRows int64, recredit repository.CreditsFunc) (int64, error) {
+	start := time.Now()
+	affected, err := r.next.UpdateMatching(ctx, match, changes, maxRows, recredit)
+	r.observe("UpdateMatching", start, err)
+	return affected, err
+}
+
+func (r *songRepository) DeleteMatching(ctx context.Context, match models.SongMatch, maxRows int64) (int64, error) {
+	start := time.Now()
+	affected, err := r.next.DeleteMatching(ctx, match, maxRows)
+	r.observe("DeleteMatching", start, err)
+	return affected, err
+}
+
+type tagRepository struct {
+	next    repository.TagRepository
+	metrics *Metrics
+}
+
+func (m *Metrics) InstrumentTagRepository(next repository.TagRepository) repository.TagRepository {
+	return &tagRepository{
+		next:    next,
+		metrics: m,
+	}
+}
+
+func (r *tagRepository) observe(method string, start time.Time, err error) {
+	r.metrics.observeQuery("tags", method, start, err)
+}
+
+func (r *tagRepository) List(ctx context.Context) ([]models.TagCount, error) {
+	start := time.Now()
+	tags, err := r.next.List(ctx)
+	r.observe("List", start, err)
+	return tags, err
+}
+
+func (r *tagRepository) SongTags(ctx context.Context, songId uint) ([]string, error) {
+	start := time.Now()
+	tags, err := r.next.SongTags(ctx, songId)
+	r.observe("SongTags", start, err)
+	return tags, err
+}
+
+func (r *tagRepository) AddSongTags(ctx context.Context, songId uint, names []string) error {
+	start := time.Now()
+	err := r.next.AddSongTags(ctx, songId, names)
+	r.observe("AddSongTags", start, err)
+	return err
+}
+
+func (r *tagRepository) SetSongTags(ctx context.Context, songId uint, names []string) error {
+	start := time.Now()
+	err := r.next.SetSongTags(ctx, songId, names)
+	r.observe("SetSongTags", start, err)
+	return err
+}
+
+func (r *tagRepository) RemoveSongTag(ctx context.Context, songId uint, name string) error {
+	start := time.Now()
+	err := r.next.RemoveSongTag(ctx, songId, name)
+	r.observe("RemoveSongTag", start, err)
+	return err
+}
+
+type creditRepository struct {
+	next    repository.CreditRepository
+	metrics *Metrics
+}
+
+func (m *Metrics) InstrumentCreditRepository(next repository.CreditRepository) repository.CreditRepository {
+	return &creditRepository{
+		next:    next,
+		metrics: m,
+	}
+}
+
+func (r *creditRepository) observe(method string, start time.Time, err error) {
+	r.metrics.observeQuery("credits", method, start, err)
+}
+
+func (r *creditRepository) SongCredits(ctx context.Context, songId uint) ([]models.SongCredit, error) {
+	start := time.Now()
+	credits, err := r.next.SongCredits(ctx, songId)
+	r.observe("SongCredits", start, err)
+	return credits, err
+}
+
+func (r *creditRepository) SetSongCredits(ctx context.Context, songId uint, credits []models.SongCredit) error {
+	start := time.Now()
+	err := r.next.SetSongCredits(ctx, songId, credits)
+	r.observe("SetSongCredits", start, err)
+	return err
+}
+
+type playlistRepository struct {
+	next    repository.PlaylistRepository
+	metrics *Metrics
+}
+
+func (m *Metrics) InstrumentPlaylistRepository(next repository.PlaylistRepository) repository.PlaylistRepository {
+	return &playlistRepository{
+		next:    next,
+		metrics: m,
+	}
+}
+
+func (r *playlistRepository) observe(method string, start time.Time, err error) {
+	r.metrics.observeQuery("playlists", method, start, err)
+}
+
+func (r *playlistRepository) Add(ctx context.Context, playlist *models.Playlist) error {
+	start := time.Now()
+	err := r.next.Add(ctx, playlist)
+	r.observe("Add", start, err)
+	return err
+}
+
+func (r *playlistRepository) GetById(ctx context.Context, id uint) (*models.Playlist, error) {
+	start := time.Now()
+	playlist, err := r.next.GetById(ctx, id)
+	r.observe("GetById", start, err)
+	return playlist, err
+}
+
+func (r *playlistRepository) List(ctx context.Context, page int, pageSize int) ([]models.Playlist, error) {
+	start := time.Now()
+	playlists, err := r.next.List(ctx, page, pageSize)
+	r.observe("List", start, err)
+	return playlists, err
+}
+
+func (r *playlistRepository) Update(ctx context.Context, playlist *models.Playlist) error {
+	start := time.Now()
+	err := r.next.Update(ctx, playlist)
+	r.observe("Update", start, err)
+	return err
+}
+
+func (r *playlistRepository) Delete(ctx context.Context, id uint, version int) error {
+	start := time.Now()
+	err := r.next.Delete(ctx, id, version)
+	r.observe("Delete", start, err)
+	return err
+}
+
+func (r *playlistRepository) Items(ctx context.Context, playlistId uint) ([]models.PlaylistItem, error) {
+	start := time.Now()
+	items, err := r.next.Items(ctx, playlistId)
+	r.observe("Items", start, err)
+	return items, err
+}
+
+// Длительность включает время работы edit внутри транзакции
+func (r *playlistRepository) EditItems(ctx context.Context, playlistId uint, version int, edit func(playlist *models.Playlist, items []models.PlaylistItem) ([]models.PlaylistItem, error)) (*models.Playlist, error) {
+	start := time.Now()
+	playlist, err := r.next.EditItems(ctx, playlistId, version, edit)
+	r.observe("EditItems", start, err)
+	return playlist, err
+}
+
+type changeRequestRepository struct {
+	next    repository.ChangeRequestRepository
+	metrics *Metrics
+}
+
+func (m *Metrics) InstrumentChangeRequestRepository(next repository.ChangeRequestRepository) repository.ChangeRequestRepository {
+	return &changeRequestRepository{
+		next:    next,
+		metrics: m,
+	}
+}
+
+func (r *changeRequestRepository) observe(method string, start time.Time, err error) {
+	r.metrics.observeQuery("change_requests", method, start, err)
+}
+
+func (r *changeRequestRepository) Add(ctx context.Context, request *models.ChangeRequest) error {
+	start := time.Now()
+	err := r.next.Add(ctx, request)
+	r.observe("Add", start, err)
+	return err
+}
+
+func (r *changeRequestRepository) GetById(ctx context.Context, id uint) (*models.ChangeRequest, error) {
+	start := time.Now()
+	request, err := r.next.GetById(ctx, id)
+	r.observe("GetById", start, err)
+	return request, err
+}
+
+func (r *changeRequestRepository) GetByStatus(ctx context.Context, status string, page int, pageSize int) ([]models.ChangeRequest, error) {
+	start := time.Now()
+	requests, err := r.next.GetByStatus(ctx, status, page, pageSize)
+	r.observe("GetByStatus", start, err)
+	return requests, err
+}
+
+func (r *changeRequestRepository) UpdateFromStatus(ctx context.Context, request *models.ChangeRequest, fromStatus string) error {
+	start := time.Now()
+	err := r.next.UpdateFromStatus(ctx, request, fromStatus)
+	r.observe("UpdateFromStatus", start, err)
+	return err
+}
+
+type apiKeyRepository struct {
+	next    repository.APIKeyRepository
+	metrics *Metrics
+}
+
+func (m *Metrics) InstrumentAPIKeyRepository(next repository.APIKeyRepository) repository.APIKeyRepository {
+	return &apiKeyRepository{
+		next:    next,
+		metrics: m,
+	}
+}
+
+func (r *apiKeyRepository) observe(method string, start time.Time, err error) {
+	r.metrics.observeQuery("api_keys", method, start, err)
+}
+
+func (r *apiKeyRepository) Add(ctx context.Context, key *models.APIKey) error {
+	start := time.Now()
+	err := r.next.Add(ctx, key)
+	r.observe("Add", start, err)
+	return err
+}
+
+func (r *apiKeyRepository) GetAll(ctx context.Context) ([]models.APIKey, error) {
+	start := time.Now()
+	keys, err := r.next.GetAll(ctx)
+	r.observe("GetAll", start, err)
+	return keys, err
+}
+
+func (r *apiKeyRepository) GetById(ctx context.Context, id uint) (*models.APIKey, error) {
+	start := time.Now()
+	key, err := r.next.GetById(ctx, id)
+	r.observe("GetById", start, err)
+	return key, err
+}
+
+func (r *apiKeyRepository) GetByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	start := time.Now()
+	key, err := r.next.GetByHash(ctx, hash)
+	r.observe("GetByHash", start, err)
+	return key, err
+}
+
+func (r *apiKeyRepository) Update(ctx context.Context, key *models.APIKey) error {
+	start := time.Now()
+	err := r.next.Update(ctx, key)
+	r.observe("Update", start, err)
+	return err
+}
+
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id uint, usedAt time.Time) error {
+	start := time.Now()
+	err := r.next.TouchLastUsed(ctx, id, usedAt)
+	r.observe("TouchLastUsed", start, err)
+	return err
+}
+
+type tenantRepository struct {
+	next    repository.TenantRepository
+	metrics *Metrics
+}
+
+func (m *Metrics) InstrumentTenantRepository(next repository.TenantRepository) repository.TenantRepository {
+	return &tenantRepository{
+		next:    next,
+		metrics: m,
+	}
+}
+
+func (r *tenantRepository) observe(method string, start time.Time, err error) {
+	r.metrics.observeQuery("tenants", method, start, err)
+}
+
+func (r *tenantRepository) Add(ctx context.Context, tenant *models.Tenant) error {
+	start := time.Now()
+	err := r.next.Add(ctx, tenant)
+	r.observe("Add", start, err)
+	return err
+}
+
+func (r *tenantRepository) GetAll(ctx context.Context) ([]models.Tenant, error) {
+	start := time.Now()
+	tenants, err := r.next.GetAll(ctx)
+	r.observe("GetAll", start, err)
+	return tenants, err
+}
+
+func (r *tenantRepository) GetById(ctx context.Context, id string) (*models.Tenant, error) {
+	start := time.Now()
+	tenant, err := r.next.GetById(ctx, id)
+	r.observe("GetById", start, err)
+	return tenant, err
+}
+
+func (r *tenantRepository) Update(ctx context.Context, tenant *models.Tenant) error {
+	start := time.Now()
+	err := r.next.Update(ctx, tenant)
+	r.observe("Update", start, err)
+	return err
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+)
+
+// Транспорт HTTP-клиента с замером длительности запросов к внешнему API
+type externalApiTransport struct {
+	next    http.RoundTripper
+	metrics *Metrics
+}
+
+func (m *Metrics) InstrumentExternalApi(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &externalApiTransport{
+		next:    next,
+		metrics: m,
+	}
+}
+
+func (t *externalApiTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+
+	var outcome string
+	switch {
+	case err != nil:
+		outcome = "error"
+	case resp.StatusCode >= http.StatusInternalServerError:
+		outcome = "server_error"
+	case resp.StatusCode >= http.StatusBadRequest:
+		outcome = "client_error"
+	default:
+		outcome = "success"
+	}
+	t.metrics.externalApiDuration.WithLabelValues(outcome).Observe(time.Since(start).Seconds())
+
+	return resp, err
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Области доступа API-ключей
+const (
+	ScopeSongsRead      = "songs:read"
+	ScopeSongsWrite     = "songs:write"
+	ScopeSongsDelete    = "songs:delete"
+	ScopeChangesPropose = "changes:propose"
+	ScopeChangesReview  = "changes:review"
+	ScopePlaylistsWrite = "playlists:write"
+	ScopeAdmin          = "admin"
+)
+
+var Scopes = []string{ScopeSongsRead, ScopeSongsWrite, ScopeSongsDelete, ScopeChangesPropose, ScopeChangesReview, ScopePlaylistsWrite, ScopeAdmin}
+
+type APIKey struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	TenantID   string         `json:"tenantId" gorm:"column:tenant_id"`
+	Name       string         `json:"name" gorm:"column:name"`
+	Prefix     string         `json:"prefix" gorm:"column:prefix"`
+	KeyHash    string         `json:"-" gorm:"column:key_hash"`
+	Scopes     pq.StringArray `json:"scopes" gorm:"column:scopes;type:text[]" swaggertype:"array,string"`
+	ExpiresAt  *time.Time     `json:"expiresAt,omitempty" gorm:"column:expires_at"`
+	LastUsedAt *time.Time     `json:"lastUsedAt,omitempty" gorm:"column:last_used_at"`
+	RevokedAt  *time.Time     `json:"revokedAt,omitempty" gorm:"column:revoked_at"`
+	CreatedAt  time.Time      `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt  time.Time      `json:"updatedAt" gorm:"column:updated_at"`
+}
+
+// Области доступа ключа задаются явно и/или через роли
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes"`
+	Roles     []string   `json:"roles"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// Ответ с ключом в открытом виде; показывается только при создании и ротации
+type APIKeyWithSecret struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import "time"
+
+// Результаты добавления песни в пакете
+const (
+	BulkStatusCreated          = "created"
+	BulkStatusDuplicate        = "duplicate"
+	BulkStatusInvalid          = "invalid"
+	BulkStatusRejected         = "rejected"
+	BulkStatusEnrichmentFailed = "enrichment_failed"
+	BulkStatusFailed           = "failed"
+)
+
+// Статусы фонового задания
+const (
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusCanceled  = "canceled"
+)
+
+// Результат добавления одной песни пакета; Index - позиция песни в запросе
+type BulkAddResult struct {
+	Index  int    `json:"index"`
+	Group  string `json:"group"`
+	Song   string `json:"song"`
+	Status string `json:"status"`
+	SongID uint   `json:"songId,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Число песен пакета по результатам добавления
+type BulkSummary map[string]int
+
+func SummarizeBulk(results []BulkAddResult) BulkSummary {
+	summary := BulkSummary{}
+	for _, result := range results {
+		summary[result.Status]++
+	}
+	return summary
+}
+
+// Фоновое задание пакетного добавления песен
+type BulkJob struct {
+	ID         string          `json:"id"`
+	TenantID   string          `json:"-"`
+	Status     string          `json:"status"`
+	Total      int             `json:"total"`
+	Processed  int             `json:"processed"`
+	Summary    BulkSummary     `json:"summary"`
+	Results    []BulkAddResult `json:"results"`
+	CreatedBy  string          `json:"createdBy,omitempty"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	FinishedAt *time.Time      `json:"finishedAt,omitempty"`
+}
+
+// Выбор песен для пакетного изменения: список ID или фильтр, как у GET /songs
+type SongMatch struct {
+	IDs    []uint                 `json:"ids,omitempty"`
+	Filter map[string]interface{} `json:"filter,omitempty"`
+}
+
+// Пакетное удаление песен; Force снимает ограничение на число затрагиваемых песен
+type BulkDeleteRequest struct {
+	SongMatch
+	DryRun bool `json:"dryRun"`
+	Force  bool `json:"force"`
+}
+
+// Пакетное изменение песен по правилам JSON Merge Patch
+type BulkUpdateRequest struct {
+	SongMatch
+	Changes SongPatch `json:"changes"`
+	DryRun  bool      `json:"dryRun"`
+	Force   bool      `json:"force"`
+}
+
+// Результат пакетного изменения или удаления; при DryRun - число песен, которые были бы затронуты
+type BulkChangeResult struct {
+	Affected int64 `json:"affected"`
+	DryRun   bool  `json:"dryRun"`
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Статусы запроса на изменение песни
+const (
+	ChangeStatusPending  = "pending"
+	ChangeStatusApproved = "approved"
+	ChangeStatusRejected = "rejected"
+)
+
+// Предлагаемые изменения полей песни; Clear перечисляет очищаемые поля
+type SongChanges struct {
+	GroupName   string   `json:"group,omitempty"`
+	SongName    string   `json:"song,omitempty"`
+	ReleaseDate string   `json:"releaseDate,omitempty"`
+	Text        string   `json:"text,omitempty"`
+	Link        string   `json:"link,omitempty"`
+	Clear       []string `json:"clear,omitempty"`
+}
+
+func (c SongChanges) Empty() bool {
+	return c.GroupName == "" && c.SongName == "" && c.ReleaseDate == "" && c.Text == "" && c.Link == "" && len(c.Clear) == 0
+}
+
+// Изменения в виде частичного обновления для применения через UpdateSong
+func (c SongChanges) Patch() SongPatch {
+	patch := SongFieldsUpdate{
+		GroupName:   c.GroupName,
+		SongName:    c.SongName,
+		ReleaseDate: c.ReleaseDate,
+		Text:        c.Text,
+		Link:        c.Link,
+	}.Patch()
+	fields := patch.fields()
+	for _, name := range c.Clear {
+		if field, ok := fields[name]; ok {
+			*field = ClearString()
+		}
+	}
+	return patch
+}
+
+func SongChangesFrom(patch SongPatch) SongChanges {
+	var changes SongChanges
+	values := map[string]*string{
+		"group":       &changes.GroupName,
+		"song":        &changes.SongName,
+		"releaseDate": &changes.ReleaseDate,
+		"text":        &changes.Text,
+		"link":        &changes.Link,
+	}
+	fields := patch.fields()
+	for _, name := range SongPatchFields {
+		field := fields[name]
+		switch {
+		case !field.Set:
+		case field.Null || field.Value == "":
+			changes.Clear = append(changes.Clear, name)
+		default:
+			*values[name] = field.Value
+		}
+	}
+	return changes
+}
+
+// Сериализация изменений в JSONB
+func (c SongChanges) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Десериализация изменений из JSONB
+func (c *SongChanges) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	default:
+		return fmt.Errorf("unsupported song changes type: %T", src)
+	}
+}
+
+type ChangeRequest struct {
+	ID            uint        `json:"id" gorm:"primaryKey"`
+	TenantID      string      `json:"-" gorm:"column:tenant_id"`
+	SongID        uint        `json:"songId" gorm:"column:song_id"`
+	SongVersion   int         `json:"songVersion" gorm:"column:song_version"`
+	Changes       SongChanges `json:"changes" gorm:"column:changes;type:jsonb"`
+	Status        string      `json:"status" gorm:"column:status"`
+	ProposedBy    string      `json:"proposedBy,omitempty" gorm:"column:proposed_by"`
+	ReviewedBy    string      `json:"reviewedBy,omitempty" gorm:"column:reviewed_by"`
+	ReviewComment string      `json:"reviewComment,omitempty" gorm:"column:review_comment"`
+	ReviewedAt    *time.Time  `json:"reviewedAt,omitempty" gorm:"column:reviewed_at"`
+	CreatedAt     time.Time   `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt     time.Time   `json:"updatedAt" gorm:"column:updated_at"`
+}
+
+func (ChangeRequest) TableName() string {
+	return "song_change_requests"
+}
+
+type ReviewChangeRequest struct {
+	Comment string `json:"comment"`
+}
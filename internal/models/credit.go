@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Роли участников песни
+const (
+	CreditRoleArtist    = "artist"
+	CreditRoleFeaturing = "featuring"
+	CreditRoleComposer  = "composer"
+	CreditRoleLyricist  = "lyricist"
+)
+
+// Роли в порядке вывода участников
+var CreditRoles = []string{CreditRoleArtist, CreditRoleFeaturing, CreditRoleComposer, CreditRoleLyricist}
+
+// Участник песни; Position - порядок участника в своей роли, начиная с 1
+type SongCredit struct {
+	ID        uint      `json:"-" gorm:"primaryKey"`
+	SongID    uint      `json:"-" gorm:"column:song_id"`
+	Name      string    `json:"name" gorm:"column:name"`
+	Role      string    `json:"role" gorm:"column:role"`
+	Position  int       `json:"position" gorm:"column:position"`
+	CreatedAt time.Time `json:"-" gorm:"column:created_at"`
+}
+
+// Замена участников песни; порядок в списке задает порядок участников в каждой роли
+type SongCreditsRequest struct {
+	Credits []SongCredit `json:"credits" binding:"required"`
+}
@@ -0,0 +1,64 @@
+package models
+
+// Результаты импорта строки каталога
+const (
+	ImportStatusCreated          = "created"
+	ImportStatusUpdated          = "updated"
+	ImportStatusUnchanged        = "unchanged"
+	ImportStatusRejected         = "rejected"
+	ImportStatusEnrichmentFailed = "enrichment_failed"
+	ImportStatusFailed           = "failed"
+)
+
+// Строка импортируемого каталога; Line - номер строки в файле, Error - ошибка разбора строки
+type ImportRow struct {
+	Line        int    `json:"line"`
+	GroupName   string `json:"group"`
+	SongName    string `json:"song"`
+	ReleaseDate string `json:"releaseDate,omitempty"`
+	Text        string `json:"text,omitempty"`
+	Link        string `json:"link,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Параметры импорта: Enrich дополняет недостающие поля из внешнего API
+type ImportOptions struct {
+	Enrich bool
+}
+
+// Результат импорта строки
+type ImportResult struct {
+	Line   int    `json:"line"`
+	Group  string `json:"group"`
+	Song   string `json:"song"`
+	Status string `json:"status"`
+	SongID uint   `json:"songId,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Успешно ли импортирована строка
+func (r ImportResult) Imported() bool {
+	switch r.Status {
+	case ImportStatusCreated, ImportStatusUpdated, ImportStatusUnchanged:
+		return true
+	default:
+		return false
+	}
+}
+
+// Итоги импорта: число строк по результатам и отклоненные строки
+type ImportReport struct {
+	Summary  map[string]int `json:"summary"`
+	Rejected []ImportResult `json:"rejected"`
+}
+
+func NewImportReport() *ImportReport {
+	return &ImportReport{Summary: map[string]int{}, Rejected: []ImportResult{}}
+}
+
+func (r *ImportReport) Add(result ImportResult) {
+	r.Summary[result.Status]++
+	if !result.Imported() {
+		r.Rejected = append(r.Rejected, result)
+	}
+}
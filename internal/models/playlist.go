@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+type Playlist struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	TenantID    string         `json:"-" gorm:"column:tenant_id"`
+	Name        string         `json:"name" gorm:"column:name"`
+	Description string         `json:"description,omitempty" gorm:"column:description"`
+	Version     int            `json:"version" gorm:"column:version;default:1"`
+	CreatedBy   string         `json:"createdBy,omitempty" gorm:"column:created_by"`
+	UpdatedBy   string         `json:"updatedBy,omitempty" gorm:"column:updated_by"`
+	CreatedAt   time.Time      `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt   time.Time      `json:"updatedAt" gorm:"column:updated_at"`
+	Items       []PlaylistItem `json:"items,omitempty" gorm:"-"`
+}
+
+// Песня в плейлисте; Position - место песни, начиная с 1
+type PlaylistItem struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	PlaylistID uint      `json:"-" gorm:"column:playlist_id"`
+	SongID     uint      `json:"songId" gorm:"column:song_id"`
+	Position   int       `json:"position" gorm:"column:position"`
+	Song       *Song     `json:"song,omitempty" gorm:"foreignKey:SongID"`
+	CreatedAt  time.Time `json:"addedAt" gorm:"column:created_at"`
+}
+
+type PlaylistRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+// Добавление песни в плейлист; без Position песня добавляется в конец
+type AddPlaylistItemRequest struct {
+	SongID   uint `json:"songId" binding:"required"`
+	Position int  `json:"position"`
+}
+
+type MovePlaylistItemRequest struct {
+	Position int `json:"position" binding:"required"`
+}
+
+// Новый порядок песен плейлиста: все элементы плейлиста по одному разу
+type ReorderPlaylistRequest struct {
+	ItemIDs []uint `json:"itemIds" binding:"required"`
+}
@@ -0,0 +1,26 @@
+package models
+
+// Роли пользователей
+const (
+	RoleViewer      = "viewer"
+	RoleContributor = "contributor"
+	RoleEditor      = "editor"
+	RoleAdmin       = "admin"
+)
+
+// Области доступа, входящие в роль
+var RoleScopes = map[string][]string{
+	RoleViewer:      {ScopeSongsRead},
+	RoleContributor: {ScopeSongsRead, ScopeChangesPropose, ScopePlaylistsWrite},
+	RoleEditor:      {ScopeSongsRead, ScopeSongsWrite, ScopeChangesPropose, ScopeChangesReview, ScopePlaylistsWrite},
+	RoleAdmin:       {ScopeAdmin},
+}
+
+// Области доступа для набора ролей
+func ExpandRoles(roles []string) []string {
+	var scopes []string
+	for _, role := range roles {
+		scopes = append(scopes, RoleScopes[role]...)
+	}
+	return scopes
+}
@@ -1,14 +1,30 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type Song struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	GroupName   string    `json:"group" gorm:"column:group_name"`
-	SongName    string    `json:"song" gorm:"column:song_name"`
-	ReleaseDate string    `json:"releaseDate,omitempty" gorm:"column:release_date"`
-	Text        string    `json:"text,omitempty" gorm:"column:text"`
-	Link        string    `json:"link,omitempty" gorm:"column:link"`
-	CreatedAt   time.Time `json:"createdAt" gorm:"column:created_at"`
-	UpdatedAt   time.Time `json:"updatedAt" gorm:"column:updated_at"`
+	ID               uint              `json:"id" gorm:"primaryKey"`
+	TenantID         string            `json:"-" gorm:"column:tenant_id"`
+	GroupName        string            `json:"group" gorm:"column:group_name"`
+	SongName         string            `json:"song" gorm:"column:song_name"`
+	ReleaseDate      string            `json:"releaseDate,omitempty" gorm:"column:release_date"`
+	Text             string            `json:"text,omitempty" gorm:"column:text"`
+	Link             string            `json:"link,omitempty" gorm:"column:link"`
+	NeedsReview      bool              `json:"needsReview" gorm:"column:needs_review"`
+	ValidationReport *ValidationReport `json:"validationReport,omitempty" gorm:"column:validation_report;type:jsonb"`
+	CreatedBy        string            `json:"createdBy,omitempty" gorm:"column:created_by"`
+	UpdatedBy        string            `json:"updatedBy,omitempty" gorm:"column:updated_by"`
+	CreatedAt        time.Time         `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt        time.Time         `json:"updatedAt" gorm:"column:updated_at"`
+	Version          int               `json:"version" gorm:"column:version;default:1"`
+	// Участники, сохраняемые вместе с новой песней; у прочитанных песен не заполняется
+	Credits []SongCredit `json:"credits,omitempty" gorm:"-"`
+}
+
+// Куплеты песни; разделены переводом строки
+func (s Song) Verses() []string {
+	return strings.Split(s.Text, "\n")
 }
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Строковое поле частичного обновления: отсутствует (Set == false), null (очистить) или значение
+type PatchString struct {
+	Set   bool
+	Null  bool
+	Value string
+}
+
+func SetString(value string) PatchString {
+	return PatchString{Set: true, Value: value}
+}
+
+func ClearString() PatchString {
+	return PatchString{Set: true, Null: true}
+}
+
+func (p *PatchString) UnmarshalJSON(data []byte) error {
+	p.Set = true
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		p.Null = true
+		return nil
+	}
+	if err := json.Unmarshal(data, &p.Value); err != nil {
+		return fmt.Errorf("expected string or null")
+	}
+	return nil
+}
+
+func (p PatchString) MarshalJSON() ([]byte, error) {
+	if !p.Set || p.Null {
+		return []byte("null"), nil
+	}
+	return json.Marshal(p.Value)
+}
+
+// Частичное обновление песни (JSON Merge Patch, RFC 7396):
+// отсутствующее поле не меняется, null очищает поле
+type SongPatch struct {
+	GroupName   PatchString `json:"group" swaggertype:"string"`
+	SongName    PatchString `json:"song" swaggertype:"string"`
+	ReleaseDate PatchString `json:"releaseDate" swaggertype:"string"`
+	Text        PatchString `json:"text" swaggertype:"string"`
+	Link        PatchString `json:"link" swaggertype:"string"`
+}
+
+// Изменяемые поля песни в порядке их следования
+var SongPatchFields = []string{"group", "song", "releaseDate", "text", "link"}
+
+// Поля изменения по JSON-именам
+func (p *SongPatch) fields() map[string]*PatchString {
+	return map[string]*PatchString{
+		"group":       &p.GroupName,
+		"song":        &p.SongName,
+		"releaseDate": &p.ReleaseDate,
+		"text":        &p.Text,
+		"link":        &p.Link,
+	}
+}
+
+func (p SongPatch) Empty() bool {
+	return !p.GroupName.Set && !p.SongName.Set && !p.ReleaseDate.Set && !p.Text.Set && !p.Link.Set
+}
+
+// Замена песни целиком (PUT): пустые поля очищаются
+type SongUpdate struct {
+	GroupName   string `json:"group" binding:"required"`
+	SongName    string `json:"song" binding:"required"`
+	ReleaseDate string `json:"releaseDate"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+}
+
+func (u SongUpdate) Patch() SongPatch {
+	return SongPatch{
+		GroupName:   SetString(u.GroupName),
+		SongName:    SetString(u.SongName),
+		ReleaseDate: SetString(u.ReleaseDate),
+		Text:        SetString(u.Text),
+		Link:        SetString(u.Link),
+	}
+}
+
+// Изменение непустых полей (устаревший PUT /update-song/{id})
+type SongFieldsUpdate struct {
+	GroupName   string `json:"group"`
+	SongName    string `json:"song"`
+	ReleaseDate string `json:"releaseDate"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+}
+
+func (u SongFieldsUpdate) Patch() SongPatch {
+	var patch SongPatch
+	for _, field := range []struct {
+		target *PatchString
+		value  string
+	}{
+		{&patch.GroupName, u.GroupName},
+		{&patch.SongName, u.SongName},
+		{&patch.ReleaseDate, u.ReleaseDate},
+		{&patch.Text, u.Text},
+		{&patch.Link, u.Link},
+	} {
+		if field.value != "" {
+			*field.target = SetString(field.value)
+		}
+	}
+	return patch
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Совпадение песни с фильтром по тегам: хотя бы один из тегов или все теги
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
+
+// Наибольшая длина тега
+const MaxTagLength = 64
+
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	TenantID  string    `json:"-" gorm:"column:tenant_id"`
+	Name      string    `json:"name" gorm:"column:name"`
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at"`
+}
+
+// Связь песни с тегом
+type SongTag struct {
+	SongID    uint      `gorm:"column:song_id;primaryKey"`
+	TagID     uint      `gorm:"column:tag_id;primaryKey"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+// Тег с числом отмеченных им песен
+type TagCount struct {
+	Name  string `json:"name" gorm:"column:name"`
+	Songs int64  `json:"songs" gorm:"column:songs"`
+}
+
+type SongTagsRequest struct {
+	Tags []string `json:"tags" binding:"required"`
+}
+
+// Теги хранятся в нижнем регистре с одиночными пробелами, чтобы "80s" и " 80S " были одним тегом
+func NormalizeTag(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(norm.NFC.String(name)), " "))
+}
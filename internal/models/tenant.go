@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Арендатор (библиотека отдельной радиостанции) с собственными настройками.
+// Пустые настройки означают значения из общей конфигурации.
+type Tenant struct {
+	ID                  string    `json:"id" gorm:"primaryKey"`
+	Name                string    `json:"name" gorm:"column:name"`
+	ExternalApi         string    `json:"externalApi,omitempty" gorm:"column:external_api"`
+	MaxSongs            *int      `json:"maxSongs,omitempty" gorm:"column:max_songs"`
+	RateLimitReadRate   *float64  `json:"rateLimitReadRate,omitempty" gorm:"column:rate_limit_read_rate"`
+	RateLimitReadBurst  *int      `json:"rateLimitReadBurst,omitempty" gorm:"column:rate_limit_read_burst"`
+	RateLimitWriteRate  *float64  `json:"rateLimitWriteRate,omitempty" gorm:"column:rate_limit_write_rate"`
+	RateLimitWriteBurst *int      `json:"rateLimitWriteBurst,omitempty" gorm:"column:rate_limit_write_burst"`
+	CreatedAt           time.Time `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt           time.Time `json:"updatedAt" gorm:"column:updated_at"`
+}
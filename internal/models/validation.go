@@ -0,0 +1,64 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Действия при нарушении правила валидации поля
+const (
+	ValidationActionIgnore = "ignore"
+	ValidationActionFlag   = "flag"
+	ValidationActionReject = "reject"
+)
+
+type ValidationIssue struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Action  string `json:"action"`
+}
+
+// Отчет о валидации данных, полученных из внешнего API
+type ValidationReport struct {
+	Issues []ValidationIssue `json:"issues"`
+}
+
+func (r *ValidationReport) Add(issue ValidationIssue) {
+	r.Issues = append(r.Issues, issue)
+}
+
+// Проверка наличия нарушений с заданным действием
+func (r *ValidationReport) Has(action string) bool {
+	for _, issue := range r.Issues {
+		if issue.Action == action {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *ValidationReport) Empty() bool {
+	return len(r.Issues) == 0
+}
+
+// Сериализация отчета в JSONB
+func (r ValidationReport) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+// Десериализация отчета из JSONB
+func (r *ValidationReport) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*r = ValidationReport{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, r)
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	default:
+		return fmt.Errorf("unsupported validation report type: %T", src)
+	}
+}
@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Как часто удалять полностью пополненные корзины
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	fullAt  time.Time
+}
+
+// Ограничитель в памяти процесса; лимиты не разделяются между экземплярами приложения
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+func (l *MemoryLimiter) Allow(_ context.Context, key string, policy Policy) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(policy.Burst), updated: now}
+		l.buckets[key] = b
+	}
+
+	tokens, result := take(b.tokens, now.Sub(b.updated), policy)
+	b.tokens = tokens
+	b.updated = now
+	b.fullAt = now.Add(result.ResetAfter)
+	return result, nil
+}
+
+// Полная корзина не отличается от новой, поэтому ее можно удалить
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	for key, b := range l.buckets {
+		if !now.Before(b.fullAt) {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/internal/identity"
+	"github.com/ananikitina/song_lib/internal/service"
+)
+
+// Ключ клиента: аутентифицированный субъект (API-ключ или пользователь SSO) в пределах его арендатора,
+// иначе IP-адрес. Анонимные запросы и запросы с неверными учетными данными ограничиваются по IP
+// независимо от заголовка X-Tenant-ID.
+func clientKey(c *gin.Context, principal *identity.Principal) string {
+	if principal != nil {
+		return principal.Tenant + ":" + principal.Subject
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// Политика клиента: для аутентифицированного клиента с лимитами его арендатора
+func (m *middleware) policy(c *gin.Context, principal *identity.Principal) Policy {
+	if principal == nil {
+		return m.defaultPolicy
+	}
+	t, err := m.tenants.GetTenant(c.Request.Context(), principal.Tenant)
+	if err != nil {
+		m.logger.WithContext(c.Request.Context()).Warnf("Middleware: failed to get tenant %s, using default limits: %v", principal.Tenant, err)
+		return m.defaultPolicy
+	}
+	return m.defaultPolicy.ForTenant(t)
+}
+
+// Округление длительности вверх до целых секунд для заголовков
+func headerSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+type middleware struct {
+	limiter       Limiter
+	defaultPolicy Policy
+	tenants       service.TenantService
+	logger        *logrus.Logger
+}
+
+// Middleware ограничения частоты запросов.
+// Должен идти после auth.Identify, чтобы лимит считался по клиенту с лимитами его арендатора,
+// и до проверки прав доступа, чтобы ограничивались и отклоненные запросы.
+// Ответы содержат заголовки RateLimit-* (draft-ietf-httpapi-ratelimit-headers);
+// при ошибке ограничителя запрос пропускается.
+func Middleware(limiter Limiter, defaultPolicy Policy, tenants service.TenantService, logger *logrus.Logger) gin.HandlerFunc {
+	m := &middleware{
+		limiter:       limiter,
+		defaultPolicy: defaultPolicy,
+		tenants:       tenants,
+		logger:        logger,
+	}
+	return m.handle
+}
+
+func (m *middleware) handle(c *gin.Context) {
+	principal := identity.PrincipalFromContext(c.Request.Context())
+	policy := m.policy(c, principal)
+	key := clientKey(c, principal)
+	result, err := m.limiter.Allow(c.Request.Context(), policy.Name+":"+key, policy)
+	if err != nil {
+		m.logger.WithContext(c.Request.Context()).Errorf("Middleware: rate limiter failed, allowing request: %v", err)
+		c.Next()
+		return
+	}
+
+	c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%s", policy.Burst, headerSeconds(policy.Window())))
+	c.Header("RateLimit-Limit", strconv.Itoa(policy.Burst))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("RateLimit-Reset", headerSeconds(result.ResetAfter))
+
+	if !result.Allowed {
+		m.logger.WithContext(c.Request.Context()).Warnf("Middleware: %s exceeded %s rate limit", key, policy.Name)
+		c.Header("Retry-After", headerSeconds(result.RetryAfter))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+		return
+	}
+
+	c.Next()
+}
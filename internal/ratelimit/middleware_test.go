@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/internal/identity"
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/service"
+)
+
+// Сервис арендаторов, возвращающий арендатора с лимитом всплеска burst
+type tenantLimits struct {
+	service.TenantService
+	burst int
+}
+
+func (s *tenantLimits) GetTenant(ctx context.Context, id string) (*models.Tenant, error) {
+	return &models.Tenant{ID: id, RateLimitReadBurst: &s.burst}, nil
+}
+
+// Маршрут, на котором ограничитель стоит до проверки доступа: handler отклоняет запросы
+// без субъекта, как Require. Субъект задается заголовком X-Subject, как его определил бы auth.Identify.
+func newLimitedRouter(tenantBurst int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	policy := Policy{Name: PolicyRead, Rate: 0.001, Burst: 2}
+	router := gin.New()
+	router.GET("/songs",
+		func(c *gin.Context) {
+			if subject := c.GetHeader("X-Subject"); subject != "" {
+				principal := &identity.Principal{Subject: subject, Tenant: "radio-one"}
+				c.Request = c.Request.WithContext(identity.WithPrincipal(c.Request.Context(), principal))
+			}
+		},
+		Middleware(NewMemoryLimiter(), policy, &tenantLimits{burst: tenantBurst}, logger),
+		func(c *gin.Context) {
+			if identity.PrincipalFromContext(c.Request.Context()) == nil {
+				c.AbortWithStatus(http.StatusUnauthorized)
+				return
+			}
+			c.Status(http.StatusOK)
+		},
+	)
+	return router
+}
+
+func request(router *gin.Engine, ip, subject string) int {
+	req := httptest.NewRequest(http.MethodGet, "/songs", nil)
+	req.RemoteAddr = ip + ":1234"
+	if subject != "" {
+		req.Header.Set("X-Subject", subject)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestMiddlewareLimitsRejectedRequestsByIP(t *testing.T) {
+	router := newLimitedRouter(2)
+
+	for i, want := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests} {
+		if code := request(router, "192.0.2.1", ""); code != want {
+			t.Errorf("request %d from 192.0.2.1: status = %d, want %d", i+1, code, want)
+		}
+	}
+	if code := request(router, "192.0.2.2", ""); code != http.StatusUnauthorized {
+		t.Errorf("request from 192.0.2.2: status = %d, want %d", code, http.StatusUnauthorized)
+	}
+}
+
+func TestMiddlewareLimitsClientsSeparately(t *testing.T) {
+	router := newLimitedRouter(1)
+
+	// Лимит арендатора клиента, а не общий, и отдельный от анонимных запросов с того же IP
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		if code := request(router, "192.0.2.1", "api-key:1"); code != want {
+			t.Errorf("request %d of api-key:1: status = %d, want %d", i+1, code, want)
+		}
+	}
+	if code := request(router, "192.0.2.1", "api-key:2"); code != http.StatusOK {
+		t.Errorf("request of api-key:2: status = %d, want %d", code, http.StatusOK)
+	}
+	if code := request(router, "192.0.2.1", ""); code != http.StatusUnauthorized {
+		t.Errorf("anonymous request: status = %d, want %d", code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Ограничитель с корзинами в таблице rate_limit_buckets, общий для всех экземпляров приложения.
+// Время берется из базы данных, чтобы не зависеть от расхождения часов между экземплярами.
+type PostgresLimiter struct {
+	db           *gorm.DB
+	logger       *logrus.Logger
+	queryTimeout time.Duration
+}
+
+func NewPostgresLimiter(db *gorm.DB, logger *logrus.Logger, queryTimeout time.Duration) *PostgresLimiter {
+	return &PostgresLimiter{
+		db:           db,
+		logger:       logger,
+		queryTimeout: queryTimeout,
+	}
+}
+
+func (l *PostgresLimiter) Allow(ctx context.Context, key string, policy Policy) (Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.queryTimeout)
+	defer cancel()
+
+	var result Result
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`INSERT INTO rate_limit_buckets (key, tokens, updated_at) VALUES (?, ?, NOW())
+			ON CONFLICT (key) DO NOTHING`, key, policy.Burst).Error
+		if err != nil {
+			return err
+		}
+
+		var state struct {
+			Tokens  float64
+			Elapsed float64
+		}
+		err = tx.Raw(`SELECT tokens, EXTRACT(EPOCH FROM NOW() - updated_at) AS elapsed
+			FROM rate_limit_buckets WHERE key = ? FOR UPDATE`, key).Scan(&state).Error
+		if err != nil {
+			return err
+		}
+
+		var tokens float64
+		tokens, result = take(state.Tokens, seconds(state.Elapsed), policy)
+		return tx.Exec(`UPDATE rate_limit_buckets SET tokens = ?, updated_at = NOW() WHERE key = ?`, tokens, key).Error
+	})
+	return result, err
+}
+
+// Периодическое удаление корзин, не использовавшихся дольше maxIdle
+func (l *PostgresLimiter) Cleanup(ctx context.Context, interval, maxIdle time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			queryCtx, cancel := context.WithTimeout(ctx, l.queryTimeout)
+			res := l.db.WithContext(queryCtx).Exec(
+				`DELETE FROM rate_limit_buckets WHERE updated_at < NOW() - make_interval(secs => ?)`, maxIdle.Seconds())
+			cancel()
+			if res.Error != nil {
+				l.logger.Warnf("Cleanup: failed to delete idle rate limit buckets: %v", res.Error)
+				continue
+			}
+			l.logger.Debugf("Cleanup: deleted %d idle rate limit buckets", res.RowsAffected)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+// Имена политик; по ним выбираются лимиты арендатора
+const (
+	PolicyRead  = "read"
+	PolicyWrite = "write"
+)
+
+// Параметры корзины токенов: скорость пополнения (токенов в секунду) и емкость
+type Policy struct {
+	Name  string
+	Rate  float64
+	Burst int
+}
+
+// Политика с лимитами, переопределенными арендатором
+func (p Policy) ForTenant(t *models.Tenant) Policy {
+	if t == nil {
+		return p
+	}
+	rate, burst := t.RateLimitReadRate, t.RateLimitReadBurst
+	if p.Name == PolicyWrite {
+		rate, burst = t.RateLimitWriteRate, t.RateLimitWriteBurst
+	}
+	if rate != nil {
+		p.Rate = *rate
+	}
+	if burst != nil {
+		p.Burst = *burst
+	}
+	return p
+}
+
+// Время полного пополнения корзины
+func (p Policy) Window() time.Duration {
+	return seconds(float64(p.Burst) / p.Rate)
+}
+
+// Результат проверки лимита
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	ResetAfter time.Duration // до полного пополнения корзины
+	RetryAfter time.Duration // до появления следующего токена, если запрос отклонен
+}
+
+type Limiter interface {
+	// Списание токена из корзины клиента key для политики policy
+	Allow(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// Пополнение корзины за прошедшее время и попытка списать токен
+func take(tokens float64, elapsed time.Duration, policy Policy) (float64, Result) {
+	burst := float64(policy.Burst)
+	tokens = math.Min(burst, tokens+elapsed.Seconds()*policy.Rate)
+
+	var result Result
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - tokens) / policy.Rate)
+	}
+	result.Remaining = int(math.Floor(tokens))
+	result.ResetAfter = seconds((burst - tokens) / policy.Rate)
+	return tokens, result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+type APIKeyRepository interface {
+	Add(ctx context.Context, key *models.APIKey) error
+	GetAll(ctx context.Context) ([]models.APIKey, error)
+	GetById(ctx context.Context, id uint) (*models.APIKey, error)
+	GetByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	Update(ctx context.Context, key *models.APIKey) error
+	TouchLastUsed(ctx context.Context, id uint, usedAt time.Time) error
+}
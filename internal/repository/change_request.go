@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+type ChangeRequestRepository interface {
+	Add(ctx context.Context, request *models.ChangeRequest) error
+	GetById(ctx context.Context, id uint) (*models.ChangeRequest, error)
+	GetByStatus(ctx context.Context, status string, page int, pageSize int) ([]models.ChangeRequest, error)
+	// Обновление запроса, только если его статус равен fromStatus; иначе ErrConflict
+	UpdateFromStatus(ctx context.Context, request *models.ChangeRequest, fromStatus string) error
+}
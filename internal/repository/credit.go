@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+// Участники песен
+type CreditRepository interface {
+	// Участники песни по ролям и порядку
+	SongCredits(ctx context.Context, songId uint) ([]models.SongCredit, error)
+	// Замена всех участников песни в одной транзакции
+	SetSongCredits(ctx context.Context, songId uint, credits []models.SongCredit) error
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+type PlaylistRepository interface {
+	Add(ctx context.Context, playlist *models.Playlist) error
+	GetById(ctx context.Context, id uint) (*models.Playlist, error)
+	List(ctx context.Context, page int, pageSize int) ([]models.Playlist, error)
+	// Обновление названия и описания, только если версия не изменилась с момента чтения; иначе ErrConflict
+	Update(ctx context.Context, playlist *models.Playlist) error
+	// Удаление плейлиста; при ненулевой version только этой версии, иначе ErrConflict
+	Delete(ctx context.Context, id uint, version int) error
+	// Песни плейлиста по порядку
+	Items(ctx context.Context, playlistId uint) ([]models.PlaylistItem, error)
+	// Изменение состава или порядка песен в транзакции под блокировкой плейлиста.
+	// edit получает плейлист и текущие элементы по порядку и возвращает новый список: элементы без ID
+	// добавляются, отсутствующие удаляются, позиции назначаются по порядку списка. Версия плейлиста
+	// увеличивается; при ненулевой version и другой версии возвращается ErrConflict.
+	EditItems(ctx context.Context, playlistId uint, version int, edit func(playlist *models.Playlist, items []models.PlaylistItem) ([]models.PlaylistItem, error)) (*models.Playlist, error)
+}
@@ -0,0 +1,124 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/repository"
+)
+
+type apiKeyRepository struct {
+	db           *gorm.DB
+	logger       *logrus.Logger
+	queryTimeout time.Duration
+}
+
+func NewAPIKeyRepository(db *gorm.DB, logger *logrus.Logger, queryTimeout time.Duration) repository.APIKeyRepository {
+	return &apiKeyRepository{
+		db:           db,
+		logger:       logger,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// Сессия, ограниченная ключами арендатора из контекста
+func (r *apiKeyRepository) session(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	return r.db.WithContext(ctx).Scopes(tenantScope(ctx, "api_keys")), cancel
+}
+
+// Сессия без ограничения арендатором: при аутентификации он еще не известен
+func (r *apiKeyRepository) globalSession(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	return r.db.WithContext(ctx), cancel
+}
+
+// Добавление ключа
+func (r *apiKeyRepository) Add(ctx context.Context, key *models.APIKey) error {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	tenantID, err := currentTenant(ctx)
+	if err != nil {
+		return err
+	}
+	key.TenantID = tenantID
+	if err := db.Create(key).Error; err != nil {
+		r.logger.WithContext(ctx).Errorf("Add: failed to add API key to database: %v", err)
+		return err
+	}
+	r.logger.WithContext(ctx).Infof("Add: API key added with ID %d", key.ID)
+	return nil
+}
+
+// Получение всех ключей
+func (r *apiKeyRepository) GetAll(ctx context.Context) ([]models.APIKey, error) {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	var keys []models.APIKey
+	if err := db.Order("id").Find(&keys).Error; err != nil {
+		r.logger.WithContext(ctx).Errorf("GetAll: failed to fetch API keys from database: %v", err)
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Получение ключа по ID
+func (r *apiKeyRepository) GetById(ctx context.Context, id uint) (*models.APIKey, error) {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	var key models.APIKey
+	if err := db.First(&key, id).Error; err != nil {
+		r.logger.WithContext(ctx).Errorf("GetById: failed to get API key from database with ID %d: %v", id, err)
+		return nil, notFound(err)
+	}
+	return &key, nil
+}
+
+// Получение ключа по хэшу
+func (r *apiKeyRepository) GetByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	db, cancel := r.globalSession(ctx)
+	defer cancel()
+
+	var key models.APIKey
+	if err := db.Where("key_hash = ?", hash).First(&key).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &key, nil
+}
+
+// Обновление ключа
+func (r *apiKeyRepository) Update(ctx context.Context, key *models.APIKey) error {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	tenantID, err := currentTenant(ctx)
+	if err != nil {
+		return err
+	}
+	key.TenantID = tenantID
+	res := db.Model(key).Select("*").Omit("created_at").Updates(key)
+	if res.Error != nil {
+		r.logger.WithContext(ctx).Errorf("Update: failed to update API key with ID %d: %v", key.ID, res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	r.logger.WithContext(ctx).Infof("Update: API key with ID %d updated", key.ID)
+	return nil
+}
+
+// Отметка времени последнего использования ключа
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id uint, usedAt time.Time) error {
+	db, cancel := r.globalSession(ctx)
+	defer cancel()
+
+	return db.Model(&models.APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", usedAt).Error
+}
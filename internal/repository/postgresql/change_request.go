@@ -0,0 +1,97 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/repository"
+)
+
+type changeRequestRepository struct {
+	db           *gorm.DB
+	logger       *logrus.Logger
+	queryTimeout time.Duration
+}
+
+func NewChangeRequestRepository(db *gorm.DB, logger *logrus.Logger, queryTimeout time.Duration) repository.ChangeRequestRepository {
+	return &changeRequestRepository{
+		db:           db,
+		logger:       logger,
+		queryTimeout: queryTimeout,
+	}
+}
+
+func (r *changeRequestRepository) session(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	return r.db.WithContext(ctx).Scopes(tenantScope(ctx, "song_change_requests")), cancel
+}
+
+// Добавление запроса на изменение
+func (r *changeRequestRepository) Add(ctx context.Context, request *models.ChangeRequest) error {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	tenantID, err := currentTenant(ctx)
+	if err != nil {
+		return err
+	}
+	request.TenantID = tenantID
+	if err := db.Create(request).Error; err != nil {
+		r.logger.WithContext(ctx).Errorf("Add: failed to add change request to database: %v", err)
+		return err
+	}
+	r.logger.WithContext(ctx).Infof("Add: change request added with ID %d", request.ID)
+	return nil
+}
+
+// Получение запроса по ID
+func (r *changeRequestRepository) GetById(ctx context.Context, id uint) (*models.ChangeRequest, error) {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	var request models.ChangeRequest
+	if err := db.First(&request, id).Error; err != nil {
+		r.logger.WithContext(ctx).Errorf("GetById: failed to get change request with ID %d: %v", id, err)
+		return nil, notFound(err)
+	}
+	return &request, nil
+}
+
+// Получение запросов с заданным статусом, старые первыми
+func (r *changeRequestRepository) GetByStatus(ctx context.Context, status string, page int, pageSize int) ([]models.ChangeRequest, error) {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	var requests []models.ChangeRequest
+	res := db.Where("status = ?", status).Order("created_at, id").
+		Offset((page - 1) * pageSize).Limit(pageSize).Find(&requests)
+	if res.Error != nil {
+		r.logger.WithContext(ctx).Errorf("GetByStatus: failed to fetch change requests: %v", res.Error)
+		return nil, res.Error
+	}
+	return requests, nil
+}
+
+// Условное обновление запроса по текущему статусу
+func (r *changeRequestRepository) UpdateFromStatus(ctx context.Context, request *models.ChangeRequest, fromStatus string) error {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	res := db.Model(request).Where("status = ?", fromStatus).Select(
+		"status", "reviewed_by", "review_comment", "reviewed_at", "updated_at",
+	).Updates(request)
+	if res.Error != nil {
+		r.logger.WithContext(ctx).Errorf("UpdateFromStatus: failed to update change request with ID %d: %v", request.ID, res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return repository.ErrConflict
+	}
+
+	r.logger.WithContext(ctx).Infof("UpdateFromStatus: change request with ID %d is now %s", request.ID, request.Status)
+	return nil
+}
@@ -0,0 +1,125 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/repository"
+)
+
+type creditRepository struct {
+	db           *gorm.DB
+	logger       *logrus.Logger
+	queryTimeout time.Duration
+}
+
+func NewCreditRepository(db *gorm.DB, logger *logrus.Logger, queryTimeout time.Duration) repository.CreditRepository {
+	return &creditRepository{
+		db:           db,
+		logger:       logger,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// Сессия без условия по арендатору: у участников его нет, запросы ограничиваются
+// арендатором через таблицу songs
+func (r *creditRepository) session(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	return r.db.WithContext(ctx), cancel
+}
+
+// Участники песни
+func (r *creditRepository) SongCredits(ctx context.Context, songId uint) ([]models.SongCredit, error) {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	credits := []models.SongCredit{}
+	res := db.Joins("JOIN songs ON songs.id = song_credits.song_id").
+		Scopes(tenantScope(ctx, "songs")).
+		Where("song_credits.song_id = ?", songId).
+		Order("song_credits.role, song_credits.position").
+		Find(&credits)
+	if res.Error != nil {
+		r.logger.WithContext(ctx).Errorf("SongCredits: failed to fetch credits of song with ID %d: %v", songId, res.Error)
+		return nil, res.Error
+	}
+	return credits, nil
+}
+
+// Замена участников песни
+func (r *creditRepository) SetSongCredits(ctx context.Context, songId uint, credits []models.SongCredit) error {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := checkSongsOwned(ctx, tx, songId); err != nil {
+			return err
+		}
+		return replaceCredits(tx, songId, credits)
+	})
+	if err != nil {
+		r.logger.WithContext(ctx).Errorf("SetSongCredits: failed to set credits of song with ID %d: %v", songId, err)
+		return err
+	}
+
+	r.logger.WithContext(ctx).Infof("SetSongCredits: song with ID %d now has %d credits", songId, len(credits))
+	return nil
+}
+
+// Замена участников песни songId в транзакции tx; принадлежность песни арендатору проверяет вызывающий
+func replaceCredits(tx *gorm.DB, songId uint, credits []models.SongCredit) error {
+	// Новый запрос без условий из сессии tx: у участников нет столбца арендатора
+	tx = tx.Session(&gorm.Session{NewDB: true})
+	if err := tx.Where("song_id = ?", songId).Delete(&models.SongCredit{}).Error; err != nil {
+		return err
+	}
+	if len(credits) == 0 {
+		return nil
+	}
+	for i := range credits {
+		credits[i].ID = 0
+		credits[i].SongID = songId
+	}
+	return tx.Create(&credits).Error
+}
+
+// Замена участников песен songs результатом recredit в транзакции tx
+func recreditSongs(tx *gorm.DB, songs []models.Song, recredit repository.CreditsFunc) error {
+	if len(songs) == 0 {
+		return nil
+	}
+	songIds := make([]uint, len(songs))
+	for i, song := range songs {
+		songIds[i] = song.ID
+	}
+
+	tx = tx.Session(&gorm.Session{NewDB: true})
+	var current []models.SongCredit
+	if err := tx.Where("song_id IN ?", songIds).Order("song_id, role, position").Find(&current).Error; err != nil {
+		return err
+	}
+	bySong := make(map[uint][]models.SongCredit, len(songs))
+	for _, credit := range current {
+		bySong[credit.SongID] = append(bySong[credit.SongID], credit)
+	}
+
+	var credits []models.SongCredit
+	for i := range songs {
+		for _, credit := range recredit(&songs[i], bySong[songs[i].ID]) {
+			credit.ID = 0
+			credit.SongID = songs[i].ID
+			credits = append(credits, credit)
+		}
+	}
+	if err := tx.Where("song_id IN ?", songIds).Delete(&models.SongCredit{}).Error; err != nil {
+		return err
+	}
+	if len(credits) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(&credits, 1000).Error
+}
@@ -0,0 +1,71 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/ananikitina/song_lib/config"
+)
+
+// Подключение к базе данных с повторными попытками и экспоненциальной задержкой
+func Connect(ctx context.Context, cfg *config.Config, log *logrus.Logger) (*gorm.DB, error) {
+	backoff := cfg.DbConnectBackoff
+
+	for attempt := 1; ; attempt++ {
+		db, err := open(ctx, cfg)
+		if err == nil {
+			log.Infof("Connect: connected to database on attempt %d", attempt)
+			return db, nil
+		}
+
+		if attempt > cfg.DbConnectRetries {
+			return nil, fmt.Errorf("failed to connect to database after %d attempts: %w", attempt, err)
+		}
+
+		log.Warnf("Connect: attempt %d failed: %v; retrying in %s", attempt, err, backoff)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to connect to database: %w", ctx.Err())
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > cfg.DbConnectMaxBackoff {
+			backoff = cfg.DbConnectMaxBackoff
+		}
+	}
+}
+
+// Открытие соединения, настройка пула и проверка доступности базы
+func open(ctx context.Context, cfg *config.Config) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.DbMaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.DbMaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.DbConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.DbConnMaxIdleTime)
+
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := sqlDB.PingContext(pingCtx); err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
@@ -0,0 +1,290 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/repository"
+)
+
+type playlistRepository struct {
+	db           *gorm.DB
+	logger       *logrus.Logger
+	queryTimeout time.Duration
+}
+
+func NewPlaylistRepository(db *gorm.DB, logger *logrus.Logger, queryTimeout time.Duration) repository.PlaylistRepository {
+	return &playlistRepository{
+		db:           db,
+		logger:       logger,
+		queryTimeout: queryTimeout,
+	}
+}
+
+func (r *playlistRepository) session(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	return r.db.WithContext(ctx).Scopes(tenantScope(ctx, "playlists")), cancel
+}
+
+// Ошибка для изменения, не затронувшего ни одной строки: плейлиста нет или его версия другая
+func (r *playlistRepository) missedRow(ctx context.Context, id uint) error {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	var count int64
+	if err := db.Model(&models.Playlist{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: playlist with ID %d", repository.ErrNotFound, id)
+	}
+	return fmt.Errorf("%w: playlist with ID %d", repository.ErrConflict, id)
+}
+
+// Добавление плейлиста
+func (r *playlistRepository) Add(ctx context.Context, playlist *models.Playlist) error {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	tenantID, err := currentTenant(ctx)
+	if err != nil {
+		return err
+	}
+	playlist.TenantID = tenantID
+	if err := db.Create(playlist).Error; err != nil {
+		r.logger.WithContext(ctx).Errorf("Add: failed to add playlist to database: %v", err)
+		return err
+	}
+	r.logger.WithContext(ctx).Infof("Add: playlist added with ID %d", playlist.ID)
+	return nil
+}
+
+// Получение плейлиста по ID без песен
+func (r *playlistRepository) GetById(ctx context.Context, id uint) (*models.Playlist, error) {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	var playlist models.Playlist
+	if err := db.First(&playlist, id).Error; err != nil {
+		r.logger.WithContext(ctx).Errorf("GetById: failed to get playlist with ID %d: %v", id, err)
+		return nil, notFound(err)
+	}
+	return &playlist, nil
+}
+
+// Получение плейлистов арендатора по порядку создания
+func (r *playlistRepository) List(ctx context.Context, page int, pageSize int) ([]models.Playlist, error) {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	var playlists []models.Playlist
+	res := db.Order("id").Offset((page - 1) * pageSize).Limit(pageSize).Find(&playlists)
+	if res.Error != nil {
+		r.logger.WithContext(ctx).Errorf("List: failed to fetch playlists: %v", res.Error)
+		return nil, res.Error
+	}
+	return playlists, nil
+}
+
+// Обновление названия и описания плейлиста
+func (r *playlistRepository) Update(ctx context.Context, playlist *models.Playlist) error {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	version := playlist.Version
+	playlist.Version++
+	res := db.Model(playlist).Where("version = ?", version).
+		Select("name", "description", "version", "updated_by", "updated_at").Updates(playlist)
+	if res.Error != nil {
+		playlist.Version = version
+		r.logger.WithContext(ctx).Errorf("Update: failed to update playlist with ID %d: %v", playlist.ID, res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		playlist.Version = version
+		r.logger.WithContext(ctx).Warnf("Update: playlist with ID %d version %d was not updated", playlist.ID, version)
+		return r.missedRow(ctx, playlist.ID)
+	}
+
+	r.logger.WithContext(ctx).Infof("Update: playlist with ID %d updated", playlist.ID)
+	return nil
+}
+
+// Удаление плейлиста вместе с его элементами
+func (r *playlistRepository) Delete(ctx context.Context, id uint, version int) error {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	query := db
+	if version != 0 {
+		query = query.Where("version = ?", version)
+	}
+	res := query.Delete(&models.Playlist{}, id)
+	if res.Error != nil {
+		r.logger.WithContext(ctx).Errorf("Delete: failed to delete playlist with ID %d: %v", id, res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return r.missedRow(ctx, id)
+	}
+
+	r.logger.WithContext(ctx).Infof("Delete: playlist with ID %d deleted", id)
+	return nil
+}
+
+// Песни плейлиста по порядку вместе с данными песен
+func (r *playlistRepository) Items(ctx context.Context, playlistId uint) ([]models.PlaylistItem, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var items []models.PlaylistItem
+	res := r.db.WithContext(ctx).
+		Joins("JOIN playlists ON playlists.id = playlist_items.playlist_id").
+		Scopes(tenantScope(ctx, "playlists")).
+		Where("playlist_items.playlist_id = ?", playlistId).
+		Order("playlist_items.position, playlist_items.id").
+		Preload("Song", tenantScope(ctx, "songs")).
+		Find(&items)
+	if res.Error != nil {
+		r.logger.WithContext(ctx).Errorf("Items: failed to fetch items of playlist with ID %d: %v", playlistId, res.Error)
+		return nil, res.Error
+	}
+	return items, nil
+}
+
+// Изменение песен плейлиста. Строка плейлиста блокируется до конца транзакции, поэтому
+// одновременные изменения одного плейлиста выполняются по очереди и видят результат друг друга.
+func (r *playlistRepository) EditItems(ctx context.Context, playlistId uint, version int, edit func(playlist *models.Playlist, items []models.PlaylistItem) ([]models.PlaylistItem, error)) (*models.Playlist, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var playlist models.Playlist
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Scopes(tenantScope(ctx, "playlists")).Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&playlist, playlistId).Error
+		if err != nil {
+			return notFound(err)
+		}
+		if version != 0 && playlist.Version != version {
+			return fmt.Errorf("%w: playlist with ID %d", repository.ErrConflict, playlistId)
+		}
+
+		var items []models.PlaylistItem
+		if err := tx.Where("playlist_id = ?", playlistId).Order("position, id").Find(&items).Error; err != nil {
+			return err
+		}
+		positions := make(map[uint]int, len(items))
+		for _, item := range items {
+			positions[item.ID] = item.Position
+		}
+
+		edited, err := edit(&playlist, items)
+		if err != nil {
+			return err
+		}
+
+		// Удаление элементов, которых нет в новом списке; edit может изменить items,
+		// поэтому прежний состав берется из positions
+		kept := make(map[uint]bool, len(edited))
+		for _, item := range edited {
+			kept[item.ID] = true
+		}
+		var removed []uint
+		for id := range positions {
+			if !kept[id] {
+				removed = append(removed, id)
+			}
+		}
+		if len(removed) > 0 {
+			if err := tx.Where("playlist_id = ? AND id IN ?", playlistId, removed).Delete(&models.PlaylistItem{}).Error; err != nil {
+				return err
+			}
+		}
+
+		// Новые позиции по порядку списка: изменившиеся обновляются одним запросом
+		var movedIds, movedPositions []int64
+		var added []*models.PlaylistItem
+		for i := range edited {
+			item := &edited[i]
+			item.PlaylistID = playlistId
+			item.Position = i + 1
+			switch {
+			case item.ID == 0:
+				added = append(added, item)
+			case positions[item.ID] != item.Position:
+				movedIds = append(movedIds, int64(item.ID))
+				movedPositions = append(movedPositions, int64(item.Position))
+			}
+		}
+		if len(movedIds) > 0 {
+			err := tx.Exec(`UPDATE playlist_items SET position = moved.position
+				FROM unnest(?::bigint[], ?::bigint[]) AS moved (id, position)
+				WHERE playlist_items.id = moved.id AND playlist_items.playlist_id = ?`,
+				pq.Int64Array(movedIds), pq.Int64Array(movedPositions), playlistId).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(added) > 0 {
+			songIds := make([]uint, len(added))
+			for i, item := range added {
+				songIds[i] = item.SongID
+			}
+			if err := checkSongsOwned(ctx, tx, songIds...); err != nil {
+				return err
+			}
+			if err := tx.Omit(clause.Associations).Create(added).Error; err != nil {
+				return err
+			}
+		}
+
+		playlist.Version++
+		return tx.Model(&playlist).Select("version", "updated_by", "updated_at").Updates(&playlist).Error
+	})
+	if err != nil {
+		r.logger.WithContext(ctx).Warnf("EditItems: items of playlist with ID %d were not changed: %v", playlistId, err)
+		return nil, err
+	}
+
+	r.logger.WithContext(ctx).Infof("EditItems: playlist with ID %d now has version %d", playlistId, playlist.Version)
+	return &playlist, nil
+}
+
+// Плейлисты арендатора с песнями songIds; строки плейлистов блокируются до конца транзакции,
+// как в EditItems, чтобы удаление песен не пересекалось с изменением состава плейлистов
+func lockSongPlaylists(ctx context.Context, tx *gorm.DB, songIds []uint) ([]uint, error) {
+	var playlistIds []uint
+	err := tx.Session(&gorm.Session{NewDB: true}).Model(&models.Playlist{}).Scopes(tenantScope(ctx, "playlists")).
+		Where("playlists.id IN (SELECT playlist_items.playlist_id FROM playlist_items WHERE playlist_items.song_id IN ?)", songIds).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Pluck("playlists.id", &playlistIds).Error
+	return playlistIds, err
+}
+
+// Удаление песни каскадно удаляет ее из плейлистов: позиции оставшихся песен снова
+// нумеруются подряд с 1, а версии плейлистов увеличиваются
+func compactPlaylists(tx *gorm.DB, playlistIds []uint) error {
+	if len(playlistIds) == 0 {
+		return nil
+	}
+	tx = tx.Session(&gorm.Session{NewDB: true})
+	err := tx.Exec(`UPDATE playlist_items SET position = renumbered.position
+		FROM (SELECT id, ROW_NUMBER() OVER (PARTITION BY playlist_id ORDER BY position, id) AS position
+			FROM playlist_items WHERE playlist_id IN ?) AS renumbered
+		WHERE playlist_items.id = renumbered.id AND playlist_items.position <> renumbered.position`,
+		playlistIds).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&models.Playlist{}).Where("id IN ?", playlistIds).Updates(map[string]interface{}{
+		"version":    gorm.Expr("version + 1"),
+		"updated_at": time.Now(),
+	}).Error
+}
@@ -1,121 +1,504 @@
 package postgresql
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"github.com/ananikitina/song_lib/internal/logging"
 	"github.com/ananikitina/song_lib/internal/models"
 	"github.com/ananikitina/song_lib/internal/repository"
 )
 
 type songRepository struct {
-	db     *gorm.DB
-	logger *logrus.Logger
+	db           *gorm.DB
+	logger       *logrus.Logger
+	queryTimeout time.Duration
 }
 
-func NewSongRepository(db *gorm.DB, logger *logrus.Logger) repository.SongRepository {
+func NewSongRepository(db *gorm.DB, logger *logrus.Logger, queryTimeout time.Duration) repository.SongRepository {
 	return &songRepository{
-		db:     db,
-		logger: logger,
+		db:           db,
+		logger:       logger,
+		queryTimeout: queryTimeout,
 	}
 }
 
-// Добавление песни
-func (r *songRepository) Add(song *models.Song) error {
-	if err := r.db.Create(song).Error; err != nil {
-		r.logger.Errorf("Add: failed to add song to database: %v", err)
+// Сессия базы данных, привязанная к контексту запроса и ограниченная таймаутом.
+// Все запросы ограничены песнями арендатора из контекста.
+func (r *songRepository) session(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	return r.db.WithContext(ctx).Scopes(tenantScope(ctx, "songs")), cancel
+}
+
+// Приведение ошибки gorm об отсутствии записи к ошибке репозитория
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: %v", repository.ErrNotFound, err)
+	}
+	return err
+}
+
+// Код ошибки PostgreSQL при нарушении уникальности
+const uniqueViolation = "23505"
+
+// Приведение ошибки уникальности к ErrDuplicate: у арендатора уже есть песня с такими группой и названием
+func duplicate(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return fmt.Errorf("%w: %s", repository.ErrDuplicate, pgErr.Detail)
+	}
+	return err
+}
+
+// Уникальные колонки песни: одна песня на группу и название у арендатора
+var songNameColumns = []clause.Column{{Name: "tenant_id"}, {Name: "group_name"}, {Name: "song_name"}}
+
+// Добавление песни вместе с ее участниками в одной транзакции
+func (r *songRepository) Add(ctx context.Context, song *models.Song) error {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	tenantID, err := currentTenant(ctx)
+	if err != nil {
 		return err
 	}
-	r.logger.Infof("Add: song added successfully")
+	song.TenantID = tenantID
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := checkSongQuota(ctx, tx, tenantID); err != nil {
+			return err
+		}
+		// Песню с теми же группой и названием мог добавить параллельный запрос
+		res := tx.Clauses(clause.OnConflict{Columns: songNameColumns, DoNothing: true}).Create(song)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return fmt.Errorf("%w: song %s by %s", repository.ErrDuplicate, song.SongName, song.GroupName)
+		}
+		if len(song.Credits) == 0 {
+			return nil
+		}
+		for i := range song.Credits {
+			song.Credits[i].SongID = song.ID
+		}
+		return tx.Create(&song.Credits).Error
+	})
+	if err != nil {
+		r.logger.WithContext(ctx).Errorf("Add: failed to add song to database: %v", err)
+		return err
+	}
+	r.logger.WithContext(ctx).Infof("Add: song added successfully")
 	return nil
 }
 
 // Получение всех песен
-func (r *songRepository) GetAll() ([]models.Song, error) {
+func (r *songRepository) GetAll(ctx context.Context) ([]models.Song, error) {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
 	var songs []models.Song
-	res := r.db.Find(&songs)
+	res := db.Find(&songs)
 	if res.Error != nil {
-		r.logger.Errorf("GetAll: failed to fetch all songs from database: %v", res.Error)
+		r.logger.WithContext(ctx).Errorf("GetAll: failed to fetch all songs from database: %v", res.Error)
 		return nil, res.Error
 	}
 
-	r.logger.Infof("GetAll: successfully fetched %d songs from database", len(songs))
+	r.logger.WithContext(ctx).Infof("GetAll: successfully fetched %d songs from database", len(songs))
 	return songs, nil
 }
 
+// Число песен арендатора
+func (r *songRepository) Count(ctx context.Context) (int64, error) {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	var count int64
+	if err := db.Model(&models.Song{}).Count(&count).Error; err != nil {
+		r.logger.WithContext(ctx).Errorf("Count: failed to count songs: %v", err)
+		return 0, err
+	}
+	return count, nil
+}
+
 // Получение песни по ID
-func (r *songRepository) GetById(id uint) (*models.Song, error) {
+func (r *songRepository) GetById(ctx context.Context, id uint) (*models.Song, error) {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
 	var song models.Song
-	res := r.db.First(&song, id)
+	res := db.First(&song, id)
 	if res.Error != nil {
-		r.logger.Errorf("GetById: failed to get song from database with ID %d: %v", id, res.Error)
-		return nil, res.Error
+		r.logger.WithContext(ctx).Errorf("GetById: failed to get song from database with ID %d: %v", id, res.Error)
+		return nil, notFound(res.Error)
 	}
 
-	r.logger.Infof("GetById: successfully retrieved song from database with ID %d", id)
+	r.logger.WithContext(ctx).Infof("GetById: successfully retrieved song from database with ID %d", id)
+	return &song, nil
+}
+
+// Получение песни по группе и названию
+func (r *songRepository) GetByName(ctx context.Context, groupName, songName string) (*models.Song, error) {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	var song models.Song
+	res := db.Where("group_name = ? AND song_name = ?", groupName, songName).Order("id").First(&song)
+	if res.Error != nil {
+		if !errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			r.logger.WithContext(ctx).Errorf("GetByName: failed to get song %s by %s from database: %v", songName, groupName, res.Error)
+		}
+		return nil, notFound(res.Error)
+	}
 	return &song, nil
 }
 
+// Колонки песен по именам полей API; допустимы и сами имена колонок
+var songColumns = map[string]string{
+	"id":          "id",
+	"group":       "group_name",
+	"song":        "song_name",
+	"releaseDate": "release_date",
+	"text":        "text",
+	"link":        "link",
+	"needsReview": "needs_review",
+	"createdBy":   "created_by",
+	"updatedBy":   "updated_by",
+}
+
+func songColumn(field string) (string, bool) {
+	if column, ok := songColumns[field]; ok {
+		return column, true
+	}
+	for _, column := range songColumns {
+		if column == field {
+			return column, true
+		}
+	}
+	return "", false
+}
+
+// Колонки песен, которые можно запросить в выборке, но не в фильтре
+var songReadColumns = map[string]string{
+	"validationReport": "validation_report",
+	"createdAt":        "created_at",
+	"updatedAt":        "updated_at",
+	"version":          "version",
+}
+
+// Выборка только запрошенных полей песни; ID выбирается всегда, пустой список означает все колонки
+func selectSongs(query *gorm.DB, fields []string) (*gorm.DB, error) {
+	if len(fields) == 0 {
+		return query, nil
+	}
+	columns := []string{"songs.id"}
+	for _, field := range fields {
+		column, ok := songColumns[field]
+		if !ok {
+			column, ok = songReadColumns[field]
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown field %q", repository.ErrInvalidFilter, field)
+		}
+		column = "songs." + column
+		if !slices.Contains(columns, column) {
+			columns = append(columns, column)
+		}
+	}
+	return query.Select(columns), nil
+}
+
+// Условия равенства по полям песни и фильтры по тегам и участникам
+func filterSongs(query *gorm.DB, filters map[string]interface{}) (*gorm.DB, error) {
+	for field, value := range filters {
+		switch field {
+		case "tag", "tagMatch", "credit", "creditRole":
+			continue
+		}
+		column, ok := songColumn(field)
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown field %q", repository.ErrInvalidFilter, field)
+		}
+		query = query.Where(fmt.Sprintf("songs.%s = ?", column), value)
+	}
+	query, err := filterTags(query, filters)
+	if err != nil {
+		return nil, err
+	}
+	return filterCredits(query, filters)
+}
+
+// Имена тегов из значения фильтра: строки через запятую или список строк
+func tagNames(value interface{}) ([]string, error) {
+	var values []string
+	switch v := value.(type) {
+	case string:
+		values = []string{v}
+	case []string:
+		values = v
+	case []interface{}:
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: tag must be a string", repository.ErrInvalidFilter)
+			}
+			values = append(values, s)
+		}
+	default:
+		return nil, fmt.Errorf("%w: tag must be a string or a list of strings", repository.ErrInvalidFilter)
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, list := range values {
+		for _, name := range strings.Split(list, ",") {
+			name = models.NormalizeTag(name)
+			if name != "" && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%w: tag must not be empty", repository.ErrInvalidFilter)
+	}
+	return names, nil
+}
+
+// Условие по тегам: песни хотя бы с одним из тегов (tagMatch=any) или со всеми тегами (tagMatch=all)
+func filterTags(query *gorm.DB, filters map[string]interface{}) (*gorm.DB, error) {
+	value, ok := filters["tag"]
+	if !ok {
+		return query, nil
+	}
+	names, err := tagNames(value)
+	if err != nil {
+		return nil, err
+	}
+
+	tagged := `SELECT song_tags.song_id FROM song_tags JOIN tags ON tags.id = song_tags.tag_id WHERE tags.name IN ?`
+	switch match := filters["tagMatch"]; match {
+	case nil, "", models.TagMatchAny:
+		return query.Where("songs.id IN ("+tagged+")", names), nil
+	case models.TagMatchAll:
+		return query.Where("songs.id IN ("+tagged+" GROUP BY song_tags.song_id HAVING COUNT(*) = ?)", names, len(names)), nil
+	default:
+		return nil, fmt.Errorf("%w: tagMatch must be %q or %q, got %v", repository.ErrInvalidFilter, models.TagMatchAny, models.TagMatchAll, match)
+	}
+}
+
+// Условие по участнику: песни, где он указан в любой роли или в роли creditRole; имя без учета регистра
+func filterCredits(query *gorm.DB, filters map[string]interface{}) (*gorm.DB, error) {
+	value, ok := filters["credit"]
+	if !ok {
+		return query, nil
+	}
+	name, ok := value.(string)
+	if name = strings.TrimSpace(name); !ok || name == "" {
+		return nil, fmt.Errorf("%w: credit must be a non-empty string", repository.ErrInvalidFilter)
+	}
+
+	credited := `SELECT song_credits.song_id FROM song_credits WHERE LOWER(song_credits.name) = LOWER(?)`
+	role, ok := filters["creditRole"]
+	if !ok || role == "" {
+		return query.Where("songs.id IN ("+credited+")", name), nil
+	}
+	if r, isString := role.(string); !isString || !slices.Contains(models.CreditRoles, r) {
+		return nil, fmt.Errorf("%w: creditRole must be one of %s, got %v", repository.ErrInvalidFilter, strings.Join(models.CreditRoles, ", "), role)
+	}
+	return query.Where("songs.id IN ("+credited+" AND song_credits.role = ?)", name, role), nil
+}
+
+// Песни по списку ID или фильтру
+func matchSongs(query *gorm.DB, match models.SongMatch) (*gorm.DB, error) {
+	if len(match.IDs) > 0 {
+		query = query.Where("songs.id IN ?", match.IDs)
+	}
+	return filterSongs(query, match.Filter)
+}
+
 // Получение песни с фильтрацией и пагинацией
-func (r *songRepository) GetWithFiltersAndPagination(filters map[string]interface{}, page int, pageSize int) ([]models.Song, error) {
+func (r *songRepository) GetWithFiltersAndPagination(ctx context.Context, filters map[string]interface{}, fields []string, page int, pageSize int) ([]models.Song, error) {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
 	var songs []models.Song
-	query := r.db.Model(&models.Song{})
+	query := db.Model(&models.Song{})
 
-	r.logger.Debugf("GetWithFiltersAndPagination: SQL query: %v", query.Statement.SQL.String())
+	r.logger.WithContext(ctx).Debugf("GetWithFiltersAndPagination: SQL query: %v", query.Statement.SQL.String())
 	delete(filters, "page")
 	delete(filters, "pageSize")
-	for key, value := range filters {
-		query = query.Where(fmt.Sprintf("%s = ?", key), value)
+	query, err := filterSongs(query, filters)
+	if err == nil {
+		query, err = selectSongs(query, fields)
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).Warnf("GetWithFiltersAndPagination: %v", err)
+		return nil, err
 	}
 
-	r.logger.Debugf("GetWithFiltersAndPagination: query with filters: %v", query)
+	r.logger.WithContext(ctx).Debugf("GetWithFiltersAndPagination: query with filters: %v", logging.Redact(filters))
 	res := query.Offset((page - 1) * pageSize).Limit(pageSize).Find(&songs)
 	if res.Error != nil {
-		r.logger.Errorf("GetWithFiltersAndPagination: failed to fetch songs with filters and pagination: %v", res.Error)
+		r.logger.WithContext(ctx).Errorf("GetWithFiltersAndPagination: failed to fetch songs with filters and pagination: %v", res.Error)
 		return nil, res.Error
 	}
 
-	r.logger.Infof("GetWithFiltersAndPagination: successfully fetched %d songs with filters and pagination", len(songs))
+	r.logger.WithContext(ctx).Infof("GetWithFiltersAndPagination: successfully fetched %d songs with filters and pagination", len(songs))
 	return songs, nil
 }
 
+// Построчное чтение песен по фильтру; таймаут запросов не применяется, так как выгрузка
+// всей библиотеки может идти дольше, ее ограничивает контекст вызывающего
+func (r *songRepository) Export(ctx context.Context, filters map[string]interface{}, fn func(*models.Song) error) error {
+	query, err := filterSongs(r.db.WithContext(ctx).Scopes(tenantScope(ctx, "songs")).Model(&models.Song{}), filters)
+	if err != nil {
+		r.logger.WithContext(ctx).Warnf("Export: %v", err)
+		return err
+	}
+
+	rows, err := query.Order("songs.id").Rows()
+	if err != nil {
+		r.logger.WithContext(ctx).Errorf("Export: failed to query songs: %v", err)
+		return err
+	}
+	defer rows.Close()
+
+	var count int
+	for rows.Next() {
+		var song models.Song
+		if err := r.db.ScanRows(rows, &song); err != nil {
+			r.logger.WithContext(ctx).Errorf("Export: failed to scan song: %v", err)
+			return err
+		}
+		if err := fn(&song); err != nil {
+			return err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).Errorf("Export: failed to read songs: %v", err)
+		return err
+	}
+
+	r.logger.WithContext(ctx).Infof("Export: exported %d songs", count)
+	return nil
+}
+
+// Ошибка для изменения, не затронувшего ни одной строки: песни нет или ее версия другая
+func (r *songRepository) missedRow(ctx context.Context, id uint) error {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	var count int64
+	if err := db.Model(&models.Song{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: song with ID %d", repository.ErrNotFound, id)
+	}
+	return fmt.Errorf("%w: song with ID %d", repository.ErrConflict, id)
+}
+
 // Обновление песни
-func (r *songRepository) Update(song *models.Song) error {
-	r.logger.Infof("Update: updating song in database with ID %d", song.ID)
-	if err := r.db.Save(song).Error; err != nil {
-		r.logger.Errorf("Update: failed to update song in database with ID %d: %v", song.ID, err)
+func (r *songRepository) Update(ctx context.Context, song *models.Song) error {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	r.logger.WithContext(ctx).Infof("Update: updating song in database with ID %d", song.ID)
+	// Save вставил бы отсутствующую у арендатора запись, поэтому обновляем только существующую
+	// и только прочитанную версию
+	tenantID, err := currentTenant(ctx)
+	if err != nil {
 		return err
 	}
+	song.TenantID = tenantID
+	version := song.Version
+	song.Version++
+	updated := false
+	err = db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(song).Where("version = ?", version).Select("*").Omit("created_at").Updates(song)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		updated = true
+		if song.Credits == nil {
+			return nil
+		}
+		return replaceCredits(tx, song.ID, song.Credits)
+	})
+	if err != nil {
+		song.Version = version
+		r.logger.WithContext(ctx).Errorf("Update: failed to update song in database with ID %d: %v", song.ID, err)
+		return duplicate(err)
+	}
+	if !updated {
+		song.Version = version
+		r.logger.WithContext(ctx).Warnf("Update: song with ID %d version %d was not updated", song.ID, version)
+		return r.missedRow(ctx, song.ID)
+	}
 
-	r.logger.Infof("Update: song with ID %d updated successfully in database", song.ID)
+	r.logger.WithContext(ctx).Infof("Update: song with ID %d updated successfully in database", song.ID)
 	return nil
 }
 
 // Удаление песни
-func (r *songRepository) Delete(id uint) error {
-	r.logger.Infof("Delete: deleting song from database with ID %d", id)
-	if err := r.db.Delete(&models.Song{}, id).Error; err != nil {
-		r.logger.Errorf("Delete: failed to delete song from database with ID %d: %v", id, err)
+func (r *songRepository) Delete(ctx context.Context, id uint, version int) error {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	r.logger.WithContext(ctx).Infof("Delete: deleting song from database with ID %d", id)
+	var deleted int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		playlistIds, err := lockSongPlaylists(ctx, tx, []uint{id})
+		if err != nil {
+			return err
+		}
+		query := tx
+		if version != 0 {
+			query = query.Where("version = ?", version)
+		}
+		res := query.Delete(&models.Song{}, id)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		deleted = res.RowsAffected
+		return compactPlaylists(tx, playlistIds)
+	})
+	if err != nil {
+		r.logger.WithContext(ctx).Errorf("Delete: failed to delete song from database with ID %d: %v", id, err)
 		return err
 	}
+	if deleted == 0 {
+		r.logger.WithContext(ctx).Warnf("Delete: song with ID %d version %d was not deleted", id, version)
+		return r.missedRow(ctx, id)
+	}
 
-	r.logger.Infof("Delete: song with ID %d deleted successfully", id)
+	r.logger.WithContext(ctx).Infof("Delete: song with ID %d deleted successfully", id)
 	return nil
 }
 
 // Получение текста песни с пагинацией по куплетам
-func (r *songRepository) GetVersesWithPagination(id uint, page int, pageSize int) ([]string, error) {
+func (r *songRepository) GetVersesWithPagination(ctx context.Context, id uint, page int, pageSize int) ([]string, error) {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
 	var song models.Song
-	res := r.db.First(&song, id)
+	res := db.First(&song, id)
 	if res.Error != nil {
-		r.logger.Errorf("GetVersesWithPagination: failed to get song from database with ID %d: %v", id, res.Error)
-		return nil, res.Error
+		r.logger.WithContext(ctx).Errorf("GetVersesWithPagination: failed to get song from database with ID %d: %v", id, res.Error)
+		return nil, notFound(res.Error)
 	}
 
-	verses := strings.Split(song.Text, "\n") // куплеты разделены новой строкой?
+	verses := song.Verses()
 
 	start := (page - 1) * pageSize
 	end := start + pageSize
@@ -129,3 +512,116 @@ func (r *songRepository) GetVersesWithPagination(id uint, page int, pageSize int
 
 	return verses[start:end], nil
 }
+
+// Число песен по списку ID или фильтру
+func (r *songRepository) CountMatching(ctx context.Context, match models.SongMatch) (int64, error) {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	query, err := matchSongs(db.Model(&models.Song{}), match)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		r.logger.WithContext(ctx).Errorf("CountMatching: failed to count songs: %v", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// Выполнение пакетного изменения в транзакции с откатом при превышении maxRows
+func (r *songRepository) changeMatching(ctx context.Context, match models.SongMatch, maxRows int64, change func(tx, query *gorm.DB) (int64, error)) (int64, error) {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	var affected int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		query, err := matchSongs(tx.Model(&models.Song{}), match)
+		if err != nil {
+			return err
+		}
+		affected, err = change(tx, query)
+		if err != nil {
+			return err
+		}
+		if maxRows > 0 && affected > maxRows {
+			return fmt.Errorf("%w: %d songs matched, at most %d allowed", repository.ErrTooManyRows, affected, maxRows)
+		}
+		return nil
+	})
+	return affected, err
+}
+
+// Пакетное изменение песен; версия каждой песни увеличивается
+func (r *songRepository) UpdateMatching(ctx context.Context, match models.SongMatch, changes map[string]interface{}, maxRows int64, recredit repository.CreditsFunc) (int64, error) {
+	columns := map[string]interface{}{
+		"updated_at": time.Now(),
+		"version":    gorm.Expr("version + 1"),
+	}
+	for field, value := range changes {
+		column, ok := songColumn(field)
+		if !ok {
+			return 0, fmt.Errorf("%w: unknown field %q", repository.ErrInvalidFilter, field)
+		}
+		columns[column] = value
+	}
+
+	affected, err := r.changeMatching(ctx, match, maxRows, func(tx, query *gorm.DB) (int64, error) {
+		if recredit == nil {
+			res := query.Updates(columns)
+			return res.RowsAffected, res.Error
+		}
+
+		// Песни до изменения блокируются, и изменяются ровно они, чтобы участники
+		// пересчитывались для каждой измененной песни
+		var before []models.Song
+		err := query.Session(&gorm.Session{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("songs.id", "songs.group_name", "songs.song_name").Find(&before).Error
+		if err != nil {
+			return 0, err
+		}
+		songIds := make([]uint, len(before))
+		for i, song := range before {
+			songIds[i] = song.ID
+		}
+		res := tx.Model(&models.Song{}).Where("songs.id IN ?", songIds).Updates(columns)
+		if res.Error != nil {
+			return res.RowsAffected, res.Error
+		}
+		return res.RowsAffected, recreditSongs(tx, before, recredit)
+	})
+	if err != nil {
+		r.logger.WithContext(ctx).Errorf("UpdateMatching: failed to update songs: %v", err)
+		return affected, duplicate(err)
+	}
+	r.logger.WithContext(ctx).Infof("UpdateMatching: updated %d songs", affected)
+	return affected, nil
+}
+
+// Пакетное удаление песен; плейлисты с удаленными песнями уплотняются
+func (r *songRepository) DeleteMatching(ctx context.Context, match models.SongMatch, maxRows int64) (int64, error) {
+	affected, err := r.changeMatching(ctx, match, maxRows, func(tx, query *gorm.DB) (int64, error) {
+		// Плейлисты блокируются раньше песен, в том же порядке, что и в EditItems
+		var songIds []uint
+		err := query.Session(&gorm.Session{}).Pluck("songs.id", &songIds).Error
+		if err != nil || len(songIds) == 0 {
+			return 0, err
+		}
+		playlistIds, err := lockSongPlaylists(ctx, tx, songIds)
+		if err != nil {
+			return 0, err
+		}
+		res := tx.Model(&models.Song{}).Where("songs.id IN ?", songIds).Delete(&models.Song{})
+		if res.Error != nil {
+			return res.RowsAffected, res.Error
+		}
+		return res.RowsAffected, compactPlaylists(tx, playlistIds)
+	})
+	if err != nil {
+		r.logger.WithContext(ctx).Errorf("DeleteMatching: failed to delete songs: %v", err)
+		return affected, err
+	}
+	r.logger.WithContext(ctx).Infof("DeleteMatching: deleted %d songs", affected)
+	return affected, nil
+}
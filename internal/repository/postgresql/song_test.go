@@ -0,0 +1,265 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/repository"
+	"github.com/ananikitina/song_lib/internal/tenant"
+)
+
+// База в режиме DryRun: запросы строятся, но не выполняются
+func newDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestTagNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    []string
+		wantErr error
+	}{
+		{name: "single", value: "Ballad", want: []string{"ballad"}},
+		{name: "comma separated", value: "80s, Ballad ,hard  rock", want: []string{"80s", "ballad", "hard rock"}},
+		{name: "repeated parameter", value: []string{"80s", "ballad,80S"}, want: []string{"80s", "ballad"}},
+		{name: "json list", value: []interface{}{"80s", " Ballad"}, want: []string{"80s", "ballad"}},
+		{name: "empty entries skipped", value: ",80s,, ,", want: []string{"80s"}},
+		{name: "empty", value: "", wantErr: repository.ErrInvalidFilter},
+		{name: "only separators", value: []string{" , ", ""}, wantErr: repository.ErrInvalidFilter},
+		{name: "not a string", value: 80, wantErr: repository.ErrInvalidFilter},
+		{name: "list with number", value: []interface{}{"80s", 80}, wantErr: repository.ErrInvalidFilter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tagNames(tt.value)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("tagNames() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && !slices.Equal(got, tt.want) {
+				t.Errorf("tagNames() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterTags(t *testing.T) {
+	db := newDryRunDB(t)
+
+	tests := []struct {
+		name     string
+		filters  map[string]interface{}
+		wantSQL  string
+		wantVars []interface{}
+		wantErr  error
+	}{
+		{
+			name:    "no tag filter",
+			filters: map[string]interface{}{"tagMatch": models.TagMatchAll},
+			wantSQL: `SELECT * FROM "songs"`,
+		},
+		{
+			name:     "any by default",
+			filters:  map[string]interface{}{"tag": "80s,Ballad"},
+			wantSQL:  `SELECT * FROM "songs" WHERE songs.id IN (SELECT song_tags.song_id FROM song_tags JOIN tags ON tags.id = song_tags.tag_id WHERE tags.name IN ($1,$2))`,
+			wantVars: []interface{}{"80s", "ballad"},
+		},
+		{
+			name:     "empty match is any",
+			filters:  map[string]interface{}{"tag": "80s", "tagMatch": ""},
+			wantSQL:  `SELECT * FROM "songs" WHERE songs.id IN (SELECT song_tags.song_id FROM song_tags JOIN tags ON tags.id = song_tags.tag_id WHERE tags.name IN ($1))`,
+			wantVars: []interface{}{"80s"},
+		},
+		{
+			name:     "all counts distinct tags",
+			filters:  map[string]interface{}{"tag": []string{"80s", "ballad", "80S"}, "tagMatch": models.TagMatchAll},
+			wantSQL:  `SELECT * FROM "songs" WHERE songs.id IN (SELECT song_tags.song_id FROM song_tags JOIN tags ON tags.id = song_tags.tag_id WHERE tags.name IN ($1,$2) GROUP BY song_tags.song_id HAVING COUNT(*) = $3)`,
+			wantVars: []interface{}{"80s", "ballad", 2},
+		},
+		{
+			name:    "unknown match",
+			filters: map[string]interface{}{"tag": "80s", "tagMatch": "most"},
+			wantErr: repository.ErrInvalidFilter,
+		},
+		{
+			name:    "empty tag",
+			filters: map[string]interface{}{"tag": " "},
+			wantErr: repository.ErrInvalidFilter,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := filterTags(db.Table("songs"), tt.filters)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("filterTags() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			var songs []models.Song
+			stmt := query.Find(&songs).Statement
+			if sql := strings.TrimSpace(stmt.SQL.String()); sql != tt.wantSQL {
+				t.Errorf("filterTags() SQL = %s, want %s", sql, tt.wantSQL)
+			}
+			if !slices.Equal(stmt.Vars, tt.wantVars) {
+				t.Errorf("filterTags() vars = %v, want %v", stmt.Vars, tt.wantVars)
+			}
+		})
+	}
+}
+
+func TestSelectSongs(t *testing.T) {
+	db := newDryRunDB(t)
+
+	tests := []struct {
+		name    string
+		fields  []string
+		wantSQL string
+		wantErr error
+	}{
+		{
+			name:    "all columns",
+			wantSQL: `SELECT * FROM "songs"`,
+		},
+		{
+			name:    "mapped columns",
+			fields:  []string{"group", "song", "text"},
+			wantSQL: `SELECT songs.id,songs.group_name,songs.song_name,songs.text FROM "songs"`,
+		},
+		{
+			name:    "read-only columns and id once",
+			fields:  []string{"id", "version", "updatedAt"},
+			wantSQL: `SELECT songs.id,songs.version,songs.updated_at FROM "songs"`,
+		},
+		{
+			name:    "unknown field",
+			fields:  []string{"credits"},
+			wantErr: repository.ErrInvalidFilter,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := selectSongs(db.Model(&models.Song{}), tt.fields)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("selectSongs() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			var songs []models.Song
+			stmt := query.Find(&songs).Statement
+			if sql := strings.TrimSpace(stmt.SQL.String()); sql != tt.wantSQL {
+				t.Errorf("selectSongs() SQL = %s, want %s", sql, tt.wantSQL)
+			}
+		})
+	}
+}
+
+func TestSongRepositoryAddSkipsDuplicateNames(t *testing.T) {
+	db, fake := newForeignDB(t)
+	repo := NewSongRepository(db, testLogger(), time.Minute)
+
+	if err := repo.Add(tenantContext(), &models.Song{GroupName: "Muse", SongName: "Uprising"}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	for _, s := range fake.recorded() {
+		if strings.HasPrefix(s.query, `INSERT INTO "songs"`) {
+			if !strings.Contains(s.query, `ON CONFLICT ("tenant_id","group_name","song_name") DO NOTHING`) {
+				t.Errorf("insert does not skip songs with the same group and name: %s", s.query)
+			}
+			return
+		}
+	}
+	t.Fatal("Add() did not insert the song")
+}
+
+func TestDuplicate(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "unique violation", err: fmt.Errorf("update: %w", &pgconn.PgError{Code: uniqueViolation}), want: repository.ErrDuplicate},
+		{name: "other constraint", err: &pgconn.PgError{Code: "23503"}, want: nil},
+		{name: "not a database error", err: errors.New("connection reset"), want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := duplicate(tt.err)
+			if tt.want != nil && !errors.Is(got, tt.want) {
+				t.Errorf("duplicate() = %v, want %v", got, tt.want)
+			}
+			if tt.want == nil && got != tt.err {
+				t.Errorf("duplicate() = %v, want the error unchanged", got)
+			}
+		})
+	}
+}
+
+func TestSongRepositoryDeleteCompactsPlaylists(t *testing.T) {
+	// В foreignDB все строки принадлежат арендатору tenant-b, поэтому его песня и плейлист находятся
+	ctx := tenant.WithTenant(context.Background(), &models.Tenant{ID: "tenant-b"})
+
+	tests := map[string]func(repository.SongRepository) error{
+		"delete": func(r repository.SongRepository) error {
+			return r.Delete(ctx, 2, 0)
+		},
+		"bulk delete": func(r repository.SongRepository) error {
+			_, err := r.DeleteMatching(ctx, models.SongMatch{Filter: map[string]interface{}{"group": "foreign"}}, 0)
+			return err
+		},
+	}
+
+	for name, operation := range tests {
+		t.Run(name, func(t *testing.T) {
+			db, fake := newForeignDB(t)
+			if err := operation(NewSongRepository(db, testLogger(), time.Second)); err != nil {
+				t.Fatalf("error = %v", err)
+			}
+
+			var steps []string
+			for _, s := range fake.recorded() {
+				switch {
+				case strings.Contains(s.query, `FROM "playlists"`) && strings.HasSuffix(s.query, "FOR UPDATE"):
+					steps = append(steps, "lock playlists")
+				case strings.HasPrefix(s.query, `DELETE FROM "songs"`):
+					steps = append(steps, "delete songs")
+				case strings.HasPrefix(s.query, "UPDATE playlist_items SET position"):
+					steps = append(steps, "renumber items")
+				case strings.HasPrefix(s.query, `UPDATE "playlists" SET`) && strings.Contains(s.query, `"version"=version + 1`):
+					steps = append(steps, "bump versions")
+				}
+			}
+			want := []string{"lock playlists", "delete songs", "renumber items", "bump versions"}
+			if !slices.Equal(steps, want) {
+				t.Errorf("steps = %v, want %v", steps, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/repository"
+)
+
+type tagRepository struct {
+	db           *gorm.DB
+	logger       *logrus.Logger
+	queryTimeout time.Duration
+}
+
+func NewTagRepository(db *gorm.DB, logger *logrus.Logger, queryTimeout time.Duration) repository.TagRepository {
+	return &tagRepository{
+		db:           db,
+		logger:       logger,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// Сессия без условия по арендатору: запросы к связям песен с тегами ограничиваются
+// арендатором через таблицы tags и songs
+func (r *tagRepository) session(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	return r.db.WithContext(ctx), cancel
+}
+
+// Теги арендатора с числом песен
+func (r *tagRepository) List(ctx context.Context) ([]models.TagCount, error) {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	var counts []models.TagCount
+	res := db.Table("tags").Select("tags.name, COUNT(*) AS songs").
+		Joins("JOIN song_tags ON song_tags.tag_id = tags.id").
+		Scopes(tenantScope(ctx, "tags")).
+		Group("tags.name").Order("tags.name").
+		Scan(&counts)
+	if res.Error != nil {
+		r.logger.WithContext(ctx).Errorf("List: failed to fetch tags: %v", res.Error)
+		return nil, res.Error
+	}
+	return counts, nil
+}
+
+// Теги песни
+func (r *tagRepository) SongTags(ctx context.Context, songId uint) ([]string, error) {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	names := []string{}
+	res := db.Table("tags").
+		Joins("JOIN song_tags ON song_tags.tag_id = tags.id").
+		Scopes(tenantScope(ctx, "tags")).
+		Where("song_tags.song_id = ?", songId).
+		Order("tags.name").
+		Pluck("tags.name", &names)
+	if res.Error != nil {
+		r.logger.WithContext(ctx).Errorf("SongTags: failed to fetch tags of song with ID %d: %v", songId, res.Error)
+		return nil, res.Error
+	}
+	return names, nil
+}
+
+// ID тегов по именам; недостающие теги создаются
+func tagIds(ctx context.Context, tx *gorm.DB, names []string) ([]uint, error) {
+	tenantID, err := currentTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]models.Tag, len(names))
+	for i, name := range names {
+		tags[i] = models.Tag{TenantID: tenantID, Name: name}
+	}
+	// Тег мог создать одновременный запрос; тогда его ID читается следующим запросом
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+		return nil, err
+	}
+
+	var ids []uint
+	err = tx.Model(&models.Tag{}).Scopes(tenantScope(ctx, "tags")).
+		Where("name IN ?", names).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// Связи песни с тегами; уже существующие пропускаются
+func linkTags(tx *gorm.DB, songId uint, ids []uint) error {
+	links := make([]models.SongTag, len(ids))
+	for i, id := range ids {
+		links[i] = models.SongTag{SongID: songId, TagID: id}
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error
+}
+
+// Добавление тегов песне в одной транзакции
+func (r *tagRepository) AddSongTags(ctx context.Context, songId uint, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := checkSongsOwned(ctx, tx, songId); err != nil {
+			return err
+		}
+		ids, err := tagIds(ctx, tx, names)
+		if err != nil {
+			return err
+		}
+		return linkTags(tx, songId, ids)
+	})
+	if err != nil {
+		r.logger.WithContext(ctx).Errorf("AddSongTags: failed to tag song with ID %d: %v", songId, err)
+		return err
+	}
+
+	r.logger.WithContext(ctx).Infof("AddSongTags: song with ID %d tagged with %d tags", songId, len(names))
+	return nil
+}
+
+// Замена тегов песни в одной транзакции
+func (r *tagRepository) SetSongTags(ctx context.Context, songId uint, names []string) error {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := checkSongsOwned(ctx, tx, songId); err != nil {
+			return err
+		}
+		var ids []uint
+		if len(names) > 0 {
+			var err error
+			if ids, err = tagIds(ctx, tx, names); err != nil {
+				return err
+			}
+		}
+
+		query := tx.Where("song_id = ?", songId)
+		if len(ids) > 0 {
+			query = query.Where("tag_id NOT IN ?", ids)
+		}
+		if err := query.Delete(&models.SongTag{}).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return linkTags(tx, songId, ids)
+	})
+	if err != nil {
+		r.logger.WithContext(ctx).Errorf("SetSongTags: failed to set tags of song with ID %d: %v", songId, err)
+		return err
+	}
+
+	r.logger.WithContext(ctx).Infof("SetSongTags: song with ID %d now has %d tags", songId, len(names))
+	return nil
+}
+
+// Снятие тега с песни
+func (r *tagRepository) RemoveSongTag(ctx context.Context, songId uint, name string) error {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	res := db.Where("song_id = ? AND tag_id IN (?)", songId,
+		db.Model(&models.Tag{}).Select("id").Scopes(tenantScope(ctx, "tags")).Where("name = ?", name)).
+		Delete(&models.SongTag{})
+	if res.Error != nil {
+		r.logger.WithContext(ctx).Errorf("RemoveSongTag: failed to remove tag %q from song with ID %d: %v", name, songId, res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w: song with ID %d has no tag %q", repository.ErrNotFound, songId, name)
+	}
+
+	r.logger.WithContext(ctx).Infof("RemoveSongTag: tag %q removed from song with ID %d", name, songId)
+	return nil
+}
@@ -0,0 +1,160 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/repository"
+	"github.com/ananikitina/song_lib/internal/tenant"
+)
+
+// Идентификатор арендатора из контекста; tenant.ErrMissing, если он не определен
+func currentTenant(ctx context.Context) (string, error) {
+	id, ok := tenant.ID(ctx)
+	if !ok {
+		return "", tenant.ErrMissing
+	}
+	return id, nil
+}
+
+// Ограничение запроса данными арендатора из контекста; без арендатора запрос завершается ошибкой
+func tenantScope(ctx context.Context, table string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		id, err := currentTenant(ctx)
+		if err != nil {
+			db.AddError(err)
+			return db
+		}
+		return db.Where(table+".tenant_id = ?", id)
+	}
+}
+
+// Проверка, что все песни songIds принадлежат арендатору из контекста; иначе ErrNotFound.
+// Нужна репозиториям, которые меняют связанные с песнями данные без условия по арендатору.
+func checkSongsOwned(ctx context.Context, tx *gorm.DB, songIds ...uint) error {
+	songIds = slices.Clone(songIds)
+	slices.Sort(songIds)
+	songIds = slices.Compact(songIds)
+	var count int64
+	err := tx.Model(&models.Song{}).Scopes(tenantScope(ctx, "songs")).
+		Where("songs.id IN ?", songIds).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count != int64(len(songIds)) {
+		return fmt.Errorf("%w: songs with IDs %v", repository.ErrNotFound, songIds)
+	}
+	return nil
+}
+
+// Проверка квоты арендатора на число песен перед добавлением песни в транзакции tx.
+// Строка арендатора блокируется до конца транзакции, поэтому одновременные добавления
+// проверяют квоту по очереди и не превышают ее.
+func checkSongQuota(ctx context.Context, tx *gorm.DB, tenantID string) error {
+	// Арендаторы без квоты не блокируются; квота в контексте может устареть,
+	// поэтому ее значение перечитывается из заблокированной строки
+	if t := tenant.FromContext(ctx); t == nil || t.MaxSongs == nil {
+		return nil
+	}
+	// Новый запрос в той же транзакции, без условия по песням арендатора из сессии tx
+	var t models.Tenant
+	err := tx.Session(&gorm.Session{NewDB: true}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "max_songs").Where("id = ?", tenantID).Take(&t).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && t.MaxSongs == nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var count int64
+	if err := tx.Model(&models.Song{}).Scopes(tenantScope(ctx, "songs")).Count(&count).Error; err != nil {
+		return err
+	}
+	if count >= int64(*t.MaxSongs) {
+		return fmt.Errorf("%w: tenant %s is limited to %d songs", repository.ErrQuotaExceeded, tenantID, *t.MaxSongs)
+	}
+	return nil
+}
+
+type tenantRepository struct {
+	db           *gorm.DB
+	logger       *logrus.Logger
+	queryTimeout time.Duration
+}
+
+func NewTenantRepository(db *gorm.DB, logger *logrus.Logger, queryTimeout time.Duration) repository.TenantRepository {
+	return &tenantRepository{
+		db:           db,
+		logger:       logger,
+		queryTimeout: queryTimeout,
+	}
+}
+
+func (r *tenantRepository) session(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	return r.db.WithContext(ctx), cancel
+}
+
+// Добавление арендатора
+func (r *tenantRepository) Add(ctx context.Context, t *models.Tenant) error {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	if err := db.Create(t).Error; err != nil {
+		r.logger.WithContext(ctx).Errorf("Add: failed to add tenant to database: %v", err)
+		return err
+	}
+	r.logger.WithContext(ctx).Infof("Add: tenant %s added", t.ID)
+	return nil
+}
+
+// Получение всех арендаторов
+func (r *tenantRepository) GetAll(ctx context.Context) ([]models.Tenant, error) {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	var tenants []models.Tenant
+	if err := db.Order("id").Find(&tenants).Error; err != nil {
+		r.logger.WithContext(ctx).Errorf("GetAll: failed to fetch tenants from database: %v", err)
+		return nil, err
+	}
+	return tenants, nil
+}
+
+// Получение арендатора по ID
+func (r *tenantRepository) GetById(ctx context.Context, id string) (*models.Tenant, error) {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	var t models.Tenant
+	if err := db.Where("id = ?", id).First(&t).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &t, nil
+}
+
+// Обновление настроек арендатора
+func (r *tenantRepository) Update(ctx context.Context, t *models.Tenant) error {
+	db, cancel := r.session(ctx)
+	defer cancel()
+
+	res := db.Model(t).Select("*").Omit("created_at").Updates(t)
+	if res.Error != nil {
+		r.logger.WithContext(ctx).Errorf("Update: failed to update tenant %s: %v", t.ID, res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	r.logger.WithContext(ctx).Infof("Update: tenant %s updated", t.ID)
+	return nil
+}
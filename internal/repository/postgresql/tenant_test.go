@@ -0,0 +1,443 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/repository"
+	"github.com/ananikitina/song_lib/internal/tenant"
+)
+
+// Арендатор теста; все данные в базе принадлежат другому арендатору
+const testTenant = "tenant-a"
+
+// Условие по арендатору в WHERE или JOIN: "songs.tenant_id = $1"
+var tenantCondition = regexp.MustCompile(`tenant_id"? = \$(\d+)`)
+
+// Выполненный запрос
+type statement struct {
+	query string
+	args  []driver.NamedValue
+}
+
+// Запрос ограничен арендатором теста
+func (s statement) scoped() bool {
+	for _, m := range tenantCondition.FindAllStringSubmatch(s.query, -1) {
+		for _, arg := range s.args {
+			if m[1] == strconv.Itoa(arg.Ordinal) && arg.Value == testTenant {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s statement) write() bool {
+	verb, _, _ := strings.Cut(strings.TrimSpace(s.query), " ")
+	switch strings.ToUpper(verb) {
+	case "INSERT", "UPDATE", "DELETE":
+		return true
+	}
+	return false
+}
+
+// База данных, в которой все строки принадлежат другому арендатору: запрос с условием
+// по арендатору теста не находит и не изменяет ни одной строки, запрос без него - находит и изменяет.
+// Так пропущенное условие по арендатору проявляется утечкой чужих данных.
+type foreignDB struct {
+	mu         sync.Mutex
+	statements []statement
+}
+
+func (db *foreignDB) record(query string, args []driver.NamedValue) statement {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	s := statement{query: query, args: args}
+	db.statements = append(db.statements, s)
+	return s
+}
+
+func (db *foreignDB) recorded() []statement {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return append([]statement(nil), db.statements...)
+}
+
+func (db *foreignDB) Connect(context.Context) (driver.Conn, error) { return &foreignConn{db: db}, nil }
+func (db *foreignDB) Driver() driver.Driver                        { return nil }
+
+type foreignConn struct{ db *foreignDB }
+
+func (c *foreignConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+func (c *foreignConn) Close() error                                                 { return nil }
+func (c *foreignConn) Begin() (driver.Tx, error)                                    { return c, nil }
+func (c *foreignConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) { return c, nil }
+func (c *foreignConn) Commit() error                                                { return nil }
+func (c *foreignConn) Rollback() error                                              { return nil }
+func (c *foreignConn) CheckNamedValue(*driver.NamedValue) error                     { return nil }
+
+func (c *foreignConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if c.db.record(query, args).scoped() {
+		return driver.RowsAffected(0), nil
+	}
+	return driver.RowsAffected(1), nil
+}
+
+var selectColumns = regexp.MustCompile(`(?is)^\s*SELECT\s+(.+?)\s+FROM\s`)
+
+func (c *foreignConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	s := c.db.record(query, args)
+	columns := []string{"id"}
+	if m := selectColumns.FindStringSubmatch(query); m != nil && m[1] != "*" {
+		columns = nil
+		for _, column := range strings.Split(m[1], ",") {
+			column = strings.TrimSpace(column)
+			if _, alias, found := strings.Cut(strings.ToUpper(column), " AS "); found {
+				column = alias
+			} else if i := strings.LastIndex(column, "."); i >= 0 {
+				column = column[i+1:]
+			}
+			columns = append(columns, strings.ToLower(strings.Trim(column, `"`)))
+		}
+	}
+	// Без условия по арендатору находится строка другого арендатора; INSERT ... RETURNING
+	// арендатора теста тоже возвращает строку
+	found := !s.scoped() || s.write()
+	return &foreignRows{columns: columns, left: found}, nil
+}
+
+type foreignRows struct {
+	columns []string
+	left    bool
+}
+
+func (r *foreignRows) Columns() []string { return r.columns }
+func (r *foreignRows) Close() error      { return nil }
+
+func (r *foreignRows) Next(dest []driver.Value) error {
+	if !r.left {
+		return io.EOF
+	}
+	r.left = false
+	for i, column := range r.columns {
+		switch {
+		case strings.Contains(column, "count"), column == "id", column == "version", column == "position", column == "max_songs", strings.HasSuffix(column, "_id") && column != "tenant_id":
+			dest[i] = int64(2)
+		case strings.HasSuffix(column, "_at"):
+			dest[i] = time.Now()
+		case column == "needs_review":
+			dest[i] = false
+		case column == "validation_report":
+			dest[i] = nil
+		case column == "tenant_id":
+			dest[i] = "tenant-b"
+		default:
+			dest[i] = "foreign"
+		}
+	}
+	return nil
+}
+
+func newForeignDB(t *testing.T) (*gorm.DB, *foreignDB) {
+	t.Helper()
+	fake := &foreignDB{}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(fake)}), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, fake
+}
+
+func testLogger() *logrus.Logger {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	return log
+}
+
+func tenantContext() context.Context {
+	return tenant.WithTenant(context.Background(), &models.Tenant{ID: testTenant})
+}
+
+// Проверка, что операция не нашла и не изменила чужих данных
+func checkIsolated(t *testing.T, fake *foreignDB, leaked bool, err error) {
+	t.Helper()
+	if leaked {
+		t.Errorf("operation returned data of another tenant (err %v)", err)
+	}
+	for _, s := range fake.recorded() {
+		if !s.scoped() {
+			t.Errorf("statement is not limited to the tenant: %s", s.query)
+		}
+	}
+}
+
+func TestSongRepositoryTenantIsolation(t *testing.T) {
+	ctx := tenantContext()
+	foreignSong := uint(2)
+	match := models.SongMatch{IDs: []uint{foreignSong}}
+
+	tests := map[string]func(repository.SongRepository) (bool, error){
+		"get": func(r repository.SongRepository) (bool, error) {
+			song, err := r.GetById(ctx, foreignSong)
+			return song != nil || !errors.Is(err, repository.ErrNotFound), err
+		},
+		"get by name": func(r repository.SongRepository) (bool, error) {
+			song, err := r.GetByName(ctx, "foreign", "foreign")
+			return song != nil, err
+		},
+		"list": func(r repository.SongRepository) (bool, error) {
+			songs, err := r.GetWithFiltersAndPagination(ctx, map[string]interface{}{"group": "foreign"}, []string{"group", "text"}, 1, 10)
+			return len(songs) > 0, err
+		},
+		"list all": func(r repository.SongRepository) (bool, error) {
+			songs, err := r.GetAll(ctx)
+			return len(songs) > 0, err
+		},
+		"count": func(r repository.SongRepository) (bool, error) {
+			count, err := r.Count(ctx)
+			return count > 0, err
+		},
+		"verses": func(r repository.SongRepository) (bool, error) {
+			verses, err := r.GetVersesWithPagination(ctx, foreignSong, 1, 10)
+			return verses != nil || !errors.Is(err, repository.ErrNotFound), err
+		},
+		"update": func(r repository.SongRepository) (bool, error) {
+			err := r.Update(ctx, &models.Song{ID: foreignSong, GroupName: "mine", SongName: "mine", Version: 2})
+			return !errors.Is(err, repository.ErrNotFound), err
+		},
+		"delete": func(r repository.SongRepository) (bool, error) {
+			err := r.Delete(ctx, foreignSong, 2)
+			return !errors.Is(err, repository.ErrNotFound), err
+		},
+		"delete any version": func(r repository.SongRepository) (bool, error) {
+			err := r.Delete(ctx, foreignSong, 0)
+			return !errors.Is(err, repository.ErrNotFound), err
+		},
+		"export": func(r repository.SongRepository) (bool, error) {
+			exported := 0
+			err := r.Export(ctx, nil, func(*models.Song) error {
+				exported++
+				return nil
+			})
+			return exported > 0, err
+		},
+		"bulk count": func(r repository.SongRepository) (bool, error) {
+			count, err := r.CountMatching(ctx, match)
+			return count > 0, err
+		},
+		"bulk update": func(r repository.SongRepository) (bool, error) {
+			affected, err := r.UpdateMatching(ctx, match, map[string]interface{}{"link": "mine"}, 0, nil)
+			return affected > 0, err
+		},
+		"bulk rename": func(r repository.SongRepository) (bool, error) {
+			recredited := 0
+			affected, err := r.UpdateMatching(ctx, match, map[string]interface{}{"group": "mine"}, 0,
+				func(before *models.Song, credits []models.SongCredit) []models.SongCredit {
+					recredited++
+					return credits
+				})
+			return affected > 0 || recredited > 0, err
+		},
+		"bulk delete": func(r repository.SongRepository) (bool, error) {
+			affected, err := r.DeleteMatching(ctx, models.SongMatch{Filter: map[string]interface{}{"group": "foreign"}}, 0)
+			return affected > 0, err
+		},
+	}
+
+	for name, operation := range tests {
+		t.Run(name, func(t *testing.T) {
+			db, fake := newForeignDB(t)
+			leaked, err := operation(NewSongRepository(db, testLogger(), time.Second))
+			checkIsolated(t, fake, leaked, err)
+		})
+	}
+}
+
+func TestTagRepositoryTenantIsolation(t *testing.T) {
+	ctx := tenantContext()
+	foreignSong := uint(2)
+
+	tests := map[string]func(repository.TagRepository) (bool, error){
+		"list": func(r repository.TagRepository) (bool, error) {
+			tags, err := r.List(ctx)
+			return len(tags) > 0, err
+		},
+		"song tags": func(r repository.TagRepository) (bool, error) {
+			tags, err := r.SongTags(ctx, foreignSong)
+			return len(tags) > 0, err
+		},
+		"add": func(r repository.TagRepository) (bool, error) {
+			err := r.AddSongTags(ctx, foreignSong, []string{"mine"})
+			return !errors.Is(err, repository.ErrNotFound), err
+		},
+		"set": func(r repository.TagRepository) (bool, error) {
+			err := r.SetSongTags(ctx, foreignSong, nil)
+			return !errors.Is(err, repository.ErrNotFound), err
+		},
+		"remove": func(r repository.TagRepository) (bool, error) {
+			err := r.RemoveSongTag(ctx, foreignSong, "foreign")
+			return !errors.Is(err, repository.ErrNotFound), err
+		},
+	}
+
+	for name, operation := range tests {
+		t.Run(name, func(t *testing.T) {
+			db, fake := newForeignDB(t)
+			leaked, err := operation(NewTagRepository(db, testLogger(), time.Second))
+			checkIsolated(t, fake, leaked, err)
+		})
+	}
+}
+
+func TestCreditRepositoryTenantIsolation(t *testing.T) {
+	ctx := tenantContext()
+	foreignSong := uint(2)
+
+	tests := map[string]func(repository.CreditRepository) (bool, error){
+		"song credits": func(r repository.CreditRepository) (bool, error) {
+			credits, err := r.SongCredits(ctx, foreignSong)
+			return len(credits) > 0, err
+		},
+		"set": func(r repository.CreditRepository) (bool, error) {
+			err := r.SetSongCredits(ctx, foreignSong, []models.SongCredit{{Name: "Mine", Role: models.CreditRoleArtist}})
+			return !errors.Is(err, repository.ErrNotFound), err
+		},
+		"clear": func(r repository.CreditRepository) (bool, error) {
+			err := r.SetSongCredits(ctx, foreignSong, nil)
+			return !errors.Is(err, repository.ErrNotFound), err
+		},
+	}
+
+	for name, operation := range tests {
+		t.Run(name, func(t *testing.T) {
+			db, fake := newForeignDB(t)
+			leaked, err := operation(NewCreditRepository(db, testLogger(), time.Second))
+			checkIsolated(t, fake, leaked, err)
+		})
+	}
+}
+
+func TestPlaylistRepositoryTenantIsolation(t *testing.T) {
+	ctx := tenantContext()
+	foreignPlaylist := uint(2)
+
+	tests := map[string]func(repository.PlaylistRepository) (bool, error){
+		"get": func(r repository.PlaylistRepository) (bool, error) {
+			playlist, err := r.GetById(ctx, foreignPlaylist)
+			return playlist != nil || !errors.Is(err, repository.ErrNotFound), err
+		},
+		"list": func(r repository.PlaylistRepository) (bool, error) {
+			playlists, err := r.List(ctx, 1, 10)
+			return len(playlists) > 0, err
+		},
+		"items": func(r repository.PlaylistRepository) (bool, error) {
+			items, err := r.Items(ctx, foreignPlaylist)
+			return len(items) > 0, err
+		},
+		"update": func(r repository.PlaylistRepository) (bool, error) {
+			err := r.Update(ctx, &models.Playlist{ID: foreignPlaylist, Name: "mine", Version: 2})
+			return !errors.Is(err, repository.ErrNotFound), err
+		},
+		"delete": func(r repository.PlaylistRepository) (bool, error) {
+			err := r.Delete(ctx, foreignPlaylist, 0)
+			return !errors.Is(err, repository.ErrNotFound), err
+		},
+		"edit items": func(r repository.PlaylistRepository) (bool, error) {
+			_, err := r.EditItems(ctx, foreignPlaylist, 0, func(_ *models.Playlist, items []models.PlaylistItem) ([]models.PlaylistItem, error) {
+				return nil, nil
+			})
+			return !errors.Is(err, repository.ErrNotFound), err
+		},
+	}
+
+	for name, operation := range tests {
+		t.Run(name, func(t *testing.T) {
+			db, fake := newForeignDB(t)
+			leaked, err := operation(NewPlaylistRepository(db, testLogger(), time.Second))
+			checkIsolated(t, fake, leaked, err)
+		})
+	}
+}
+
+func TestRepositoriesRequireTenant(t *testing.T) {
+	ctx := context.Background()
+
+	tests := map[string]func(db *gorm.DB) error{
+		"song get": func(db *gorm.DB) error {
+			_, err := NewSongRepository(db, testLogger(), time.Second).GetById(ctx, 1)
+			return err
+		},
+		"song add": func(db *gorm.DB) error {
+			return NewSongRepository(db, testLogger(), time.Second).Add(ctx, &models.Song{GroupName: "g", SongName: "s"})
+		},
+		"song bulk delete": func(db *gorm.DB) error {
+			_, err := NewSongRepository(db, testLogger(), time.Second).DeleteMatching(ctx, models.SongMatch{IDs: []uint{1}}, 0)
+			return err
+		},
+		"tags add": func(db *gorm.DB) error {
+			return NewTagRepository(db, testLogger(), time.Second).AddSongTags(ctx, 1, []string{"rock"})
+		},
+		"credits get": func(db *gorm.DB) error {
+			_, err := NewCreditRepository(db, testLogger(), time.Second).SongCredits(ctx, 1)
+			return err
+		},
+		"playlist add": func(db *gorm.DB) error {
+			return NewPlaylistRepository(db, testLogger(), time.Second).Add(ctx, &models.Playlist{Name: "p"})
+		},
+	}
+
+	for name, operation := range tests {
+		t.Run(name, func(t *testing.T) {
+			db, fake := newForeignDB(t)
+			if err := operation(db); !errors.Is(err, tenant.ErrMissing) {
+				t.Errorf("error = %v, want tenant.ErrMissing", err)
+			}
+			for _, s := range fake.recorded() {
+				t.Errorf("statement executed without tenant: %s", s.query)
+			}
+		})
+	}
+}
+
+func TestSongRepositoryAddLocksTenantForQuota(t *testing.T) {
+	maxSongs := 10
+	ctx := tenant.WithTenant(context.Background(), &models.Tenant{ID: testTenant, MaxSongs: &maxSongs})
+	db, fake := newForeignDB(t)
+	if err := NewSongRepository(db, testLogger(), time.Second).Add(ctx, &models.Song{GroupName: "Muse", SongName: "Uprising"}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	statements := fake.recorded()
+	if len(statements) != 3 {
+		t.Fatalf("executed %d statements, want lock, count and insert", len(statements))
+	}
+	lock, count, insert := statements[0], statements[1], statements[2]
+	if !strings.Contains(lock.query, `FROM "tenants" WHERE id = $1`) || !strings.HasSuffix(lock.query, "FOR UPDATE") || strings.Contains(lock.query, "songs.") {
+		t.Errorf("first statement does not lock the tenant row: %s", lock.query)
+	}
+	if !strings.Contains(count.query, "count(*)") || !count.scoped() {
+		t.Errorf("second statement does not count the tenant songs: %s", count.query)
+	}
+	if !strings.HasPrefix(insert.query, `INSERT INTO "songs"`) {
+		t.Errorf("third statement does not insert the song: %s", insert.query)
+	}
+}
@@ -1,15 +1,60 @@
 package repository
 
 import (
+	"context"
+	"errors"
+
 	"github.com/ananikitina/song_lib/internal/models"
 )
 
+var ErrNotFound = errors.New("record not found")
+
+// Запись с такими же уникальными полями уже есть
+var ErrDuplicate = errors.New("record already exists")
+
+// Запись изменилась с момента чтения
+var ErrConflict = errors.New("record was modified concurrently")
+
+// Фильтр по неизвестному полю
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// Пакетное изменение затронуло бы больше записей, чем разрешено
+var ErrTooManyRows = errors.New("too many rows affected")
+
+// Добавление превысило бы квоту арендатора
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// Пересчет участников песни при пакетном изменении: получает песню до изменения
+// и ее текущих участников, возвращает новых участников
+type CreditsFunc func(before *models.Song, credits []models.SongCredit) []models.SongCredit
+
 type SongRepository interface {
-	Add(song *models.Song) error
-	GetAll() ([]models.Song, error)
-	GetById(id uint) (*models.Song, error)
-	GetWithFiltersAndPagination(filters map[string]interface{}, page int, pageSize int) ([]models.Song, error)
-	Update(song *models.Song) error
-	Delete(id uint) error
-	GetVersesWithPagination(id uint, page int, pageSize int) ([]string, error)
+	// Добавление песни; ее участники song.Credits сохраняются в той же транзакции.
+	// Если у арендатора задана квота на число песен и она исчерпана, возвращается ErrQuotaExceeded,
+	// если у арендатора уже есть песня с такими группой и названием - ErrDuplicate.
+	Add(ctx context.Context, song *models.Song) error
+	GetAll(ctx context.Context) ([]models.Song, error)
+	GetById(ctx context.Context, id uint) (*models.Song, error)
+	// Получение песни по группе и названию; ErrNotFound, если такой нет
+	GetByName(ctx context.Context, groupName, songName string) (*models.Song, error)
+	Count(ctx context.Context) (int64, error)
+	GetWithFiltersAndPagination(ctx context.Context, filters map[string]interface{}, fields []string, page int, pageSize int) ([]models.Song, error)
+	// Построчное чтение песен по фильтру курсором БД в порядке ID; fn вызывается для каждой песни,
+	// ее ошибка прерывает чтение. Запрос ограничен только контекстом вызывающего.
+	Export(ctx context.Context, filters map[string]interface{}, fn func(*models.Song) error) error
+	// Обновление песни, только если ее версия не изменилась с момента чтения; иначе ErrConflict.
+	// Версия песни увеличивается. Если song.Credits != nil, участники заменяются в той же транзакции.
+	// Переименование в группу и название другой песни арендатора возвращает ErrDuplicate.
+	Update(ctx context.Context, song *models.Song) error
+	// Удаление песни; ErrNotFound, если ее нет, при ненулевой version только этой версии, иначе ErrConflict
+	Delete(ctx context.Context, id uint, version int) error
+	GetVersesWithPagination(ctx context.Context, id uint, page int, pageSize int) ([]string, error)
+	// Число песен по списку ID или фильтру
+	CountMatching(ctx context.Context, match models.SongMatch) (int64, error)
+	// Пакетное изменение и удаление песен по списку ID или фильтру в одной транзакции.
+	// Если затронуто больше maxRows (при maxRows > 0) песен, транзакция откатывается с ErrTooManyRows.
+	// При ненулевом recredit участники каждой измененной песни заменяются его результатом.
+	// Если изменение совпало бы по группе и названию с другой песней, возвращается ErrDuplicate.
+	UpdateMatching(ctx context.Context, match models.SongMatch, changes map[string]interface{}, maxRows int64, recredit CreditsFunc) (int64, error)
+	DeleteMatching(ctx context.Context, match models.SongMatch, maxRows int64) (int64, error)
 }
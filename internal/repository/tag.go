@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+// Теги песен; имена тегов передаются уже нормализованными
+type TagRepository interface {
+	// Теги, которыми отмечена хотя бы одна песня, с числом песен
+	List(ctx context.Context) ([]models.TagCount, error)
+	// Теги песни по алфавиту
+	SongTags(ctx context.Context, songId uint) ([]string, error)
+	// Добавление тегов песне; недостающие теги создаются
+	AddSongTags(ctx context.Context, songId uint, names []string) error
+	// Замена всех тегов песни
+	SetSongTags(ctx context.Context, songId uint, names []string) error
+	// Снятие тега с песни; ErrNotFound, если песня им не отмечена
+	RemoveSongTag(ctx context.Context, songId uint, name string) error
+}
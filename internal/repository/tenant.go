@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+type TenantRepository interface {
+	Add(ctx context.Context, tenant *models.Tenant) error
+	GetAll(ctx context.Context) ([]models.Tenant, error)
+	GetById(ctx context.Context, id string) (*models.Tenant, error)
+	Update(ctx context.Context, tenant *models.Tenant) error
+}
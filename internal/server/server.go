@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/config"
+)
+
+type Server struct {
+	httpServer *http.Server
+	logger     *logrus.Logger
+}
+
+func NewServer(cfg *config.Config, handler http.Handler, logger *logrus.Logger) *Server {
+	return &Server{
+		httpServer: &http.Server{
+			Addr:              cfg.Addr(),
+			Handler:           handler,
+			ReadTimeout:       cfg.HTTPReadTimeout,
+			ReadHeaderTimeout: cfg.HTTPReadHeaderTimeout,
+			WriteTimeout:      cfg.HTTPWriteTimeout,
+			IdleTimeout:       cfg.HTTPIdleTimeout,
+		},
+		logger: logger,
+	}
+}
+
+// Запуск сервера: порт занимается синхронно, обработка запросов идет в фоне.
+// Возвращаемый канал получает ошибку, если сервер остановился не через Shutdown.
+func (s *Server) Start() (<-chan error, error) {
+	listener, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", s.httpServer.Addr, err)
+	}
+
+	s.logger.Infof("Server has started on %s...", listener.Addr())
+
+	errCh := make(chan error, 1)
+	go func() {
+		if err := s.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	return errCh, nil
+}
+
+// Остановка сервера с ожиданием завершения обрабатываемых запросов
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.logger.Info("Server is shutting down...")
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to shut down server gracefully: %w", err)
+	}
+	s.logger.Info("Server stopped")
+	return nil
+}
@@ -0,0 +1,15 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+type APIKeyService interface {
+	CreateKey(ctx context.Context, req models.CreateAPIKeyRequest) (*models.APIKeyWithSecret, error)
+	ListKeys(ctx context.Context) ([]models.APIKey, error)
+	RotateKey(ctx context.Context, id uint) (*models.APIKeyWithSecret, error)
+	RevokeKey(ctx context.Context, id uint) error
+	Authenticate(ctx context.Context, key string) (*models.APIKey, error)
+}
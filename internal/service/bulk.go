@@ -0,0 +1,18 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+type BulkSongService interface {
+	// Добавление песен пакетом; onResult вызывается последовательно по мере обработки песен
+	AddSongs(ctx context.Context, songs []models.AddSongRequest, onResult func(models.BulkAddResult)) error
+	// Запуск пакетного добавления фоновым заданием
+	StartAddSongsJob(ctx context.Context, songs []models.AddSongRequest) (*models.BulkJob, error)
+	GetJob(ctx context.Context, id string) (*models.BulkJob, error)
+	// Пакетные изменение и удаление песен по списку ID или фильтру
+	UpdateSongs(ctx context.Context, req models.BulkUpdateRequest) (*models.BulkChangeResult, error)
+	DeleteSongs(ctx context.Context, req models.BulkDeleteRequest) (*models.BulkChangeResult, error)
+}
@@ -0,0 +1,16 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+type ChangeRequestService interface {
+	// Предложение изменений к текущей версии песни; при ненулевой version только к этой версии
+	ProposeChange(ctx context.Context, songId uint, changes models.SongChanges, version int) (*models.ChangeRequest, error)
+	ListChangeRequests(ctx context.Context, status string, page int, pageSize int) ([]models.ChangeRequest, error)
+	// Одобрение применяет изменения, только если песня не менялась после предложения
+	ApproveChangeRequest(ctx context.Context, id uint, comment string) (*models.ChangeRequest, error)
+	RejectChangeRequest(ctx context.Context, id uint, comment string) (*models.ChangeRequest, error)
+}
@@ -0,0 +1,13 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+// Участники песен по ролям: исполнитель, приглашенные артисты, композитор и автор текста
+type CreditService interface {
+	GetSongCredits(ctx context.Context, songId uint) ([]models.SongCredit, error)
+	SetSongCredits(ctx context.Context, songId uint, credits []models.SongCredit) ([]models.SongCredit, error)
+}
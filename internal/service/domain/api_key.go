@@ -0,0 +1,247 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/repository"
+	"github.com/ananikitina/song_lib/internal/service"
+)
+
+var (
+	ErrInvalidAPIKey  = errors.New("invalid API key")
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	ErrAPIKeyRevoked  = errors.New("API key is revoked")
+	ErrUnknownScope   = errors.New("unknown scope")
+	ErrUnknownRole    = errors.New("unknown role")
+	ErrInvalidExpiry  = errors.New("expiresAt must be in the future")
+)
+
+// Ключ имеет вид sl_<prefix>_<secret>; префикс хранится открыто для идентификации ключа
+const apiKeyPrefix = "sl_"
+
+// Как часто обновлять время последнего использования ключа
+const lastUsedResolution = time.Minute
+
+type apiKeyService struct {
+	repo   repository.APIKeyRepository
+	logger *logrus.Logger
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository, logger *logrus.Logger) service.APIKeyService {
+	return &apiKeyService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Генерация ключа: открытое значение, префикс и хэш для хранения
+func generateAPIKey() (key, prefix, hash string, err error) {
+	prefixBytes := make([]byte, 4)
+	secretBytes := make([]byte, 32)
+	if _, err = rand.Read(prefixBytes); err != nil {
+		return "", "", "", err
+	}
+	if _, err = rand.Read(secretBytes); err != nil {
+		return "", "", "", err
+	}
+
+	prefix = hex.EncodeToString(prefixBytes)
+	key = apiKeyPrefix + prefix + "_" + base64.RawURLEncoding.EncodeToString(secretBytes)
+	return key, prefix, hashAPIKey(key), nil
+}
+
+// Проверка формата API-ключа (в отличие от JWT)
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyPrefix)
+}
+
+// Ключи содержат 256 бит случайных данных, поэтому для хранения достаточно SHA-256
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func validateScopes(scopes []string) error {
+	for _, scope := range scopes {
+		known := false
+		for _, s := range models.Scopes {
+			if scope == s {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("%w: %s", ErrUnknownScope, scope)
+		}
+	}
+	return nil
+}
+
+// Создание ключа
+func (s *apiKeyService) CreateKey(ctx context.Context, req models.CreateAPIKeyRequest) (*models.APIKeyWithSecret, error) {
+	if err := s.validateCreateRequest(req); err != nil {
+		s.logger.WithContext(ctx).Warnf("CreateKey: invalid request: %v", err)
+		return nil, err
+	}
+
+	key, prefix, hash, err := generateAPIKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	apiKey := &models.APIKey{
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    uniqueScopes(append(req.Scopes, models.ExpandRoles(req.Roles)...)),
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := s.repo.Add(ctx, apiKey); err != nil {
+		s.logger.WithContext(ctx).Errorf("CreateKey: failed to save API key: %v", err)
+		return nil, err
+	}
+
+	s.logger.WithContext(ctx).Infof("CreateKey: API key %s created with ID: %d", prefix, apiKey.ID)
+	return &models.APIKeyWithSecret{APIKey: *apiKey, Key: key}, nil
+}
+
+func (s *apiKeyService) validateCreateRequest(req models.CreateAPIKeyRequest) error {
+	if strings.TrimSpace(req.Name) == "" || len(req.Scopes)+len(req.Roles) == 0 {
+		return ErrEmptyParameters
+	}
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		return ErrInvalidExpiry
+	}
+	for _, role := range req.Roles {
+		if _, ok := models.RoleScopes[role]; !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownRole, role)
+		}
+	}
+	return validateScopes(req.Scopes)
+}
+
+// Удаление повторяющихся областей доступа с сохранением порядка
+func uniqueScopes(scopes []string) []string {
+	seen := make(map[string]bool, len(scopes))
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	return result
+}
+
+// Получение всех ключей
+func (s *apiKeyService) ListKeys(ctx context.Context) ([]models.APIKey, error) {
+	keys, err := s.repo.GetAll(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Errorf("ListKeys: failed to fetch API keys: %v", err)
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (s *apiKeyService) getKey(ctx context.Context, id uint) (*models.APIKey, error) {
+	if id == 0 {
+		return nil, ErrInvalidID
+	}
+	apiKey, err := s.repo.GetById(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrAPIKeyNotFound
+	}
+	return apiKey, err
+}
+
+// Ротация ключа: новое секретное значение с теми же областями доступа
+func (s *apiKeyService) RotateKey(ctx context.Context, id uint) (*models.APIKeyWithSecret, error) {
+	apiKey, err := s.getKey(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Warnf("RotateKey: failed to get API key: %v", err)
+		return nil, err
+	}
+	if apiKey.RevokedAt != nil {
+		return nil, ErrAPIKeyRevoked
+	}
+
+	key, prefix, hash, err := generateAPIKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	oldPrefix := apiKey.Prefix
+	apiKey.Prefix = prefix
+	apiKey.KeyHash = hash
+	apiKey.LastUsedAt = nil
+
+	if err := s.repo.Update(ctx, apiKey); err != nil {
+		s.logger.WithContext(ctx).Errorf("RotateKey: failed to save API key: %v", err)
+		return nil, err
+	}
+
+	s.logger.WithContext(ctx).Infof("RotateKey: API key %s rotated to %s", oldPrefix, prefix)
+	return &models.APIKeyWithSecret{APIKey: *apiKey, Key: key}, nil
+}
+
+// Отзыв ключа
+func (s *apiKeyService) RevokeKey(ctx context.Context, id uint) error {
+	apiKey, err := s.getKey(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Warnf("RevokeKey: failed to get API key: %v", err)
+		return err
+	}
+	if apiKey.RevokedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	apiKey.RevokedAt = &now
+	if err := s.repo.Update(ctx, apiKey); err != nil {
+		s.logger.WithContext(ctx).Errorf("RevokeKey: failed to save API key: %v", err)
+		return err
+	}
+
+	s.logger.WithContext(ctx).Infof("RevokeKey: API key %s revoked", apiKey.Prefix)
+	return nil
+}
+
+// Проверка ключа из заголовка Authorization
+func (s *apiKeyService) Authenticate(ctx context.Context, key string) (*models.APIKey, error) {
+	if !IsAPIKey(key) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	apiKey, err := s.repo.GetByHash(ctx, hashAPIKey(key))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).Errorf("Authenticate: failed to look up API key: %v", err)
+		return nil, err
+	}
+
+	now := time.Now()
+	if apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && apiKey.ExpiresAt.Before(now)) {
+		s.logger.WithContext(ctx).Warnf("Authenticate: API key %s is revoked or expired", apiKey.Prefix)
+		return nil, ErrInvalidAPIKey
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > lastUsedResolution {
+		if err := s.repo.TouchLastUsed(ctx, apiKey.ID, now); err != nil {
+			s.logger.WithContext(ctx).Warnf("Authenticate: failed to update last used time: %v", err)
+		}
+	}
+
+	return apiKey, nil
+}
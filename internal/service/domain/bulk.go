@@ -0,0 +1,396 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/internal/identity"
+	"github.com/ananikitina/song_lib/internal/logging"
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/repository"
+	"github.com/ananikitina/song_lib/internal/service"
+	"github.com/ananikitina/song_lib/internal/tenant"
+	"github.com/ananikitina/song_lib/internal/workers"
+)
+
+var (
+	ErrBulkEmpty       = errors.New("no songs to add")
+	ErrBulkTooLarge    = errors.New("too many songs in one request")
+	ErrBulkJobNotFound = errors.New("bulk job not found")
+	ErrInvalidMatch    = errors.New("either ids or filter must be given")
+	ErrTooManySongs    = errors.New("too many songs affected")
+)
+
+type bulkSongService struct {
+	songService service.SongService
+	repo        repository.SongRepository
+	workers     *workers.Group
+	logger      *logrus.Logger
+	concurrency int
+	maxItems    int
+	maxAffected int
+	jobTTL      time.Duration
+
+	mu   sync.Mutex
+	jobs map[string]*models.BulkJob
+}
+
+func NewBulkSongService(songService service.SongService, repo repository.SongRepository, group *workers.Group, logger *logrus.Logger, concurrency, maxItems, maxAffected int, jobTTL time.Duration) service.BulkSongService {
+	return &bulkSongService{
+		songService: songService,
+		repo:        repo,
+		workers:     group,
+		logger:      logger,
+		concurrency: concurrency,
+		maxItems:    maxItems,
+		maxAffected: maxAffected,
+		jobTTL:      jobTTL,
+		jobs:        make(map[string]*models.BulkJob),
+	}
+}
+
+func (s *bulkSongService) validateBatch(songs []models.AddSongRequest) error {
+	if len(songs) == 0 {
+		return ErrBulkEmpty
+	}
+	if len(songs) > s.maxItems {
+		return fmt.Errorf("%w: %d songs, at most %d allowed", ErrBulkTooLarge, len(songs), s.maxItems)
+	}
+	return nil
+}
+
+// Статус песни пакета по ошибке добавления
+func bulkStatus(err error) string {
+	var validationErr *ValidationError
+	switch {
+	case errors.Is(err, ErrEmptyParameters):
+		return models.BulkStatusInvalid
+	case errors.As(err, &validationErr):
+		return models.BulkStatusRejected
+	case errors.Is(err, ErrFailedAPIRequest):
+		return models.BulkStatusEnrichmentFailed
+	default:
+		return models.BulkStatusFailed
+	}
+}
+
+// Добавление одной песни пакета; песня, уже имеющаяся у арендатора, не добавляется повторно
+func (s *bulkSongService) addOne(ctx context.Context, index int, req models.AddSongRequest) models.BulkAddResult {
+	result := models.BulkAddResult{Index: index, Group: req.Group, Song: req.Song}
+
+	existing, err := s.repo.GetByName(ctx, req.Group, req.Song)
+	switch {
+	case err == nil:
+		result.Status = models.BulkStatusDuplicate
+		result.SongID = existing.ID
+		return result
+	case !errors.Is(err, repository.ErrNotFound):
+		result.Status = models.BulkStatusFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	song, err := s.songService.AddSong(ctx, req.Group, req.Song)
+	if errors.Is(err, ErrSongExists) {
+		// Песню добавил параллельный запрос после проверки
+		if existing, err := s.repo.GetByName(ctx, req.Group, req.Song); err == nil {
+			result.Status = models.BulkStatusDuplicate
+			result.SongID = existing.ID
+			return result
+		}
+	}
+	if err != nil {
+		result.Status = bulkStatus(err)
+		result.Error = err.Error()
+		return result
+	}
+	result.Status = models.BulkStatusCreated
+	result.SongID = song.ID
+	return result
+}
+
+// Добавление песен пакетом с ограничением числа одновременных запросов к внешнему API
+func (s *bulkSongService) AddSongs(ctx context.Context, songs []models.AddSongRequest, onResult func(models.BulkAddResult)) error {
+	if err := s.validateBatch(songs); err != nil {
+		s.logger.WithContext(ctx).Warnf("AddSongs: %v", err)
+		return err
+	}
+
+	s.logger.WithContext(ctx).Infof("AddSongs: adding %d songs", len(songs))
+
+	batch := make([]models.AddSongRequest, len(songs))
+	for i, req := range songs {
+		batch[i] = models.AddSongRequest{Group: normalizeString(req.Group), Song: normalizeString(req.Song)}
+	}
+
+	indexes := make(chan int)
+	results := make(chan models.BulkAddResult)
+	var wg sync.WaitGroup
+	for i := 0; i < min(s.concurrency, len(batch)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				results <- s.addOne(ctx, index, batch[index])
+			}
+		}()
+	}
+
+	// Повторы внутри пакета отмечаются без обращения к базе данных
+	go func() {
+		defer close(indexes)
+		seen := make(map[[2]string]bool, len(batch))
+		for index, req := range batch {
+			key := [2]string{req.Group, req.Song}
+			if seen[key] && req.Group != "" && req.Song != "" {
+				results <- models.BulkAddResult{Index: index, Group: req.Group, Song: req.Song, Status: models.BulkStatusDuplicate}
+				continue
+			}
+			seen[key] = true
+			select {
+			case indexes <- index:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	counts := make(map[string]int)
+	for result := range results {
+		counts[result.Status]++
+		onResult(result)
+	}
+
+	s.logger.WithContext(ctx).Infof("AddSongs: processed songs: %v", counts)
+	return ctx.Err()
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Удаление завершенных заданий старше jobTTL; вызывается под s.mu
+func (s *bulkSongService) pruneJobs() {
+	for id, job := range s.jobs {
+		if job.FinishedAt != nil && time.Since(*job.FinishedAt) > s.jobTTL {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+// Запуск пакетного добавления фоновым заданием; результаты хранятся в памяти экземпляра jobTTL после завершения
+func (s *bulkSongService) StartAddSongsJob(ctx context.Context, songs []models.AddSongRequest) (*models.BulkJob, error) {
+	if err := s.validateBatch(songs); err != nil {
+		s.logger.WithContext(ctx).Warnf("StartAddSongsJob: %v", err)
+		return nil, err
+	}
+
+	tenantID, ok := tenant.ID(ctx)
+	if !ok {
+		return nil, tenant.ErrMissing
+	}
+	id, err := newJobID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate job ID: %w", err)
+	}
+	job := &models.BulkJob{
+		ID:        id,
+		TenantID:  tenantID,
+		Status:    models.JobStatusRunning,
+		Total:     len(songs),
+		Summary:   models.BulkSummary{},
+		Results:   make([]models.BulkAddResult, 0, len(songs)),
+		CreatedBy: identity.Subject(ctx),
+		CreatedAt: time.Now(),
+	}
+
+	s.mu.Lock()
+	s.pruneJobs()
+	s.jobs[id] = job
+	s.mu.Unlock()
+
+	// Задание переживает запрос, но отменяется при остановке приложения
+	jobCtx := context.WithoutCancel(ctx)
+	s.workers.Go("bulk-job-"+id, func(workersCtx context.Context) {
+		ctx, cancel := context.WithCancel(jobCtx)
+		defer cancel()
+		stop := context.AfterFunc(workersCtx, cancel)
+		defer stop()
+
+		err := s.AddSongs(ctx, songs, func(result models.BulkAddResult) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			job.Results = append(job.Results, result)
+			job.Summary[result.Status]++
+			job.Processed++
+		})
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		now := time.Now()
+		job.FinishedAt = &now
+		job.Status = models.JobStatusCompleted
+		if err != nil {
+			job.Status = models.JobStatusCanceled
+		}
+		s.logger.WithContext(ctx).Infof("StartAddSongsJob: job %s %s, processed %d of %d songs", id, job.Status, job.Processed, job.Total)
+	})
+
+	s.logger.WithContext(ctx).Infof("StartAddSongsJob: started job %s for %d songs", id, len(songs))
+	return s.snapshot(job), nil
+}
+
+// Копия задания для чтения вне s.mu
+func (s *bulkSongService) snapshot(job *models.BulkJob) *models.BulkJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *job
+	copied.Results = append([]models.BulkAddResult(nil), job.Results...)
+	copied.Summary = make(models.BulkSummary, len(job.Summary))
+	for status, count := range job.Summary {
+		copied.Summary[status] = count
+	}
+	return &copied
+}
+
+// Получение задания арендатора
+func (s *bulkSongService) GetJob(ctx context.Context, id string) (*models.BulkJob, error) {
+	s.mu.Lock()
+	job, ok := s.jobs[id]
+	s.mu.Unlock()
+	if tenantID, found := tenant.ID(ctx); !ok || !found || job.TenantID != tenantID {
+		return nil, ErrBulkJobNotFound
+	}
+	return s.snapshot(job), nil
+}
+
+// Проверка выбора песен: ровно один из списка ID и фильтра
+func validateMatch(match models.SongMatch) error {
+	if (len(match.IDs) == 0) == (len(match.Filter) == 0) {
+		return ErrInvalidMatch
+	}
+	return nil
+}
+
+// Ошибки пакетного изменения в терминах сервиса
+func bulkChangeError(err error) error {
+	if errors.Is(err, repository.ErrTooManyRows) {
+		return fmt.Errorf("%w: %v; set force to override", ErrTooManySongs, err)
+	}
+	return songNotFound(err)
+}
+
+// Предел числа затрагиваемых песен; 0 - без ограничения
+func (s *bulkSongService) affectedLimit(force bool) int64 {
+	if force {
+		return 0
+	}
+	return int64(s.maxAffected)
+}
+
+// Пробный запуск: число песен, которые были бы затронуты, с той же проверкой предела
+func (s *bulkSongService) dryRun(ctx context.Context, match models.SongMatch, force bool) (*models.BulkChangeResult, error) {
+	count, err := s.repo.CountMatching(ctx, match)
+	if err != nil {
+		return nil, bulkChangeError(err)
+	}
+	if limit := s.affectedLimit(force); limit > 0 && count > limit {
+		return nil, bulkChangeError(fmt.Errorf("%w: %d songs matched, at most %d allowed", repository.ErrTooManyRows, count, limit))
+	}
+	return &models.BulkChangeResult{Affected: count, DryRun: true}, nil
+}
+
+// Пакетное изменение песен: changes применяются ко всем выбранным песням по правилам PATCH
+func (s *bulkSongService) UpdateSongs(ctx context.Context, req models.BulkUpdateRequest) (*models.BulkChangeResult, error) {
+	if err := validateMatch(req.SongMatch); err != nil {
+		return nil, err
+	}
+	if req.Changes.Empty() {
+		return nil, ErrEmptyParameters
+	}
+
+	// Нормализация и валидация изменений на пустой песне
+	var normalized models.Song
+	if err := applySongPatch(&normalized, req.Changes); err != nil {
+		s.logger.WithContext(ctx).Warnf("UpdateSongs: changes rejected: %v", err)
+		return nil, err
+	}
+	changes := map[string]interface{}{"updatedBy": identity.Subject(ctx)}
+	for field, value := range map[string]struct {
+		patch models.PatchString
+		value string
+	}{
+		"group":       {req.Changes.GroupName, normalized.GroupName},
+		"song":        {req.Changes.SongName, normalized.SongName},
+		"releaseDate": {req.Changes.ReleaseDate, normalized.ReleaseDate},
+		"text":        {req.Changes.Text, normalized.Text},
+		"link":        {req.Changes.Link, normalized.Link},
+	} {
+		if value.patch.Set {
+			changes[field] = value.value
+		}
+	}
+
+	if req.DryRun {
+		return s.dryRun(ctx, req.SongMatch, req.Force)
+	}
+
+	// При смене группы или названия участники из названий пересчитываются для каждой песни
+	var recredit repository.CreditsFunc
+	if req.Changes.GroupName.Set || req.Changes.SongName.Set {
+		recredit = func(before *models.Song, credits []models.SongCredit) []models.SongCredit {
+			groupName, songName := before.GroupName, before.SongName
+			if req.Changes.GroupName.Set {
+				groupName = normalized.GroupName
+			}
+			if req.Changes.SongName.Set {
+				songName = normalized.SongName
+			}
+			return rederiveCredits(credits, before.GroupName, before.SongName, groupName, songName)
+		}
+	}
+
+	s.logger.WithContext(ctx).Infof("UpdateSongs: updating songs matching %d IDs and filter %v", len(req.IDs), logging.Redact(req.Filter))
+	affected, err := s.repo.UpdateMatching(ctx, req.SongMatch, changes, s.affectedLimit(req.Force), recredit)
+	if err != nil {
+		s.logger.WithContext(ctx).Warnf("UpdateSongs: failed to update songs: %v", err)
+		return nil, bulkChangeError(err)
+	}
+	s.logger.WithContext(ctx).Infof("UpdateSongs: updated %d songs", affected)
+	return &models.BulkChangeResult{Affected: affected}, nil
+}
+
+// Пакетное удаление песен
+func (s *bulkSongService) DeleteSongs(ctx context.Context, req models.BulkDeleteRequest) (*models.BulkChangeResult, error) {
+	if err := validateMatch(req.SongMatch); err != nil {
+		return nil, err
+	}
+	if req.DryRun {
+		return s.dryRun(ctx, req.SongMatch, req.Force)
+	}
+
+	s.logger.WithContext(ctx).Infof("DeleteSongs: deleting songs matching %d IDs and filter %v", len(req.IDs), logging.Redact(req.Filter))
+	affected, err := s.repo.DeleteMatching(ctx, req.SongMatch, s.affectedLimit(req.Force))
+	if err != nil {
+		s.logger.WithContext(ctx).Warnf("DeleteSongs: failed to delete songs: %v", err)
+		return nil, bulkChangeError(err)
+	}
+	s.logger.WithContext(ctx).Infof("DeleteSongs: deleted %d songs", affected)
+	return &models.BulkChangeResult{Affected: affected}, nil
+}
@@ -0,0 +1,421 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/service"
+	"github.com/ananikitina/song_lib/internal/tenant"
+	"github.com/ananikitina/song_lib/internal/workers"
+)
+
+// Добавление песен без внешнего API: исход зависит от названия песни.
+// Песню "Raced" перед добавлением добавляет "параллельный запрос".
+// Считает одновременные вызовы; при block вызов ждет отмены контекста.
+type fakeAddSongs struct {
+	service.SongService
+	repo     *memorySongs
+	delay    time.Duration
+	block    bool
+	inFlight atomic.Int32
+	maxSeen  atomic.Int32
+}
+
+func (s *fakeAddSongs) AddSong(ctx context.Context, groupName, songName string) (*models.Song, error) {
+	current := s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+	for seen := s.maxSeen.Load(); current > seen && !s.maxSeen.CompareAndSwap(seen, current); seen = s.maxSeen.Load() {
+	}
+	time.Sleep(s.delay)
+	if s.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	switch {
+	case groupName == "" || songName == "":
+		return nil, ErrEmptyParameters
+	case songName == "Rejected":
+		return nil, &ValidationError{Report: models.ValidationReport{Issues: []models.ValidationIssue{
+			{Field: "link", Rule: "url", Message: "invalid link", Action: models.ValidationActionReject},
+		}}}
+	case songName == "Offline":
+		return nil, fmt.Errorf("%w: unexpected status code: 503", ErrFailedAPIRequest)
+	case songName == "Broken":
+		return nil, errors.New("connection reset")
+	case songName == "Raced":
+		// Параллельный запрос добавил песню после проверки повторов
+		s.repo.Add(ctx, &models.Song{GroupName: groupName, SongName: songName})
+	}
+	song := &models.Song{GroupName: groupName, SongName: songName}
+	if err := s.repo.Add(ctx, song); err != nil {
+		return nil, songNotFound(err)
+	}
+	return song, nil
+}
+
+func newTestBulkService(t *testing.T, songs *fakeAddSongs, concurrency, maxItems, maxAffected int) (*bulkSongService, *workers.Group) {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	group := workers.NewGroup(logger)
+	t.Cleanup(func() { group.Shutdown(context.Background()) })
+	return NewBulkSongService(songs, songs.repo, group, logger, concurrency, maxItems, maxAffected, time.Hour).(*bulkSongService), group
+}
+
+func collectResults(t *testing.T, svc *bulkSongService, ctx context.Context, batch []models.AddSongRequest) []models.BulkAddResult {
+	t.Helper()
+	var results []models.BulkAddResult
+	if err := svc.AddSongs(ctx, batch, func(result models.BulkAddResult) {
+		results = append(results, result)
+	}); err != nil {
+		t.Fatalf("AddSongs() error = %v", err)
+	}
+	slices.SortFunc(results, func(a, b models.BulkAddResult) int { return a.Index - b.Index })
+	return results
+}
+
+func TestAddSongsPerItemResults(t *testing.T) {
+	repo := &memorySongs{}
+	repo.Add(context.Background(), &models.Song{GroupName: "Muse", SongName: "Uprising"})
+	svc, _ := newTestBulkService(t, &fakeAddSongs{repo: repo}, 3, 10, 100)
+
+	batch := []models.AddSongRequest{
+		{Group: "Muse", Song: "Starlight"},
+		{Group: " Muse ", Song: "Starlight"},
+		{Group: "Muse", Song: "Uprising"},
+		{Group: "Muse", Song: ""},
+		{Group: "Muse", Song: "Rejected"},
+		{Group: "Muse", Song: "Offline"},
+		{Group: "Muse", Song: "Broken"},
+		{Group: "Muse", Song: "Raced"},
+	}
+	want := []string{
+		models.BulkStatusCreated,
+		models.BulkStatusDuplicate,
+		models.BulkStatusDuplicate,
+		models.BulkStatusInvalid,
+		models.BulkStatusRejected,
+		models.BulkStatusEnrichmentFailed,
+		models.BulkStatusFailed,
+		models.BulkStatusDuplicate,
+	}
+
+	results := collectResults(t, svc, context.Background(), batch)
+	if len(results) != len(batch) {
+		t.Fatalf("AddSongs() returned %d results, want %d", len(results), len(batch))
+	}
+	for i, result := range results {
+		if result.Index != i || result.Status != want[i] {
+			t.Errorf("result %d = %+v, want status %q", i, result, want[i])
+		}
+		failed := result.Status != models.BulkStatusCreated && result.Status != models.BulkStatusDuplicate
+		if failed != (result.Error != "") {
+			t.Errorf("result %d status %q has error %q", i, result.Status, result.Error)
+		}
+	}
+	if results[2].SongID != 1 {
+		t.Errorf("duplicate of an existing song has song ID %d, want 1", results[2].SongID)
+	}
+	if results[7].SongID == 0 {
+		t.Errorf("song added by a concurrent request has no song ID in result %+v", results[7])
+	}
+	if len(repo.songs) != 3 {
+		t.Errorf("library has %d songs, want 3", len(repo.songs))
+	}
+}
+
+func TestAddSongsNormalizesNames(t *testing.T) {
+	tests := []struct {
+		name      string
+		batch     []models.AddSongRequest
+		want      []string
+		wantSongs int
+	}{
+		{
+			name:      "html entity matches stored song",
+			batch:     []models.AddSongRequest{{Group: "Caf&eacute; Tacvba", Song: "Eres"}},
+			want:      []string{models.BulkStatusDuplicate},
+			wantSongs: 1,
+		},
+		{
+			name:      "decomposed name matches stored song",
+			batch:     []models.AddSongRequest{{Group: "Cafe\u0301 Tacvba", Song: "Eres"}},
+			want:      []string{models.BulkStatusDuplicate},
+			wantSongs: 1,
+		},
+		{
+			name: "spellings repeated in the batch",
+			batch: []models.AddSongRequest{
+				{Group: "Caf\u00e9 Tacvba", Song: "Las Flores"},
+				{Group: "Cafe\u0301 Tacvba", Song: "Las Flores"},
+				{Group: "Caf&eacute; Tacvba ", Song: "Las Flores"},
+			},
+			want:      []string{models.BulkStatusCreated, models.BulkStatusDuplicate, models.BulkStatusDuplicate},
+			wantSongs: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memorySongs{}
+			repo.Add(context.Background(), &models.Song{GroupName: "Caf\u00e9 Tacvba", SongName: "Eres"})
+			svc, _ := newTestBulkService(t, &fakeAddSongs{repo: repo}, 1, 10, 100)
+
+			results := collectResults(t, svc, context.Background(), tt.batch)
+			for i, result := range results {
+				if result.Status != tt.want[i] {
+					t.Errorf("result %d = %+v, want status %q", i, result, tt.want[i])
+				}
+			}
+			if len(repo.songs) != tt.wantSongs {
+				t.Errorf("library has %d songs, want %d", len(repo.songs), tt.wantSongs)
+			}
+		})
+	}
+}
+
+func TestAddSongsBatchLimits(t *testing.T) {
+	svc, _ := newTestBulkService(t, &fakeAddSongs{repo: &memorySongs{}}, 2, 2, 100)
+	tests := []struct {
+		name  string
+		batch []models.AddSongRequest
+		want  error
+	}{
+		{"empty", nil, ErrBulkEmpty},
+		{"too large", make([]models.AddSongRequest, 3), ErrBulkTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.AddSongs(context.Background(), tt.batch, func(models.BulkAddResult) {
+				t.Error("onResult called for a rejected batch")
+			})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("AddSongs() error = %v, want %v", err, tt.want)
+			}
+			if _, err := svc.StartAddSongsJob(context.Background(), tt.batch); !errors.Is(err, tt.want) {
+				t.Errorf("StartAddSongsJob() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddSongsLimitsConcurrency(t *testing.T) {
+	songs := &fakeAddSongs{repo: &memorySongs{}, delay: 10 * time.Millisecond}
+	svc, _ := newTestBulkService(t, songs, 3, 20, 100)
+
+	batch := make([]models.AddSongRequest, 12)
+	for i := range batch {
+		batch[i] = models.AddSongRequest{Group: "Muse", Song: fmt.Sprintf("Song %d", i)}
+	}
+	results := collectResults(t, svc, context.Background(), batch)
+
+	if len(results) != len(batch) {
+		t.Fatalf("AddSongs() returned %d results, want %d", len(results), len(batch))
+	}
+	if got := songs.maxSeen.Load(); got > 3 || got < 2 {
+		t.Errorf("at most %d songs were added at once, want 2 to 3", got)
+	}
+}
+
+// Ожидание завершения задания
+func waitForJob(t *testing.T, svc *bulkSongService, ctx context.Context, id string) *models.BulkJob {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := svc.GetJob(ctx, id)
+		if err != nil {
+			t.Fatalf("GetJob() error = %v", err)
+		}
+		if job.Status != models.JobStatusRunning {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s is still running", id)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestBulkJobCompletes(t *testing.T) {
+	songs := &fakeAddSongs{repo: &memorySongs{}, delay: 5 * time.Millisecond}
+	svc, _ := newTestBulkService(t, songs, 2, 10, 100)
+	ctx := tenant.WithTenant(context.Background(), &models.Tenant{ID: "radio-one"})
+
+	batch := []models.AddSongRequest{
+		{Group: "Muse", Song: "Starlight"},
+		{Group: "Muse", Song: "Offline"},
+		{Group: "Muse", Song: "Starlight"},
+	}
+	started, err := svc.StartAddSongsJob(ctx, batch)
+	if err != nil {
+		t.Fatalf("StartAddSongsJob() error = %v", err)
+	}
+	if started.Status != models.JobStatusRunning || started.Total != len(batch) || started.FinishedAt != nil {
+		t.Errorf("started job = %+v, want running job of %d songs", started, len(batch))
+	}
+
+	job := waitForJob(t, svc, ctx, started.ID)
+	if job.Status != models.JobStatusCompleted || job.FinishedAt == nil {
+		t.Errorf("finished job status = %q, finishedAt = %v; want completed", job.Status, job.FinishedAt)
+	}
+	if job.Processed != len(batch) || len(job.Results) != len(batch) {
+		t.Errorf("job processed %d songs with %d results, want %d", job.Processed, len(job.Results), len(batch))
+	}
+	want := models.BulkSummary{models.BulkStatusCreated: 1, models.BulkStatusEnrichmentFailed: 1, models.BulkStatusDuplicate: 1}
+	if fmt.Sprint(job.Summary) != fmt.Sprint(want) {
+		t.Errorf("job summary = %v, want %v", job.Summary, want)
+	}
+
+	other := tenant.WithTenant(context.Background(), &models.Tenant{ID: "radio-two"})
+	if _, err := svc.GetJob(other, started.ID); !errors.Is(err, ErrBulkJobNotFound) {
+		t.Errorf("GetJob() of another tenant error = %v, want %v", err, ErrBulkJobNotFound)
+	}
+}
+
+func TestBulkJobCanceledOnShutdown(t *testing.T) {
+	songs := &fakeAddSongs{repo: &memorySongs{}, block: true}
+	svc, group := newTestBulkService(t, songs, 2, 10, 100)
+	ctx := tenant.WithTenant(context.Background(), &models.Tenant{ID: "radio-one"})
+
+	started, err := svc.StartAddSongsJob(ctx, []models.AddSongRequest{{Group: "Muse", Song: "Starlight"}})
+	if err != nil {
+		t.Fatalf("StartAddSongsJob() error = %v", err)
+	}
+	if err := group.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	job := waitForJob(t, svc, ctx, started.ID)
+	if job.Status != models.JobStatusCanceled || job.FinishedAt == nil {
+		t.Errorf("job status = %q, finishedAt = %v; want canceled", job.Status, job.FinishedAt)
+	}
+}
+
+// Библиотека из трех песен Muse и одной песни Queen
+func newBulkChangeLibrary() *memorySongs {
+	repo := &memorySongs{}
+	for _, song := range []models.Song{
+		{GroupName: "Muse", SongName: "Uprising"},
+		{GroupName: "Muse", SongName: "Starlight"},
+		{GroupName: "Muse", SongName: "Madness"},
+		{GroupName: "Queen", SongName: "Bicycle Race"},
+	} {
+		repo.Add(context.Background(), &song)
+	}
+	return repo
+}
+
+func TestBulkChangeRequestValidation(t *testing.T) {
+	repo := newBulkChangeLibrary()
+	svc, _ := newTestBulkService(t, &fakeAddSongs{repo: repo}, 1, 10, 2)
+	rename := models.SongFieldsUpdate{GroupName: "MUSE"}.Patch()
+
+	tests := []struct {
+		name string
+		run  func() (*models.BulkChangeResult, error)
+		want error
+	}{
+		{"update without match", func() (*models.BulkChangeResult, error) {
+			return svc.UpdateSongs(context.Background(), models.BulkUpdateRequest{Changes: rename})
+		}, ErrInvalidMatch},
+		{"update with ids and filter", func() (*models.BulkChangeResult, error) {
+			match := models.SongMatch{IDs: []uint{1}, Filter: map[string]interface{}{"group": "Muse"}}
+			return svc.UpdateSongs(context.Background(), models.BulkUpdateRequest{SongMatch: match, Changes: rename})
+		}, ErrInvalidMatch},
+		{"update without changes", func() (*models.BulkChangeResult, error) {
+			return svc.UpdateSongs(context.Background(), models.BulkUpdateRequest{SongMatch: models.SongMatch{IDs: []uint{1}}})
+		}, ErrEmptyParameters},
+		{"update clearing group", func() (*models.BulkChangeResult, error) {
+			changes := models.SongPatch{GroupName: models.ClearString()}
+			return svc.UpdateSongs(context.Background(), models.BulkUpdateRequest{SongMatch: models.SongMatch{IDs: []uint{1}}, Changes: changes})
+		}, ErrSongValidation},
+		{"delete without match", func() (*models.BulkChangeResult, error) {
+			return svc.DeleteSongs(context.Background(), models.BulkDeleteRequest{})
+		}, ErrInvalidMatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.run(); !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+			if len(repo.songs) != 4 || repo.songs[0].GroupName != "Muse" || repo.songs[0].Version != 1 {
+				t.Errorf("library changed by a rejected request: %+v", repo.songs[0])
+			}
+		})
+	}
+}
+
+func TestBulkChangeAffectedLimit(t *testing.T) {
+	muse := models.SongMatch{Filter: map[string]interface{}{"group": "Muse"}}
+	twoSongs := models.SongMatch{IDs: []uint{1, 4}}
+	rename := models.SongFieldsUpdate{GroupName: "MUSE"}.Patch()
+
+	tests := []struct {
+		name         string
+		update       bool
+		match        models.SongMatch
+		dryRun       bool
+		force        bool
+		wantErr      error
+		wantAffected int64
+		wantSongs    int
+		wantRenamed  int
+	}{
+		{name: "update dry run within limit", update: true, match: twoSongs, dryRun: true, wantAffected: 2, wantSongs: 4},
+		{name: "update dry run over limit", update: true, match: muse, dryRun: true, wantErr: ErrTooManySongs, wantSongs: 4},
+		{name: "update dry run forced", update: true, match: muse, dryRun: true, force: true, wantAffected: 3, wantSongs: 4},
+		{name: "update within limit", update: true, match: twoSongs, wantAffected: 2, wantSongs: 4, wantRenamed: 2},
+		{name: "update over limit", update: true, match: muse, wantErr: ErrTooManySongs, wantSongs: 4},
+		{name: "update forced", update: true, match: muse, force: true, wantAffected: 3, wantSongs: 4, wantRenamed: 3},
+		{name: "delete dry run over limit", match: muse, dryRun: true, wantErr: ErrTooManySongs, wantSongs: 4},
+		{name: "delete dry run within limit", match: twoSongs, dryRun: true, wantAffected: 2, wantSongs: 4},
+		{name: "delete within limit", match: twoSongs, wantAffected: 2, wantSongs: 2},
+		{name: "delete over limit", match: muse, wantErr: ErrTooManySongs, wantSongs: 4},
+		{name: "delete forced", match: muse, force: true, wantAffected: 3, wantSongs: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newBulkChangeLibrary()
+			svc, _ := newTestBulkService(t, &fakeAddSongs{repo: repo}, 1, 10, 2)
+
+			var (
+				result *models.BulkChangeResult
+				err    error
+			)
+			if tt.update {
+				result, err = svc.UpdateSongs(context.Background(), models.BulkUpdateRequest{SongMatch: tt.match, Changes: rename, DryRun: tt.dryRun, Force: tt.force})
+			} else {
+				result, err = svc.DeleteSongs(context.Background(), models.BulkDeleteRequest{SongMatch: tt.match, DryRun: tt.dryRun, Force: tt.force})
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (result.Affected != tt.wantAffected || result.DryRun != tt.dryRun) {
+				t.Errorf("result = %+v, want %d affected, dry run %t", result, tt.wantAffected, tt.dryRun)
+			}
+			renamed := 0
+			for _, song := range repo.songs {
+				if song.GroupName == "MUSE" {
+					renamed++
+				}
+			}
+			if len(repo.songs) != tt.wantSongs || renamed != tt.wantRenamed {
+				t.Errorf("library has %d songs, %d renamed; want %d songs, %d renamed", len(repo.songs), renamed, tt.wantSongs, tt.wantRenamed)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/internal/identity"
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/repository"
+	"github.com/ananikitina/song_lib/internal/service"
+)
+
+var (
+	ErrChangeRequestNotFound = errors.New("change request not found")
+	ErrChangeRequestReviewed = errors.New("change request is already reviewed")
+	ErrUnknownChangeStatus   = errors.New("unknown change request status")
+	ErrChangeRequestStale    = errors.New("song was changed after the change request was proposed")
+)
+
+type changeRequestService struct {
+	repo        repository.ChangeRequestRepository
+	songService service.SongService
+	logger      *logrus.Logger
+}
+
+func NewChangeRequestService(repo repository.ChangeRequestRepository, songService service.SongService, logger *logrus.Logger) service.ChangeRequestService {
+	return &changeRequestService{
+		repo:        repo,
+		songService: songService,
+		logger:      logger,
+	}
+}
+
+// Предложение изменений песни; применяются после одобрения редактором к той же версии песни.
+// Ненулевая version отклоняет предложение к устаревшей версии
+func (s *changeRequestService) ProposeChange(ctx context.Context, songId uint, changes models.SongChanges, version int) (*models.ChangeRequest, error) {
+	if changes.Empty() {
+		return nil, ErrEmptyParameters
+	}
+	// Проверка существования песни
+	song, err := s.songService.GetSongById(ctx, songId)
+	if err != nil {
+		s.logger.WithContext(ctx).Warnf("ProposeChange: failed to get song with ID %d: %v", songId, err)
+		return nil, err
+	}
+	if version != 0 && song.Version != version {
+		s.logger.WithContext(ctx).Warnf("ProposeChange: song with ID %d has version %d, expected %d", songId, song.Version, version)
+		return nil, ErrVersionMismatch
+	}
+
+	request := &models.ChangeRequest{
+		SongID:      songId,
+		SongVersion: song.Version,
+		Changes:     changes,
+		Status:      models.ChangeStatusPending,
+		ProposedBy:  identity.Subject(ctx),
+	}
+	if err := s.repo.Add(ctx, request); err != nil {
+		s.logger.WithContext(ctx).Errorf("ProposeChange: failed to save change request: %v", err)
+		return nil, err
+	}
+
+	s.logger.WithContext(ctx).Infof("ProposeChange: change request %d created for song %d", request.ID, songId)
+	return request, nil
+}
+
+// Получение запросов с заданным статусом
+func (s *changeRequestService) ListChangeRequests(ctx context.Context, status string, page int, pageSize int) ([]models.ChangeRequest, error) {
+	switch status {
+	case "":
+		status = models.ChangeStatusPending
+	case models.ChangeStatusPending, models.ChangeStatusApproved, models.ChangeStatusRejected:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownChangeStatus, status)
+	}
+
+	requests, err := s.repo.GetByStatus(ctx, status, page, pageSize)
+	if err != nil {
+		s.logger.WithContext(ctx).Errorf("ListChangeRequests: failed to fetch change requests: %v", err)
+		return nil, err
+	}
+	return requests, nil
+}
+
+func (s *changeRequestService) getPending(ctx context.Context, id uint) (*models.ChangeRequest, error) {
+	if id == 0 {
+		return nil, ErrInvalidID
+	}
+	request, err := s.repo.GetById(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrChangeRequestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if request.Status != models.ChangeStatusPending {
+		return nil, ErrChangeRequestReviewed
+	}
+	return request, nil
+}
+
+// Перевод запроса из pending в итоговый статус; конкурентное рассмотрение получает ErrChangeRequestReviewed
+func (s *changeRequestService) review(ctx context.Context, request *models.ChangeRequest, status, comment string) error {
+	now := time.Now()
+	request.Status = status
+	request.ReviewedBy = identity.Subject(ctx)
+	request.ReviewComment = comment
+	request.ReviewedAt = &now
+	request.UpdatedAt = now
+
+	err := s.repo.UpdateFromStatus(ctx, request, models.ChangeStatusPending)
+	if errors.Is(err, repository.ErrConflict) {
+		return ErrChangeRequestReviewed
+	}
+	return err
+}
+
+// Одобрение запроса и применение изменений к песне
+func (s *changeRequestService) ApproveChangeRequest(ctx context.Context, id uint, comment string) (*models.ChangeRequest, error) {
+	request, err := s.getPending(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Warnf("ApproveChangeRequest: failed to get change request: %v", err)
+		return nil, err
+	}
+
+	// Сначала фиксируем решение, чтобы изменения не применились дважды
+	if err := s.review(ctx, request, models.ChangeStatusApproved, comment); err != nil {
+		s.logger.WithContext(ctx).Warnf("ApproveChangeRequest: failed to approve change request %d: %v", id, err)
+		return nil, err
+	}
+
+	// Изменения применяются только к версии песни, для которой они предложены
+	if _, err := s.songService.UpdateSong(ctx, request.SongID, request.Changes.Patch(), request.SongVersion); err != nil {
+		s.logger.WithContext(ctx).Errorf("ApproveChangeRequest: failed to apply change request %d: %v", id, err)
+		s.revert(ctx, request)
+		if errors.Is(err, ErrVersionMismatch) {
+			return nil, ErrChangeRequestStale
+		}
+		return nil, err
+	}
+
+	s.logger.WithContext(ctx).Infof("ApproveChangeRequest: change request %d applied to song %d", id, request.SongID)
+	return request, nil
+}
+
+// Возврат запроса в очередь, если изменения не удалось применить
+func (s *changeRequestService) revert(ctx context.Context, request *models.ChangeRequest) {
+	request.Status = models.ChangeStatusPending
+	request.ReviewedBy = ""
+	request.ReviewComment = ""
+	request.ReviewedAt = nil
+	request.UpdatedAt = time.Now()
+	if err := s.repo.UpdateFromStatus(ctx, request, models.ChangeStatusApproved); err != nil {
+		s.logger.WithContext(ctx).Errorf("revert: failed to return change request %d to pending: %v", request.ID, err)
+	}
+}
+
+// Отклонение запроса
+func (s *changeRequestService) RejectChangeRequest(ctx context.Context, id uint, comment string) (*models.ChangeRequest, error) {
+	request, err := s.getPending(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Warnf("RejectChangeRequest: failed to get change request: %v", err)
+		return nil, err
+	}
+
+	if err := s.review(ctx, request, models.ChangeStatusRejected, comment); err != nil {
+		s.logger.WithContext(ctx).Warnf("RejectChangeRequest: failed to reject change request %d: %v", id, err)
+		return nil, err
+	}
+
+	s.logger.WithContext(ctx).Infof("RejectChangeRequest: change request %d rejected", id)
+	return request, nil
+}
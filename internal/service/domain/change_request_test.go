@@ -0,0 +1,152 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/repository"
+	"github.com/ananikitina/song_lib/internal/service"
+)
+
+// Хранилище запросов на изменение в памяти
+type memoryChangeRequests struct {
+	repository.ChangeRequestRepository
+	requests map[uint]*models.ChangeRequest
+}
+
+func (r *memoryChangeRequests) Add(_ context.Context, request *models.ChangeRequest) error {
+	request.ID = uint(len(r.requests) + 1)
+	stored := *request
+	r.requests[request.ID] = &stored
+	return nil
+}
+
+func (r *memoryChangeRequests) GetById(_ context.Context, id uint) (*models.ChangeRequest, error) {
+	request, ok := r.requests[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	stored := *request
+	return &stored, nil
+}
+
+func (r *memoryChangeRequests) UpdateFromStatus(_ context.Context, request *models.ChangeRequest, fromStatus string) error {
+	if r.requests[request.ID].Status != fromStatus {
+		return repository.ErrConflict
+	}
+	stored := *request
+	r.requests[request.ID] = &stored
+	return nil
+}
+
+// Песни с версиями; UpdateSong применяет изменения только к ожидаемой версии
+type versionedSongs struct {
+	service.SongService
+	song *models.Song
+}
+
+func (s *versionedSongs) GetSongById(_ context.Context, id uint) (*models.Song, error) {
+	if id != s.song.ID {
+		return nil, ErrSongNotFound
+	}
+	song := *s.song
+	return &song, nil
+}
+
+func (s *versionedSongs) UpdateSong(_ context.Context, id uint, patch models.SongPatch, version int) (*models.Song, error) {
+	if id != s.song.ID {
+		return nil, ErrSongNotFound
+	}
+	if version != 0 && version != s.song.Version {
+		return nil, ErrVersionMismatch
+	}
+	if err := applySongPatch(s.song, patch); err != nil {
+		return nil, err
+	}
+	s.song.Version++
+	song := *s.song
+	return &song, nil
+}
+
+func TestApproveChangeRequest(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	tests := []struct {
+		name string
+		// Правка песни между предложением и одобрением
+		editedMeanwhile bool
+		wantErr         error
+		wantStatus      string
+		wantSongName    string
+	}{
+		{
+			name:         "song unchanged",
+			wantStatus:   models.ChangeStatusApproved,
+			wantSongName: "Proposed",
+		},
+		{
+			name:            "song edited after proposal",
+			editedMeanwhile: true,
+			wantErr:         ErrChangeRequestStale,
+			wantStatus:      models.ChangeStatusPending,
+			wantSongName:    "Edited",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			songs := &versionedSongs{song: &models.Song{ID: 1, GroupName: "Muse", SongName: "Original", Version: 3}}
+			requests := &memoryChangeRequests{requests: map[uint]*models.ChangeRequest{}}
+			svc := NewChangeRequestService(requests, songs, logger)
+
+			request, err := svc.ProposeChange(ctx, 1, models.SongChanges{SongName: "Proposed"}, 0)
+			if err != nil {
+				t.Fatalf("ProposeChange() error = %v", err)
+			}
+			if request.SongVersion != 3 {
+				t.Fatalf("ProposeChange() SongVersion = %d, want 3", request.SongVersion)
+			}
+
+			if tt.editedMeanwhile {
+				if _, err := songs.UpdateSong(ctx, 1, models.SongFieldsUpdate{SongName: "Edited"}.Patch(), 0); err != nil {
+					t.Fatalf("UpdateSong() error = %v", err)
+				}
+			}
+
+			_, err = svc.ApproveChangeRequest(ctx, request.ID, "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ApproveChangeRequest() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := requests.requests[request.ID].Status; got != tt.wantStatus {
+				t.Errorf("change request status = %q, want %q", got, tt.wantStatus)
+			}
+			if songs.song.SongName != tt.wantSongName {
+				t.Errorf("song name = %q, want %q", songs.song.SongName, tt.wantSongName)
+			}
+		})
+	}
+}
+
+func TestProposeChangeRejectsStaleVersion(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	songs := &versionedSongs{song: &models.Song{ID: 1, GroupName: "Muse", SongName: "Original", Version: 3}}
+	requests := &memoryChangeRequests{requests: map[uint]*models.ChangeRequest{}}
+	svc := NewChangeRequestService(requests, songs, logger)
+
+	_, err := svc.ProposeChange(context.Background(), 1, models.SongChanges{SongName: "Proposed"}, 2)
+	if !errors.Is(err, ErrVersionMismatch) {
+		t.Fatalf("ProposeChange() error = %v, want %v", err, ErrVersionMismatch)
+	}
+	if len(requests.requests) != 0 {
+		t.Errorf("ProposeChange() stored %d change requests, want 0", len(requests.requests))
+	}
+}
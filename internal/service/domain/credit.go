@@ -0,0 +1,168 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/repository"
+	"github.com/ananikitina/song_lib/internal/service"
+)
+
+var ErrInvalidCredit = errors.New("invalid credit")
+
+// Наибольшая длина имени участника
+const maxCreditNameLength = 255
+
+var (
+	// "Artist A feat. Artist B" в названии группы
+	featGroupPattern = regexp.MustCompile(`(?i)^(.+?)\s+(?:featuring\s+|feat\.\s*|feat\s+|ft\.\s*|ft\s+)(.+)$`)
+	// "Song (feat. Artist B)" в названии песни
+	featSongPattern = regexp.MustCompile(`(?i)[(\[]\s*(?:featuring\s+|feat\.\s*|feat\s+|ft\.\s*|ft\s+)([^)\]]+)[)\]]`)
+	// Разделители приглашенных артистов: "B, C & D x E"
+	featSeparator = regexp.MustCompile(`(?i)\s*(?:,|&)\s*|\s+x\s+`)
+)
+
+type creditService struct {
+	repo     repository.CreditRepository
+	songRepo repository.SongRepository
+	logger   *logrus.Logger
+}
+
+func NewCreditService(repo repository.CreditRepository, songRepo repository.SongRepository, logger *logrus.Logger) service.CreditService {
+	return &creditService{
+		repo:     repo,
+		songRepo: songRepo,
+		logger:   logger,
+	}
+}
+
+// Участники песни из названий группы и песни: исполнитель и приглашенные артисты после "feat."
+func parseCredits(groupName, songName string) []models.SongCredit {
+	artist, featuring := groupName, ""
+	if m := featGroupPattern.FindStringSubmatch(groupName); m != nil {
+		artist, featuring = m[1], m[2]
+	}
+	for _, m := range featSongPattern.FindAllStringSubmatch(songName, -1) {
+		featuring += "," + m[1]
+	}
+
+	credits := []models.SongCredit{{Name: artist, Role: models.CreditRoleArtist}}
+	for _, name := range featSeparator.Split(featuring, -1) {
+		credits = append(credits, models.SongCredit{Name: name, Role: models.CreditRoleFeaturing})
+	}
+	// Пустые имена от лишних разделителей отбрасываются, ошибка невозможна
+	credits, _ = normalizeCredits(credits, true)
+	return credits
+}
+
+// Участники песни после смены группы или названия: участники, выведенные из прежних названий
+// (исполнитель и приглашенные артисты), заменяются выведенными из новых, остальные сохраняются
+func rederiveCredits(credits []models.SongCredit, oldGroup, oldSong, newGroup, newSong string) []models.SongCredit {
+	derived := make(map[string]bool)
+	for _, credit := range parseCredits(oldGroup, oldSong) {
+		derived[creditKey(credit)] = true
+	}
+
+	result := parseCredits(newGroup, newSong)
+	for _, credit := range credits {
+		if !derived[creditKey(credit)] {
+			result = append(result, credit)
+		}
+	}
+	// Сохраненные участники уже прошли проверку, ошибка невозможна
+	result, _ = normalizeCredits(result, true)
+	return result
+}
+
+// Ключ участника для поиска повторов: роль и имя без учета регистра
+func creditKey(credit models.SongCredit) string {
+	name := strings.Join(strings.Fields(normalizeString(credit.Name)), " ")
+	return strings.ToLower(strings.TrimSpace(credit.Role)) + "\x00" + strings.ToLower(name)
+}
+
+// Нормализация участников: имена без лишних пробелов, без повторов в роли, роли по порядку
+// и позиции по порядку списка в каждой роли. С skipEmpty пустые имена пропускаются, иначе это ошибка.
+func normalizeCredits(credits []models.SongCredit, skipEmpty bool) ([]models.SongCredit, error) {
+	result := make([]models.SongCredit, 0, len(credits))
+	seen := make(map[string]bool, len(credits))
+	positions := make(map[string]int, len(models.CreditRoles))
+	for _, credit := range credits {
+		name := strings.Join(strings.Fields(normalizeString(credit.Name)), " ")
+		role := strings.ToLower(strings.TrimSpace(credit.Role))
+		switch {
+		case name == "" && skipEmpty:
+			continue
+		case name == "":
+			return nil, fmt.Errorf("%w: name is required", ErrInvalidCredit)
+		case utf8.RuneCountInString(name) > maxCreditNameLength:
+			return nil, fmt.Errorf("%w: name %q is longer than %d characters", ErrInvalidCredit, name, maxCreditNameLength)
+		case !slices.Contains(models.CreditRoles, role):
+			return nil, fmt.Errorf("%w: role must be one of %s, got %q", ErrInvalidCredit, strings.Join(models.CreditRoles, ", "), credit.Role)
+		}
+
+		key := creditKey(models.SongCredit{Name: name, Role: role})
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		positions[role]++
+		result = append(result, models.SongCredit{Name: name, Role: role, Position: positions[role]})
+	}
+	sortCredits(result)
+	return result, nil
+}
+
+// Участники по порядку ролей в models.CreditRoles и позициям
+func sortCredits(credits []models.SongCredit) {
+	sort.SliceStable(credits, func(i, j int) bool {
+		ri, rj := slices.Index(models.CreditRoles, credits[i].Role), slices.Index(models.CreditRoles, credits[j].Role)
+		if ri != rj {
+			return ri < rj
+		}
+		return credits[i].Position < credits[j].Position
+	})
+}
+
+// Получение участников песни
+func (s *creditService) GetSongCredits(ctx context.Context, songId uint) ([]models.SongCredit, error) {
+	if err := checkSongExists(ctx, s.songRepo, songId); err != nil {
+		s.logger.WithContext(ctx).Warnf("GetSongCredits: %v", err)
+		return nil, err
+	}
+
+	credits, err := s.repo.SongCredits(ctx, songId)
+	if err != nil {
+		return nil, err
+	}
+	sortCredits(credits)
+	return credits, nil
+}
+
+// Замена участников песни; пустой список удаляет всех участников
+func (s *creditService) SetSongCredits(ctx context.Context, songId uint, credits []models.SongCredit) ([]models.SongCredit, error) {
+	credits, err := normalizeCredits(credits, false)
+	if err == nil {
+		err = checkSongExists(ctx, s.songRepo, songId)
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).Warnf("SetSongCredits: %v", err)
+		return nil, err
+	}
+
+	if err := s.repo.SetSongCredits(ctx, songId, credits); err != nil {
+		s.logger.WithContext(ctx).Errorf("SetSongCredits: failed to set credits of song with ID %d: %v", songId, err)
+		return nil, songNotFound(err)
+	}
+
+	s.logger.WithContext(ctx).Infof("SetSongCredits: song with ID %d now has %d credits", songId, len(credits))
+	return credits, nil
+}
@@ -0,0 +1,175 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+func TestRederiveCredits(t *testing.T) {
+	credits := []models.SongCredit{
+		{Name: "Eminem", Role: models.CreditRoleArtist, Position: 1},
+		{Name: "Rihanna", Role: models.CreditRoleFeaturing, Position: 1},
+		{Name: "Dr. Dre", Role: models.CreditRoleFeaturing, Position: 2},
+		{Name: "Alex da Kid", Role: models.CreditRoleComposer, Position: 1},
+	}
+
+	tests := []struct {
+		name              string
+		newGroup, newSong string
+		want              []models.SongCredit
+	}{
+		{
+			name:     "featuring removed",
+			newGroup: "Eminem",
+			newSong:  "Love the Way You Lie",
+			want: []models.SongCredit{
+				{Name: "Eminem", Role: models.CreditRoleArtist, Position: 1},
+				{Name: "Dr. Dre", Role: models.CreditRoleFeaturing, Position: 1},
+				{Name: "Alex da Kid", Role: models.CreditRoleComposer, Position: 1},
+			},
+		},
+		{
+			name:     "artist and featuring changed",
+			newGroup: "Rihanna feat. Eminem",
+			newSong:  "Love the Way You Lie (Part II)",
+			want: []models.SongCredit{
+				{Name: "Rihanna", Role: models.CreditRoleArtist, Position: 1},
+				{Name: "Eminem", Role: models.CreditRoleFeaturing, Position: 1},
+				{Name: "Dr. Dre", Role: models.CreditRoleFeaturing, Position: 2},
+				{Name: "Alex da Kid", Role: models.CreditRoleComposer, Position: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rederiveCredits(credits, "Eminem feat. Rihanna", "Love the Way You Lie", tt.newGroup, tt.newSong)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("rederiveCredits() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCredits(t *testing.T) {
+	artist := func(name string) models.SongCredit {
+		return models.SongCredit{Name: name, Role: models.CreditRoleArtist, Position: 1}
+	}
+	featuring := func(position int, name string) models.SongCredit {
+		return models.SongCredit{Name: name, Role: models.CreditRoleFeaturing, Position: position}
+	}
+
+	tests := []struct {
+		name  string
+		group string
+		song  string
+		want  []models.SongCredit
+	}{
+		{
+			name:  "no featuring",
+			group: "Muse",
+			song:  "Uprising",
+			want:  []models.SongCredit{artist("Muse")},
+		},
+		{
+			name:  "feat. in song title",
+			group: "Daft Punk",
+			song:  "Get Lucky (feat. Pharrell Williams)",
+			want:  []models.SongCredit{artist("Daft Punk"), featuring(1, "Pharrell Williams")},
+		},
+		{
+			name:  "brackets in song title",
+			group: "Calvin Harris",
+			song:  "Feel So Close [ft. Example] (Radio Edit)",
+			want:  []models.SongCredit{artist("Calvin Harris"), featuring(1, "Example")},
+		},
+		{
+			name:  "ft. in group",
+			group: "Eminem ft. Rihanna",
+			song:  "Love the Way You Lie",
+			want:  []models.SongCredit{artist("Eminem"), featuring(1, "Rihanna")},
+		},
+		{
+			name:  "featuring in group",
+			group: "Gotye featuring Kimbra",
+			song:  "Somebody That I Used to Know",
+			want:  []models.SongCredit{artist("Gotye"), featuring(1, "Kimbra")},
+		},
+		{
+			name:  "group and song title",
+			group: "Daft Punk feat. Pharrell Williams",
+			song:  "Get Lucky (feat. Nile Rodgers)",
+			want:  []models.SongCredit{artist("Daft Punk"), featuring(1, "Pharrell Williams"), featuring(2, "Nile Rodgers")},
+		},
+		{
+			name:  "comma separator",
+			group: "Artist A feat. B, C",
+			song:  "Song",
+			want:  []models.SongCredit{artist("Artist A"), featuring(1, "B"), featuring(2, "C")},
+		},
+		{
+			name:  "ampersand separator",
+			group: "Artist A feat. B & C",
+			song:  "Song",
+			want:  []models.SongCredit{artist("Artist A"), featuring(1, "B"), featuring(2, "C")},
+		},
+		{
+			name:  "x separator",
+			group: "Artist A feat. B x C X D",
+			song:  "Song",
+			want:  []models.SongCredit{artist("Artist A"), featuring(1, "B"), featuring(2, "C"), featuring(3, "D")},
+		},
+		{
+			name:  "x inside a name is not a separator",
+			group: "Artist A feat. Lil Nas X, Xzibit",
+			song:  "Song",
+			want:  []models.SongCredit{artist("Artist A"), featuring(1, "Lil Nas X"), featuring(2, "Xzibit")},
+		},
+		{
+			name:  "mixed separators and empty names",
+			group: "Artist A ft. B,, C &  & D",
+			song:  "Song",
+			want:  []models.SongCredit{artist("Artist A"), featuring(1, "B"), featuring(2, "C"), featuring(3, "D")},
+		},
+		{
+			name:  "keyword case",
+			group: "Artist A FEAT. B",
+			song:  "Song (Ft. C) (FEATURING D)",
+			want:  []models.SongCredit{artist("Artist A"), featuring(1, "B"), featuring(2, "C"), featuring(3, "D")},
+		},
+		{
+			name:  "keyword without dot",
+			group: "Artist A feat B",
+			song:  "Song (ft C)",
+			want:  []models.SongCredit{artist("Artist A"), featuring(1, "B"), featuring(2, "C")},
+		},
+		{
+			name:  "whitespace",
+			group: "  Artist   A   feat.   B    C ",
+			song:  "Song ( feat.  D  )",
+			want:  []models.SongCredit{artist("Artist A"), featuring(1, "B C"), featuring(2, "D")},
+		},
+		{
+			name:  "repeated names in any case",
+			group: "Artist A feat. B",
+			song:  "Song (feat. b & C)",
+			want:  []models.SongCredit{artist("Artist A"), featuring(1, "B"), featuring(2, "C")},
+		},
+		{
+			name:  "feat without a name is not a separator",
+			group: "Defeat the Feat",
+			song:  "Aftermath",
+			want:  []models.SongCredit{artist("Defeat the Feat")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseCredits(tt.group, tt.song); !slices.Equal(got, tt.want) {
+				t.Errorf("parseCredits(%q, %q) = %+v, want %+v", tt.group, tt.song, got, tt.want)
+			}
+		})
+	}
+}
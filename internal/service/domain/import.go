@@ -0,0 +1,179 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/config"
+	"github.com/ananikitina/song_lib/internal/identity"
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/repository"
+	"github.com/ananikitina/song_lib/internal/service"
+)
+
+type importService struct {
+	songService service.SongService
+	repo        repository.SongRepository
+	logger      *logrus.Logger
+	rules       ValidationRules
+	maxRows     int
+}
+
+func NewImportService(songService service.SongService, repo repository.SongRepository, logger *logrus.Logger, cfg *config.Config) service.ImportService {
+	return &importService{
+		songService: songService,
+		repo:        repo,
+		logger:      logger,
+		rules: ValidationRules{
+			ReleaseDate: cfg.ValidateReleaseDate,
+			Text:        cfg.ValidateText,
+			Link:        cfg.ValidateLink,
+		},
+		maxRows: cfg.BulkMaxItems,
+	}
+}
+
+// Недостающие поля данных песни заполняются из other
+func fillSongDetail(detail *models.SongDetail, other models.SongDetail) {
+	if detail.ReleaseDate == "" {
+		detail.ReleaseDate = other.ReleaseDate
+	}
+	if detail.Text == "" {
+		detail.Text = other.Text
+	}
+	if detail.Link == "" {
+		detail.Link = other.Link
+	}
+}
+
+// Импорт одной строки: новая песня добавляется, у найденной по группе и названию
+// обновляются поля, заданные в строке; пустые поля строки не очищают данные песни.
+// Если песню добавил параллельный запрос после поиска, строка один раз применяется к ней (retry).
+func (s *importService) importOne(ctx context.Context, row models.ImportRow, opts models.ImportOptions, retry bool) models.ImportResult {
+	result := models.ImportResult{Line: row.Line, Group: row.GroupName, Song: row.SongName}
+	fail := func(status string, err error) models.ImportResult {
+		result.Status = status
+		result.Error = err.Error()
+		return result
+	}
+
+	if row.Error != "" {
+		return fail(models.ImportStatusRejected, errors.New(row.Error))
+	}
+	result.Group, result.Song = normalizeString(row.GroupName), normalizeString(row.SongName)
+	if result.Group == "" || result.Song == "" {
+		return fail(models.ImportStatusRejected, fmt.Errorf("%w: group and song are required", ErrEmptyParameters))
+	}
+
+	existing, err := s.repo.GetByName(ctx, result.Group, result.Song)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return fail(models.ImportStatusFailed, err)
+	}
+
+	// Поля из одних пробелов считаются пустыми и тоже не очищают данные песни
+	detail := models.SongDetail{
+		ReleaseDate: normalizeString(row.ReleaseDate),
+		Text:        normalizeText(row.Text),
+		Link:        normalizeString(row.Link),
+	}
+	if existing != nil {
+		fillSongDetail(&detail, models.SongDetail{ReleaseDate: existing.ReleaseDate, Text: existing.Text, Link: existing.Link})
+	}
+	if opts.Enrich && (detail.ReleaseDate == "" || detail.Text == "" || detail.Link == "") {
+		info, err := s.songService.GetSongInfo(ctx, result.Group, result.Song)
+		if err != nil {
+			return fail(models.ImportStatusEnrichmentFailed, err)
+		}
+		fillSongDetail(&detail, *info)
+	}
+
+	detail, report := normalizeSongDetail(detail, s.rules)
+	if report.Has(models.ValidationActionReject) {
+		return fail(models.ImportStatusRejected, &ValidationError{Report: report})
+	}
+	var validationReport *models.ValidationReport
+	if !report.Empty() {
+		validationReport = &report
+	}
+
+	if existing != nil {
+		result.SongID = existing.ID
+		if existing.ReleaseDate == detail.ReleaseDate && existing.Text == detail.Text && existing.Link == detail.Link {
+			result.Status = models.ImportStatusUnchanged
+			return result
+		}
+		existing.ReleaseDate = detail.ReleaseDate
+		existing.Text = detail.Text
+		existing.Link = detail.Link
+		existing.NeedsReview = report.Has(models.ValidationActionFlag)
+		existing.ValidationReport = validationReport
+		existing.UpdatedAt = time.Now()
+		existing.UpdatedBy = identity.Subject(ctx)
+		if err := s.repo.Update(ctx, existing); err != nil {
+			return fail(models.ImportStatusFailed, songNotFound(err))
+		}
+		result.Status = models.ImportStatusUpdated
+		return result
+	}
+
+	if err := checkSongQuota(ctx, s.repo); err != nil {
+		return fail(models.ImportStatusFailed, err)
+	}
+	song := &models.Song{
+		GroupName:        result.Group,
+		SongName:         result.Song,
+		ReleaseDate:      detail.ReleaseDate,
+		Text:             detail.Text,
+		Link:             detail.Link,
+		NeedsReview:      report.Has(models.ValidationActionFlag),
+		ValidationReport: validationReport,
+		CreatedBy:        identity.Subject(ctx),
+		UpdatedBy:        identity.Subject(ctx),
+		Credits:          parseCredits(result.Group, result.Song),
+	}
+	if err := s.repo.Add(ctx, song); err != nil {
+		if retry && errors.Is(err, repository.ErrDuplicate) {
+			return s.importOne(ctx, row, opts, false)
+		}
+		return fail(models.ImportStatusFailed, songNotFound(err))
+	}
+	result.Status = models.ImportStatusCreated
+	result.SongID = song.ID
+	return result
+}
+
+// Импорт каталога; строки обрабатываются по порядку, поэтому повтор песни в файле обновляет ее
+func (s *importService) ImportSongs(ctx context.Context, rows []models.ImportRow, opts models.ImportOptions, onResult func(models.ImportResult)) error {
+	if len(rows) == 0 {
+		s.logger.WithContext(ctx).Warn("ImportSongs: no rows to import")
+		return ErrBulkEmpty
+	}
+	if len(rows) > s.maxRows {
+		err := fmt.Errorf("%w: %d rows, at most %d allowed", ErrBulkTooLarge, len(rows), s.maxRows)
+		s.logger.WithContext(ctx).Warnf("ImportSongs: %v", err)
+		return err
+	}
+
+	s.logger.WithContext(ctx).Infof("ImportSongs: importing %d rows, enrich: %t", len(rows), opts.Enrich)
+
+	counts := make(map[string]int)
+	for _, row := range rows {
+		if err := ctx.Err(); err != nil {
+			s.logger.WithContext(ctx).Warnf("ImportSongs: import interrupted: %v", err)
+			return err
+		}
+		result := s.importOne(ctx, row, opts, true)
+		if result.Error != "" {
+			s.logger.WithContext(ctx).Debugf("ImportSongs: line %d %s: %s", result.Line, result.Status, result.Error)
+		}
+		counts[result.Status]++
+		onResult(result)
+	}
+
+	s.logger.WithContext(ctx).Infof("ImportSongs: processed rows: %v", counts)
+	return nil
+}
@@ -0,0 +1,192 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/config"
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/repository"
+)
+
+func TestImportMatchesSongAddedWithSurroundingWhitespace(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(models.SongDetail{
+			ReleaseDate: "16.07.2006",
+			Text:        "Ooh baby, don't you know I suffer?",
+			Link:        "https://www.youtube.com/watch?v=Xsp3_a-PMTw",
+		})
+	}))
+	defer api.Close()
+
+	cfg := &config.Config{
+		ExternalApi:         api.URL,
+		BulkMaxItems:        10,
+		ValidateReleaseDate: models.ValidationActionFlag,
+		ValidateText:        models.ValidationActionFlag,
+		ValidateLink:        models.ValidationActionFlag,
+	}
+	songs := &memorySongs{}
+	songService := NewSongService(songs, nil, logger, cfg, api.Client())
+	importService := NewImportService(songService, songs, logger, cfg)
+	ctx := context.Background()
+
+	song, err := songService.AddSong(ctx, "  Muse ", "Supermassive Black Hole\t")
+	if err != nil {
+		t.Fatalf("AddSong() error = %v", err)
+	}
+	if song.GroupName != "Muse" || song.SongName != "Supermassive Black Hole" {
+		t.Fatalf("AddSong() stored %q - %q, want names without surrounding whitespace", song.GroupName, song.SongName)
+	}
+
+	var results []models.ImportResult
+	rows := []models.ImportRow{{Line: 2, GroupName: "Muse", SongName: "Supermassive Black Hole", Text: "Ooh baby"}}
+	if err := importService.ImportSongs(ctx, rows, models.ImportOptions{}, func(result models.ImportResult) {
+		results = append(results, result)
+	}); err != nil {
+		t.Fatalf("ImportSongs() error = %v", err)
+	}
+
+	if len(results) != 1 || results[0].Status != models.ImportStatusUpdated || results[0].SongID != song.ID {
+		t.Errorf("ImportSongs() results = %+v, want song %d updated", results, song.ID)
+	}
+	if len(songs.songs) != 1 {
+		t.Errorf("library has %d songs after import, want 1", len(songs.songs))
+	}
+}
+
+func TestImportKeepsFieldsMissingFromRow(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	cfg := &config.Config{
+		BulkMaxItems:        10,
+		ValidateReleaseDate: models.ValidationActionReject,
+		ValidateText:        models.ValidationActionReject,
+		ValidateLink:        models.ValidationActionReject,
+	}
+	stored := models.Song{
+		GroupName:   "Muse",
+		SongName:    "Supermassive Black Hole",
+		ReleaseDate: "16.07.2006",
+		Text:        "Ooh baby, don't you know I suffer?",
+		Link:        "https://www.youtube.com/watch?v=Xsp3_a-PMTw",
+	}
+
+	tests := []struct {
+		name       string
+		row        models.ImportRow
+		wantStatus string
+		want       models.SongDetail
+	}{
+		{
+			name:       "empty fields",
+			row:        models.ImportRow{},
+			wantStatus: models.ImportStatusUnchanged,
+			want:       models.SongDetail{ReleaseDate: stored.ReleaseDate, Text: stored.Text, Link: stored.Link},
+		},
+		{
+			name:       "whitespace only fields",
+			row:        models.ImportRow{ReleaseDate: " ", Text: " \r\n\t", Link: "\t"},
+			wantStatus: models.ImportStatusUnchanged,
+			want:       models.SongDetail{ReleaseDate: stored.ReleaseDate, Text: stored.Text, Link: stored.Link},
+		},
+		{
+			name:       "same values with whitespace",
+			row:        models.ImportRow{ReleaseDate: " 2006-07-16 ", Text: "Ooh baby, don't you know I suffer?\r\n", Link: " " + stored.Link},
+			wantStatus: models.ImportStatusUnchanged,
+			want:       models.SongDetail{ReleaseDate: stored.ReleaseDate, Text: stored.Text, Link: stored.Link},
+		},
+		{
+			name:       "one field changed",
+			row:        models.ImportRow{Text: "  Ooh baby\r\n"},
+			wantStatus: models.ImportStatusUpdated,
+			want:       models.SongDetail{ReleaseDate: stored.ReleaseDate, Text: "Ooh baby", Link: stored.Link},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			songs := &memorySongs{}
+			song := stored
+			if err := songs.Add(context.Background(), &song); err != nil {
+				t.Fatal(err)
+			}
+			importService := NewImportService(nil, songs, logger, cfg)
+
+			row := tt.row
+			row.Line, row.GroupName, row.SongName = 2, " Muse", "Supermassive Black Hole "
+			var results []models.ImportResult
+			if err := importService.ImportSongs(context.Background(), []models.ImportRow{row}, models.ImportOptions{}, func(result models.ImportResult) {
+				results = append(results, result)
+			}); err != nil {
+				t.Fatalf("ImportSongs() error = %v", err)
+			}
+
+			if len(results) != 1 || results[0].Status != tt.wantStatus {
+				t.Fatalf("ImportSongs() results = %+v, want status %s", results, tt.wantStatus)
+			}
+			got := songs.songs[0]
+			if detail := (models.SongDetail{ReleaseDate: got.ReleaseDate, Text: got.Text, Link: got.Link}); detail != tt.want {
+				t.Errorf("song after import = %+v, want %+v", detail, tt.want)
+			}
+		})
+	}
+}
+
+// Хранилище, в которое параллельный запрос добавляет песню сразу после первого поиска по названию
+type racingSongs struct {
+	*memorySongs
+	raced bool
+}
+
+func (r *racingSongs) GetByName(ctx context.Context, groupName, songName string) (*models.Song, error) {
+	song, err := r.memorySongs.GetByName(ctx, groupName, songName)
+	if !r.raced && errors.Is(err, repository.ErrNotFound) {
+		r.raced = true
+		r.memorySongs.Add(ctx, &models.Song{GroupName: groupName, SongName: songName, Text: "Ooh baby"})
+	}
+	return song, err
+}
+
+func TestImportUpdatesSongAddedConcurrently(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	cfg := &config.Config{
+		BulkMaxItems:        10,
+		ValidateReleaseDate: models.ValidationActionIgnore,
+		ValidateText:        models.ValidationActionIgnore,
+		ValidateLink:        models.ValidationActionIgnore,
+	}
+	songs := &racingSongs{memorySongs: &memorySongs{}}
+	importService := NewImportService(nil, songs, logger, cfg)
+
+	var results []models.ImportResult
+	rows := []models.ImportRow{{Line: 2, GroupName: "Muse", SongName: "Uprising", Link: "https://www.youtube.com/watch?v=w8KQmps-Sog"}}
+	if err := importService.ImportSongs(context.Background(), rows, models.ImportOptions{}, func(result models.ImportResult) {
+		results = append(results, result)
+	}); err != nil {
+		t.Fatalf("ImportSongs() error = %v", err)
+	}
+
+	if len(results) != 1 || results[0].Status != models.ImportStatusUpdated {
+		t.Fatalf("ImportSongs() results = %+v, want the concurrently added song updated", results)
+	}
+	if len(songs.songs) != 1 {
+		t.Fatalf("library has %d songs after import, want 1", len(songs.songs))
+	}
+	if got := songs.songs[0]; got.Text != "Ooh baby" || got.Link != rows[0].Link {
+		t.Errorf("song after import = %+v, want text kept and link from the row", got)
+	}
+}
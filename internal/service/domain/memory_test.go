@@ -0,0 +1,146 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/repository"
+)
+
+// Хранилище песен в памяти с проверкой версий и уникальности названий, как в repository.SongRepository
+type memorySongs struct {
+	repository.SongRepository
+	mu     sync.Mutex
+	songs  []*models.Song
+	nextID uint
+}
+
+// Поиск песни по ID; вызывается под r.mu
+func (r *memorySongs) find(id uint) *models.Song {
+	for _, song := range r.songs {
+		if song.ID == id {
+			return song
+		}
+	}
+	return nil
+}
+
+func (r *memorySongs) Add(_ context.Context, song *models.Song) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stored := range r.songs {
+		if stored.GroupName == song.GroupName && stored.SongName == song.SongName {
+			return fmt.Errorf("%w: song %s by %s", repository.ErrDuplicate, song.SongName, song.GroupName)
+		}
+	}
+	r.nextID++
+	song.ID = r.nextID
+	song.Version = 1
+	stored := *song
+	r.songs = append(r.songs, &stored)
+	return nil
+}
+
+func (r *memorySongs) GetById(_ context.Context, id uint) (*models.Song, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	song := r.find(id)
+	if song == nil {
+		return nil, fmt.Errorf("%w: song with ID %d", repository.ErrNotFound, id)
+	}
+	stored := *song
+	return &stored, nil
+}
+
+func (r *memorySongs) GetByName(_ context.Context, groupName, songName string) (*models.Song, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, song := range r.songs {
+		if song.GroupName == groupName && song.SongName == songName {
+			stored := *song
+			return &stored, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memorySongs) Count(context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return int64(len(r.songs)), nil
+}
+
+func (r *memorySongs) Update(_ context.Context, song *models.Song) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := r.find(song.ID)
+	if stored == nil {
+		return fmt.Errorf("%w: song with ID %d", repository.ErrNotFound, song.ID)
+	}
+	if stored.Version != song.Version {
+		return fmt.Errorf("%w: song with ID %d", repository.ErrConflict, song.ID)
+	}
+	song.Version++
+	*stored = *song
+	return nil
+}
+
+// Песни, выбранные по списку ID; фильтр выбирает песни по группе. Вызывается под r.mu
+func (r *memorySongs) matching(match models.SongMatch) []*models.Song {
+	var matched []*models.Song
+	for _, song := range r.songs {
+		if slices.Contains(match.IDs, song.ID) || (match.Filter != nil && match.Filter["group"] == song.GroupName) {
+			matched = append(matched, song)
+		}
+	}
+	return matched
+}
+
+func (r *memorySongs) CountMatching(_ context.Context, match models.SongMatch) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return int64(len(r.matching(match))), nil
+}
+
+func (r *memorySongs) UpdateMatching(_ context.Context, match models.SongMatch, changes map[string]interface{}, maxRows int64, recredit repository.CreditsFunc) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	matched := r.matching(match)
+	if maxRows > 0 && int64(len(matched)) > maxRows {
+		return 0, fmt.Errorf("%w: %d songs matched, at most %d allowed", repository.ErrTooManyRows, len(matched), maxRows)
+	}
+	for _, song := range matched {
+		if group, ok := changes["group"].(string); ok {
+			song.GroupName = group
+		}
+		if name, ok := changes["song"].(string); ok {
+			song.SongName = name
+		}
+		song.Version++
+	}
+	return int64(len(matched)), nil
+}
+
+func (r *memorySongs) DeleteMatching(_ context.Context, match models.SongMatch, maxRows int64) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	matched := r.matching(match)
+	if maxRows > 0 && int64(len(matched)) > maxRows {
+		return 0, fmt.Errorf("%w: %d songs matched, at most %d allowed", repository.ErrTooManyRows, len(matched), maxRows)
+	}
+	r.songs = slices.DeleteFunc(r.songs, func(song *models.Song) bool {
+		return slices.Contains(matched, song)
+	})
+	return int64(len(matched)), nil
+}
@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+var ErrSongValidation = errors.New("song details failed validation")
+
+// Формат хранения даты релиза
+const releaseDateLayout = "02.01.2006"
+
+// Допустимые форматы даты релиза во внешнем API
+var releaseDateLayouts = []string{
+	releaseDateLayout,
+	"2.1.2006",
+	"2006-01-02",
+	time.RFC3339,
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+}
+
+// Действия при нарушении правил для каждого поля
+type ValidationRules struct {
+	ReleaseDate string
+	Text        string
+	Link        string
+}
+
+// Ошибка валидации с отчетом о нарушениях
+type ValidationError struct {
+	Report models.ValidationReport
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Report.Issues))
+	for _, issue := range e.Report.Issues {
+		if issue.Action == models.ValidationActionReject {
+			fields = append(fields, fmt.Sprintf("%s: %s", issue.Field, issue.Message))
+		}
+	}
+	return fmt.Sprintf("%v: %s", ErrSongValidation, strings.Join(fields, "; "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrSongValidation
+}
+
+// Нормализация строки: HTML-сущности, Unicode NFC и пробелы по краям
+func normalizeString(s string) string {
+	s = html.UnescapeString(s)
+	s = norm.NFC.String(s)
+	return strings.TrimSpace(s)
+}
+
+// Нормализация текста песни: дополнительно унифицирует переводы строк
+func normalizeText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	lines := strings.Split(normalizeString(s), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRightFunc(line, func(r rune) bool { return r == ' ' || r == '\t' })
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Приведение даты релиза к формату хранения
+func parseReleaseDate(s string) (string, bool) {
+	for _, layout := range releaseDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format(releaseDateLayout), true
+		}
+	}
+	return s, false
+}
+
+// Проверка ссылки: абсолютный http(s) URL с хостом
+func validLink(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Нормализация и валидация данных из внешнего API
+func normalizeSongDetail(detail models.SongDetail, rules ValidationRules) (models.SongDetail, models.ValidationReport) {
+	var report models.ValidationReport
+	check := func(field, action, rule, message string) {
+		if action == models.ValidationActionIgnore {
+			return
+		}
+		report.Add(models.ValidationIssue{Field: field, Rule: rule, Message: message, Action: action})
+	}
+
+	result := models.SongDetail{
+		ReleaseDate: normalizeString(detail.ReleaseDate),
+		Text:        normalizeText(detail.Text),
+		Link:        normalizeString(detail.Link),
+	}
+
+	if result.ReleaseDate == "" {
+		check("releaseDate", rules.ReleaseDate, "required", "release date is empty")
+	} else if date, ok := parseReleaseDate(result.ReleaseDate); ok {
+		result.ReleaseDate = date
+	} else {
+		check("releaseDate", rules.ReleaseDate, "date", fmt.Sprintf("unrecognized release date %q", result.ReleaseDate))
+	}
+
+	if result.Text == "" {
+		check("text", rules.Text, "required", "text is empty")
+	}
+
+	if result.Link == "" {
+		check("link", rules.Link, "required", "link is empty")
+	} else if !validLink(result.Link) {
+		check("link", rules.Link, "url", fmt.Sprintf("invalid link %q", result.Link))
+	}
+
+	return result, report
+}
@@ -0,0 +1,150 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+// Нарушения отчета в виде "поле/правило/действие"
+func reportIssues(report models.ValidationReport) []string {
+	issues := make([]string, 0, len(report.Issues))
+	for _, issue := range report.Issues {
+		issues = append(issues, issue.Field+"/"+issue.Rule+"/"+issue.Action)
+	}
+	return issues
+}
+
+func TestNormalizeSongDetail(t *testing.T) {
+	valid := models.SongDetail{
+		ReleaseDate: "16.07.2006",
+		Text:        "Ooh baby, don't you know I suffer?",
+		Link:        "https://www.youtube.com/watch?v=Xsp3_a-PMTw",
+	}
+	reject := ValidationRules{
+		ReleaseDate: models.ValidationActionReject,
+		Text:        models.ValidationActionReject,
+		Link:        models.ValidationActionReject,
+	}
+	flag := ValidationRules{
+		ReleaseDate: models.ValidationActionFlag,
+		Text:        models.ValidationActionFlag,
+		Link:        models.ValidationActionFlag,
+	}
+	ignore := ValidationRules{
+		ReleaseDate: models.ValidationActionIgnore,
+		Text:        models.ValidationActionIgnore,
+		Link:        models.ValidationActionIgnore,
+	}
+
+	tests := []struct {
+		name       string
+		detail     models.SongDetail
+		rules      ValidationRules
+		want       models.SongDetail
+		wantIssues []string
+	}{
+		{
+			name:   "valid",
+			detail: valid,
+			rules:  reject,
+			want:   valid,
+		},
+		{
+			name: "whitespace and entities",
+			detail: models.SongDetail{
+				ReleaseDate: " 16.07.2006\t",
+				Text:        "  Ooh baby, don&#39;t you know  \r\nI suffer?\r\n",
+				Link:        "\nhttps://www.youtube.com/watch?v=Xsp3_a-PMTw&amp;t=1 ",
+			},
+			rules: reject,
+			want: models.SongDetail{
+				ReleaseDate: "16.07.2006",
+				Text:        "Ooh baby, don't you know\nI suffer?",
+				Link:        "https://www.youtube.com/watch?v=Xsp3_a-PMTw&t=1",
+			},
+		},
+		{
+			name:   "composed unicode",
+			detail: models.SongDetail{ReleaseDate: valid.ReleaseDate, Text: "Cafe\u0301", Link: valid.Link},
+			rules:  reject,
+			want:   models.SongDetail{ReleaseDate: valid.ReleaseDate, Text: "Caf\u00e9", Link: valid.Link},
+		},
+		{
+			name:       "empty fields rejected",
+			detail:     models.SongDetail{},
+			rules:      reject,
+			want:       models.SongDetail{},
+			wantIssues: []string{"releaseDate/required/reject", "text/required/reject", "link/required/reject"},
+		},
+		{
+			name:       "whitespace only fields flagged",
+			detail:     models.SongDetail{ReleaseDate: "  ", Text: " \r\n\t", Link: "\t"},
+			rules:      flag,
+			want:       models.SongDetail{},
+			wantIssues: []string{"releaseDate/required/flag", "text/required/flag", "link/required/flag"},
+		},
+		{
+			name:   "empty fields ignored",
+			detail: models.SongDetail{Text: " "},
+			rules:  ignore,
+			want:   models.SongDetail{},
+		},
+		{
+			name:       "invalid values keep their text",
+			detail:     models.SongDetail{ReleaseDate: " summer 2006 ", Text: valid.Text, Link: " youtube.com/watch "},
+			rules:      reject,
+			want:       models.SongDetail{ReleaseDate: "summer 2006", Text: valid.Text, Link: "youtube.com/watch"},
+			wantIssues: []string{"releaseDate/date/reject", "link/url/reject"},
+		},
+		{
+			name:       "rules per field",
+			detail:     models.SongDetail{ReleaseDate: "someday", Link: "ftp://example.com/song"},
+			rules:      ValidationRules{ReleaseDate: models.ValidationActionFlag, Text: models.ValidationActionIgnore, Link: models.ValidationActionReject},
+			want:       models.SongDetail{ReleaseDate: "someday", Link: "ftp://example.com/song"},
+			wantIssues: []string{"releaseDate/date/flag", "link/url/reject"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, report := normalizeSongDetail(tt.detail, tt.rules)
+			if got != tt.want {
+				t.Errorf("normalizeSongDetail() = %+v, want %+v", got, tt.want)
+			}
+			if issues := reportIssues(report); !slices.Equal(issues, tt.wantIssues) {
+				t.Errorf("normalizeSongDetail() issues = %v, want %v", issues, tt.wantIssues)
+			}
+		})
+	}
+}
+
+func TestParseReleaseDate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{in: "16.07.2006", want: "16.07.2006", ok: true},
+		{in: "6.7.2006", want: "06.07.2006", ok: true},
+		{in: "2006-07-16", want: "16.07.2006", ok: true},
+		{in: "2006-07-16T00:00:00Z", want: "16.07.2006", ok: true},
+		{in: "July 16, 2006", want: "16.07.2006", ok: true},
+		{in: "Jul 16, 2006", want: "16.07.2006", ok: true},
+		{in: "16 July 2006", want: "16.07.2006", ok: true},
+		{in: "16 Jul 2006", want: "16.07.2006", ok: true},
+		{in: "07/16/2006", want: "07/16/2006"},
+		{in: "31.02.2006", want: "31.02.2006"},
+		{in: "2006", want: "2006"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, ok := parseReleaseDate(tt.in)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("parseReleaseDate(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,315 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/internal/identity"
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/repository"
+	"github.com/ananikitina/song_lib/internal/service"
+)
+
+var (
+	ErrPlaylistNotFound     = errors.New("playlist not found")
+	ErrPlaylistItemNotFound = errors.New("playlist item not found")
+	ErrInvalidPosition      = errors.New("invalid playlist position")
+	ErrInvalidReorder       = errors.New("itemIds must list every playlist item exactly once")
+)
+
+type playlistService struct {
+	repo     repository.PlaylistRepository
+	songRepo repository.SongRepository
+	logger   *logrus.Logger
+}
+
+func NewPlaylistService(repo repository.PlaylistRepository, songRepo repository.SongRepository, logger *logrus.Logger) service.PlaylistService {
+	return &playlistService{
+		repo:     repo,
+		songRepo: songRepo,
+		logger:   logger,
+	}
+}
+
+// Ошибка отсутствия плейлиста или несовпадения его версии
+func playlistNotFound(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return ErrPlaylistNotFound
+	case errors.Is(err, repository.ErrConflict):
+		return ErrVersionMismatch
+	default:
+		return err
+	}
+}
+
+// Песни плейлиста по порядку; позиции считаются заново, так как удаление песни
+// из библиотеки оставляет пропуск до следующего изменения плейлиста
+func (s *playlistService) withItems(ctx context.Context, playlist *models.Playlist) (*models.Playlist, error) {
+	items, err := s.repo.Items(ctx, playlist.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].Position = i + 1
+	}
+	playlist.Items = items
+	return playlist, nil
+}
+
+func normalizePlaylistRequest(req models.PlaylistRequest) (models.PlaylistRequest, error) {
+	req.Name = normalizeString(req.Name)
+	req.Description = normalizeText(req.Description)
+	if req.Name == "" {
+		return req, fmt.Errorf("%w: playlist name is required", ErrEmptyParameters)
+	}
+	return req, nil
+}
+
+// Создание плейлиста
+func (s *playlistService) CreatePlaylist(ctx context.Context, req models.PlaylistRequest) (*models.Playlist, error) {
+	req, err := normalizePlaylistRequest(req)
+	if err != nil {
+		s.logger.WithContext(ctx).Warnf("CreatePlaylist: %v", err)
+		return nil, err
+	}
+
+	playlist := &models.Playlist{
+		Name:        req.Name,
+		Description: req.Description,
+		CreatedBy:   identity.Subject(ctx),
+		UpdatedBy:   identity.Subject(ctx),
+	}
+	if err := s.repo.Add(ctx, playlist); err != nil {
+		s.logger.WithContext(ctx).Errorf("CreatePlaylist: failed to save playlist: %v", err)
+		return nil, err
+	}
+
+	s.logger.WithContext(ctx).Infof("CreatePlaylist: playlist created with ID: %d", playlist.ID)
+	return playlist, nil
+}
+
+// Получение плейлистов
+func (s *playlistService) ListPlaylists(ctx context.Context, page int, pageSize int) ([]models.Playlist, error) {
+	playlists, err := s.repo.List(ctx, page, pageSize)
+	if err != nil {
+		s.logger.WithContext(ctx).Errorf("ListPlaylists: failed to fetch playlists: %v", err)
+		return nil, err
+	}
+	return playlists, nil
+}
+
+// Получение плейлиста с песнями
+func (s *playlistService) GetPlaylist(ctx context.Context, id uint) (*models.Playlist, error) {
+	if id == 0 {
+		return nil, ErrInvalidID
+	}
+	playlist, err := s.repo.GetById(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Warnf("GetPlaylist: failed to get playlist with ID %d: %v", id, err)
+		return nil, playlistNotFound(err)
+	}
+	return s.withItems(ctx, playlist)
+}
+
+// Изменение названия и описания плейлиста
+func (s *playlistService) UpdatePlaylist(ctx context.Context, id uint, req models.PlaylistRequest, version int) (*models.Playlist, error) {
+	if id == 0 {
+		return nil, ErrInvalidID
+	}
+	req, err := normalizePlaylistRequest(req)
+	if err != nil {
+		s.logger.WithContext(ctx).Warnf("UpdatePlaylist: %v", err)
+		return nil, err
+	}
+
+	playlist, err := s.repo.GetById(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Warnf("UpdatePlaylist: failed to get playlist with ID %d: %v", id, err)
+		return nil, playlistNotFound(err)
+	}
+	if version != 0 && playlist.Version != version {
+		s.logger.WithContext(ctx).Warnf("UpdatePlaylist: playlist with ID %d has version %d, expected %d", id, playlist.Version, version)
+		return nil, ErrVersionMismatch
+	}
+
+	playlist.Name = req.Name
+	playlist.Description = req.Description
+	playlist.UpdatedAt = time.Now()
+	playlist.UpdatedBy = identity.Subject(ctx)
+	if err := s.repo.Update(ctx, playlist); err != nil {
+		s.logger.WithContext(ctx).Errorf("UpdatePlaylist: failed to update playlist with ID %d: %v", id, err)
+		return nil, playlistNotFound(err)
+	}
+
+	s.logger.WithContext(ctx).Infof("UpdatePlaylist: playlist updated with ID: %d", id)
+	return s.withItems(ctx, playlist)
+}
+
+// Удаление плейлиста
+func (s *playlistService) DeletePlaylist(ctx context.Context, id uint, version int) error {
+	if id == 0 {
+		return ErrInvalidID
+	}
+	if err := s.repo.Delete(ctx, id, version); err != nil {
+		s.logger.WithContext(ctx).Warnf("DeletePlaylist: failed to delete playlist with ID %d: %v", id, err)
+		return playlistNotFound(err)
+	}
+
+	s.logger.WithContext(ctx).Infof("DeletePlaylist: playlist deleted with ID: %d", id)
+	return nil
+}
+
+// Изменение песен плейлиста функцией edit с отметкой автора изменения
+func (s *playlistService) editItems(ctx context.Context, id uint, version int, edit func(items []models.PlaylistItem) ([]models.PlaylistItem, error)) (*models.Playlist, error) {
+	if id == 0 {
+		return nil, ErrInvalidID
+	}
+	playlist, err := s.repo.EditItems(ctx, id, version, func(playlist *models.Playlist, items []models.PlaylistItem) ([]models.PlaylistItem, error) {
+		playlist.UpdatedAt = time.Now()
+		playlist.UpdatedBy = identity.Subject(ctx)
+		return edit(items)
+	})
+	if err != nil {
+		return nil, playlistNotFound(err)
+	}
+	return s.withItems(ctx, playlist)
+}
+
+// Индекс элемента плейлиста по ID
+func itemIndex(items []models.PlaylistItem, itemId uint) (int, error) {
+	for i, item := range items {
+		if item.ID == itemId {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: item %d", ErrPlaylistItemNotFound, itemId)
+}
+
+// Вставка элемента на позицию position (с 1)
+func insertItem(items []models.PlaylistItem, item models.PlaylistItem, position int) []models.PlaylistItem {
+	items = append(items, models.PlaylistItem{})
+	copy(items[position:], items[position-1:])
+	items[position-1] = item
+	return items
+}
+
+// Добавление элемента на позицию (с 1) или, при position == 0, в конец
+func addItemAt(items []models.PlaylistItem, item models.PlaylistItem, position int) ([]models.PlaylistItem, error) {
+	if position == 0 {
+		position = len(items) + 1
+	}
+	if position < 1 || position > len(items)+1 {
+		return nil, fmt.Errorf("%w: %d, expected 1 to %d", ErrInvalidPosition, position, len(items)+1)
+	}
+	return insertItem(items, item, position), nil
+}
+
+// Удаление элемента; следующие элементы сдвигаются на его место
+func removeItem(items []models.PlaylistItem, itemId uint) ([]models.PlaylistItem, error) {
+	i, err := itemIndex(items, itemId)
+	if err != nil {
+		return nil, err
+	}
+	return append(items[:i], items[i+1:]...), nil
+}
+
+// Перемещение элемента на позицию position (с 1)
+func moveItem(items []models.PlaylistItem, itemId uint, position int) ([]models.PlaylistItem, error) {
+	i, err := itemIndex(items, itemId)
+	if err != nil {
+		return nil, err
+	}
+	if position < 1 || position > len(items) {
+		return nil, fmt.Errorf("%w: %d, expected 1 to %d", ErrInvalidPosition, position, len(items))
+	}
+	item := items[i]
+	items = append(items[:i], items[i+1:]...)
+	return insertItem(items, item, position), nil
+}
+
+// Элементы в порядке itemIds; itemIds должен перечислять каждый элемент ровно один раз
+func reorderItems(items []models.PlaylistItem, itemIds []uint) ([]models.PlaylistItem, error) {
+	if len(itemIds) != len(items) {
+		return nil, fmt.Errorf("%w: got %d items, playlist has %d", ErrInvalidReorder, len(itemIds), len(items))
+	}
+	byId := make(map[uint]models.PlaylistItem, len(items))
+	for _, item := range items {
+		byId[item.ID] = item
+	}
+	reordered := make([]models.PlaylistItem, 0, len(items))
+	for _, itemId := range itemIds {
+		item, ok := byId[itemId]
+		if !ok {
+			return nil, fmt.Errorf("%w: item %d is not in the playlist or repeated", ErrInvalidReorder, itemId)
+		}
+		delete(byId, itemId)
+		reordered = append(reordered, item)
+	}
+	return reordered, nil
+}
+
+// Добавление песни на позицию или в конец плейлиста
+func (s *playlistService) AddItem(ctx context.Context, id uint, req models.AddPlaylistItemRequest, version int) (*models.Playlist, error) {
+	if _, err := s.songRepo.GetById(ctx, req.SongID); err != nil {
+		s.logger.WithContext(ctx).Warnf("AddItem: failed to get song with ID %d: %v", req.SongID, err)
+		return nil, songNotFound(err)
+	}
+
+	playlist, err := s.editItems(ctx, id, version, func(items []models.PlaylistItem) ([]models.PlaylistItem, error) {
+		return addItemAt(items, models.PlaylistItem{SongID: req.SongID}, req.Position)
+	})
+	if err != nil {
+		s.logger.WithContext(ctx).Warnf("AddItem: failed to add song %d to playlist %d: %v", req.SongID, id, err)
+		return nil, err
+	}
+
+	s.logger.WithContext(ctx).Infof("AddItem: song %d added to playlist %d", req.SongID, id)
+	return playlist, nil
+}
+
+// Удаление песни из плейлиста; следующие песни сдвигаются на ее место
+func (s *playlistService) RemoveItem(ctx context.Context, id uint, itemId uint, version int) (*models.Playlist, error) {
+	playlist, err := s.editItems(ctx, id, version, func(items []models.PlaylistItem) ([]models.PlaylistItem, error) {
+		return removeItem(items, itemId)
+	})
+	if err != nil {
+		s.logger.WithContext(ctx).Warnf("RemoveItem: failed to remove item %d from playlist %d: %v", itemId, id, err)
+		return nil, err
+	}
+
+	s.logger.WithContext(ctx).Infof("RemoveItem: item %d removed from playlist %d", itemId, id)
+	return playlist, nil
+}
+
+// Перемещение песни на позицию position; песни между старой и новой позицией сдвигаются
+func (s *playlistService) MoveItem(ctx context.Context, id uint, itemId uint, position int, version int) (*models.Playlist, error) {
+	playlist, err := s.editItems(ctx, id, version, func(items []models.PlaylistItem) ([]models.PlaylistItem, error) {
+		return moveItem(items, itemId, position)
+	})
+	if err != nil {
+		s.logger.WithContext(ctx).Warnf("MoveItem: failed to move item %d of playlist %d: %v", itemId, id, err)
+		return nil, err
+	}
+
+	s.logger.WithContext(ctx).Infof("MoveItem: item %d of playlist %d moved to position %d", itemId, id, position)
+	return playlist, nil
+}
+
+// Новый порядок всех песен плейлиста
+func (s *playlistService) ReorderItems(ctx context.Context, id uint, itemIds []uint, version int) (*models.Playlist, error) {
+	playlist, err := s.editItems(ctx, id, version, func(items []models.PlaylistItem) ([]models.PlaylistItem, error) {
+		return reorderItems(items, itemIds)
+	})
+	if err != nil {
+		s.logger.WithContext(ctx).Warnf("ReorderItems: failed to reorder playlist %d: %v", id, err)
+		return nil, err
+	}
+
+	s.logger.WithContext(ctx).Infof("ReorderItems: playlist %d reordered", id)
+	return playlist, nil
+}
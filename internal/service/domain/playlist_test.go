@@ -0,0 +1,154 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+// Элементы плейлиста с заданными ID в порядке аргументов
+func playlistItems(ids ...uint) []models.PlaylistItem {
+	items := make([]models.PlaylistItem, 0, len(ids))
+	for _, id := range ids {
+		items = append(items, models.PlaylistItem{ID: id, SongID: id * 10})
+	}
+	return items
+}
+
+func playlistItemIds(items []models.PlaylistItem) []uint {
+	ids := make([]uint, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+func TestAddItemAt(t *testing.T) {
+	tests := []struct {
+		name     string
+		items    []models.PlaylistItem
+		position int
+		want     []uint
+		wantErr  error
+	}{
+		{name: "append to empty", items: playlistItems(), position: 0, want: []uint{9}},
+		{name: "append by default", items: playlistItems(1, 2, 3), position: 0, want: []uint{1, 2, 3, 9}},
+		{name: "first", items: playlistItems(1, 2, 3), position: 1, want: []uint{9, 1, 2, 3}},
+		{name: "middle", items: playlistItems(1, 2, 3), position: 2, want: []uint{1, 9, 2, 3}},
+		{name: "last existing", items: playlistItems(1, 2, 3), position: 3, want: []uint{1, 2, 9, 3}},
+		{name: "after last", items: playlistItems(1, 2, 3), position: 4, want: []uint{1, 2, 3, 9}},
+		{name: "past end", items: playlistItems(1, 2, 3), position: 5, wantErr: ErrInvalidPosition},
+		{name: "negative", items: playlistItems(1, 2, 3), position: -1, wantErr: ErrInvalidPosition},
+		{name: "past end of empty", items: playlistItems(), position: 2, wantErr: ErrInvalidPosition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := addItemAt(tt.items, models.PlaylistItem{ID: 9}, tt.position)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("addItemAt() error = %v, want %v", err, tt.wantErr)
+			}
+			if ids := playlistItemIds(got); err == nil && !slices.Equal(ids, tt.want) {
+				t.Errorf("addItemAt() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveItem(t *testing.T) {
+	tests := []struct {
+		name    string
+		itemId  uint
+		want    []uint
+		wantErr error
+	}{
+		{name: "first", itemId: 1, want: []uint{2, 3}},
+		{name: "middle", itemId: 2, want: []uint{1, 3}},
+		{name: "last", itemId: 3, want: []uint{1, 2}},
+		{name: "missing", itemId: 4, wantErr: ErrPlaylistItemNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := removeItem(playlistItems(1, 2, 3), tt.itemId)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("removeItem() error = %v, want %v", err, tt.wantErr)
+			}
+			if ids := playlistItemIds(got); err == nil && !slices.Equal(ids, tt.want) {
+				t.Errorf("removeItem() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoveItem(t *testing.T) {
+	tests := []struct {
+		name     string
+		itemId   uint
+		position int
+		want     []uint
+		wantErr  error
+	}{
+		{name: "first to last", itemId: 1, position: 4, want: []uint{2, 3, 4, 1}},
+		{name: "last to first", itemId: 4, position: 1, want: []uint{4, 1, 2, 3}},
+		{name: "forward by one", itemId: 2, position: 3, want: []uint{1, 3, 2, 4}},
+		{name: "backward by one", itemId: 3, position: 2, want: []uint{1, 3, 2, 4}},
+		{name: "same position", itemId: 2, position: 2, want: []uint{1, 2, 3, 4}},
+		{name: "zero position", itemId: 2, position: 0, wantErr: ErrInvalidPosition},
+		{name: "after last", itemId: 2, position: 5, wantErr: ErrInvalidPosition},
+		{name: "missing item", itemId: 5, position: 1, wantErr: ErrPlaylistItemNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := moveItem(playlistItems(1, 2, 3, 4), tt.itemId, tt.position)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("moveItem() error = %v, want %v", err, tt.wantErr)
+			}
+			if ids := playlistItemIds(got); err == nil && !slices.Equal(ids, tt.want) {
+				t.Errorf("moveItem() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestReorderItems(t *testing.T) {
+	tests := []struct {
+		name    string
+		itemIds []uint
+		want    []uint
+		wantErr error
+	}{
+		{name: "reversed", itemIds: []uint{3, 2, 1}, want: []uint{3, 2, 1}},
+		{name: "unchanged", itemIds: []uint{1, 2, 3}, want: []uint{1, 2, 3}},
+		{name: "missing item", itemIds: []uint{3, 1}, wantErr: ErrInvalidReorder},
+		{name: "extra item", itemIds: []uint{3, 2, 1, 4}, wantErr: ErrInvalidReorder},
+		{name: "unknown item", itemIds: []uint{3, 2, 4}, wantErr: ErrInvalidReorder},
+		{name: "repeated item", itemIds: []uint{3, 3, 1}, wantErr: ErrInvalidReorder},
+		{name: "empty", itemIds: nil, wantErr: ErrInvalidReorder},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := playlistItems(1, 2, 3)
+			got, err := reorderItems(items, tt.itemIds)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("reorderItems() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if ids := playlistItemIds(got); !slices.Equal(ids, tt.want) {
+				t.Errorf("reorderItems() = %v, want %v", ids, tt.want)
+			}
+			// Песни переезжают вместе с элементами
+			for _, item := range got {
+				if item.SongID != item.ID*10 {
+					t.Errorf("reorderItems() item %d has song %d, want %d", item.ID, item.SongID, item.ID*10)
+				}
+			}
+		})
+	}
+}
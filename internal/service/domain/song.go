@@ -10,10 +10,15 @@ import (
 	"time"
 
 	"github.com/ananikitina/song_lib/config"
+	"github.com/ananikitina/song_lib/internal/identity"
+	"github.com/ananikitina/song_lib/internal/logging"
 	"github.com/ananikitina/song_lib/internal/models"
 	"github.com/ananikitina/song_lib/internal/repository"
 	"github.com/ananikitina/song_lib/internal/service"
+	"github.com/ananikitina/song_lib/internal/tenant"
+	"github.com/ananikitina/song_lib/internal/tracing"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Кастомные ошибки
@@ -22,22 +27,76 @@ var (
 	ErrEmptyParameters  = errors.New("parameters must not be empty")
 	ErrSongNotFound     = errors.New("song not found")
 	ErrFailedAPIRequest = errors.New("failed to fetch data from external API")
+	ErrVersionMismatch  = errors.New("song version does not match")
+	ErrInvalidFilter    = errors.New("invalid filter")
+	ErrSongExists       = errors.New("song with this group and name already exists")
 )
 
 type songService struct {
-	repo   repository.SongRepository
-	logger *logrus.Logger
-	client *http.Client
+	repo        repository.SongRepository
+	credits     repository.CreditRepository
+	logger      *logrus.Logger
+	client      *http.Client
+	externalApi string
+	rules       ValidationRules
 }
 
-func NewSongService(repo repository.SongRepository, logger *logrus.Logger) service.SongService {
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
+func NewSongService(repo repository.SongRepository, credits repository.CreditRepository, logger *logrus.Logger, cfg *config.Config, client *http.Client) service.SongService {
 	return &songService{
-		repo:   repo,
-		logger: logger,
-		client: client,
+		repo:        repo,
+		credits:     credits,
+		logger:      logger,
+		client:      client,
+		externalApi: cfg.ExternalApi,
+		rules: ValidationRules{
+			ReleaseDate: cfg.ValidateReleaseDate,
+			Text:        cfg.ValidateText,
+			Link:        cfg.ValidateLink,
+		},
+	}
+}
+
+// Адрес внешнего API: собственный у арендатора или из общей конфигурации
+func (s *songService) externalApiURL(ctx context.Context) string {
+	if t := tenant.FromContext(ctx); t != nil && t.ExternalApi != "" {
+		return t.ExternalApi
+	}
+	return s.externalApi
+}
+
+// Предварительная проверка квоты арендатора на число песен, чтобы не обращаться к внешнему API зря;
+// окончательно квота проверяется репозиторием в транзакции добавления
+func checkSongQuota(ctx context.Context, repo repository.SongRepository) error {
+	t := tenant.FromContext(ctx)
+	if t == nil || t.MaxSongs == nil {
+		return nil
+	}
+	count, err := repo.Count(ctx)
+	if err != nil {
+		return err
+	}
+	if count >= int64(*t.MaxSongs) {
+		return fmt.Errorf("%w: tenant %s is limited to %d songs", ErrSongQuotaExceeded, t.ID, *t.MaxSongs)
+	}
+	return nil
+}
+
+// Ошибка отсутствия песни, несовпадения версии, неверного фильтра, исчерпанной квоты или повтора песни;
+// прочие ошибки (в том числе отмена контекста) возвращаются как есть
+func songNotFound(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return ErrSongNotFound
+	case errors.Is(err, repository.ErrConflict):
+		return ErrVersionMismatch
+	case errors.Is(err, repository.ErrInvalidFilter):
+		return fmt.Errorf("%w: %v", ErrInvalidFilter, err)
+	case errors.Is(err, repository.ErrQuotaExceeded):
+		return fmt.Errorf("%w: %v", ErrSongQuotaExceeded, err)
+	case errors.Is(err, repository.ErrDuplicate):
+		return fmt.Errorf("%w: %v", ErrSongExists, err)
+	default:
+		return err
 	}
 }
 
@@ -62,20 +121,15 @@ func (s *songService) validateNonEmptyParams(params ...string) error {
 // Получение информации о песне из внешнего API
 func (s *songService) GetSongInfo(ctx context.Context, groupName, songName string) (*models.SongDetail, error) {
 	if err := s.validateNonEmptyParams(groupName, songName); err != nil {
-		s.logger.Warn("GetSongInfo: groupName or songName is empty")
+		s.logger.WithContext(ctx).Warn("GetSongInfo: groupName or songName is empty")
 		return nil, err
 	}
 
-	s.logger.Infof("GetSongInfo: fetching song info for group: %s and song: %s", groupName, songName)
-
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		return nil, err
-	}
+	s.logger.WithContext(ctx).Infof("GetSongInfo: fetching song info for group: %s and song: %s", groupName, songName)
 
 	encodedGroup := url.QueryEscape(groupName)
 	encodedSong := url.QueryEscape(songName)
-	url := fmt.Sprintf("%s/info?group=%s&song=%s", cfg.ExternalApi, encodedGroup, encodedSong)
+	url := fmt.Sprintf("%s/info?group=%s&song=%s", s.externalApiURL(ctx), encodedGroup, encodedSong)
 
 	// Создание запроса с контекстом
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -85,190 +139,280 @@ func (s *songService) GetSongInfo(ctx context.Context, groupName, songName strin
 
 	resp, err := s.client.Do(req)
 	if err != nil {
-		s.logger.Errorf("GetSongInfo: failed to fetch data from external API: %v", err)
-		return nil, ErrFailedAPIRequest
+		s.logger.WithContext(ctx).Errorf("GetSongInfo: failed to fetch data from external API: %v", err)
+		return nil, fmt.Errorf("%w: %w", ErrFailedAPIRequest, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		s.logger.Errorf("GetSongInfo: unexpected status code: %d", resp.StatusCode)
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		s.logger.WithContext(ctx).Errorf("GetSongInfo: unexpected status code: %d", resp.StatusCode)
+		return nil, fmt.Errorf("%w: unexpected status code: %d", ErrFailedAPIRequest, resp.StatusCode)
 	}
 
 	var songDetail models.SongDetail
 	if err := json.NewDecoder(resp.Body).Decode(&songDetail); err != nil {
-		s.logger.Errorf("GetSongInfo: failed to decode external API response: %v", err)
-		return nil, fmt.Errorf("failed to decode song info response: %w", err)
+		s.logger.WithContext(ctx).Errorf("GetSongInfo: failed to decode external API response: %v", err)
+		return nil, fmt.Errorf("%w: failed to decode song info response: %w", ErrFailedAPIRequest, err)
 	}
 
-	s.logger.Infof("GetSongInfo: successfully fetched song info for group: %s and song: %s", groupName, songName)
+	s.logger.WithContext(ctx).Infof("GetSongInfo: successfully fetched song info for group: %s and song: %s", groupName, songName)
 	return &songDetail, nil
 }
 
-// Добавление песни
+// Добавление песни; группа и название нормализуются так же, как при импорте,
+// чтобы импорт находил песню по ним
 func (s *songService) AddSong(ctx context.Context, groupName, songName string) (*models.Song, error) {
+	groupName, songName = normalizeString(groupName), normalizeString(songName)
 	if err := s.validateNonEmptyParams(groupName, songName); err != nil {
-		s.logger.Warn("AddSong: groupName or songName is empty")
+		s.logger.WithContext(ctx).Warn("AddSong: groupName or songName is empty")
 		return nil, err
 	}
 
-	s.logger.Infof("AddSong: creating song: %s with group: %s", songName, groupName)
+	if err := checkSongQuota(ctx, s.repo); err != nil {
+		s.logger.WithContext(ctx).Warnf("AddSong: %v", err)
+		return nil, err
+	}
+
+	s.logger.WithContext(ctx).Infof("AddSong: creating song: %s with group: %s", songName, groupName)
 
-	// Получение информации о песне через внешний API
-	songDetail, err := s.GetSongInfo(ctx, groupName, songName)
+	// Получение информации о песне через внешний API; вызов идет мимо обертки сервиса,
+	// поэтому спан открывается здесь
+	infoCtx, span := tracing.Start(ctx, "SongService.GetSongInfo",
+		attribute.String("song.group", groupName), attribute.String("song.name", songName))
+	songDetail, err := s.GetSongInfo(infoCtx, groupName, songName)
+	tracing.End(span, err)
 	if err != nil {
-		s.logger.Errorf("failed to fetch song info: %v", err)
+		s.logger.WithContext(ctx).Errorf("failed to fetch song info: %v", err)
 		return nil, err
 	}
 
+	// Нормализация и валидация полученных данных
+	detail, report := normalizeSongDetail(*songDetail, s.rules)
+	if report.Has(models.ValidationActionReject) {
+		s.logger.WithContext(ctx).Warnf("AddSong: song details rejected: %v", report.Issues)
+		return nil, &ValidationError{Report: report}
+	}
+
 	// Создание новой записи песни
 	song := &models.Song{
 		GroupName:   groupName,
 		SongName:    songName,
-		ReleaseDate: songDetail.ReleaseDate,
-		Text:        songDetail.Text,
-		Link:        songDetail.Link,
+		ReleaseDate: detail.ReleaseDate,
+		Text:        detail.Text,
+		Link:        detail.Link,
+		NeedsReview: report.Has(models.ValidationActionFlag),
+		CreatedBy:   identity.Subject(ctx),
+		UpdatedBy:   identity.Subject(ctx),
+		Credits:     parseCredits(groupName, songName),
+	}
+	if !report.Empty() {
+		s.logger.WithContext(ctx).Warnf("AddSong: song details flagged for review: %v", report.Issues)
+		song.ValidationReport = &report
 	}
 
 	// Сохранение песни в базе данных
-	if err := s.repo.Add(song); err != nil {
-		s.logger.Errorf("AddSong: failed to save song to database: %v", err)
-		return nil, err
+	if err := s.repo.Add(ctx, song); err != nil {
+		s.logger.WithContext(ctx).Errorf("AddSong: failed to save song to database: %v", err)
+		return nil, songNotFound(err)
 	}
 
-	s.logger.Infof("AddSong: song created with ID: %d", song.ID)
+	s.logger.WithContext(ctx).Infof("AddSong: song created with ID: %d", song.ID)
 	return song, nil
 }
 
 // Получение песни по ID
-func (s *songService) GetSongById(id uint) (*models.Song, error) {
+func (s *songService) GetSongById(ctx context.Context, id uint) (*models.Song, error) {
 	if err := s.validateId(id); err != nil {
-		s.logger.Warn("GetSongById: invalid id")
+		s.logger.WithContext(ctx).Warn("GetSongById: invalid id")
 		return nil, err
 	}
 
-	s.logger.Infof("GetSongById: getting song with id: %d", id)
+	s.logger.WithContext(ctx).Infof("GetSongById: getting song with id: %d", id)
 
-	song, err := s.repo.GetById(id)
+	song, err := s.repo.GetById(ctx, id)
 	if err != nil {
-		s.logger.Errorf("GetSongById: failed to fetch song from database: %v", err)
-		return nil, ErrSongNotFound
+		s.logger.WithContext(ctx).Errorf("GetSongById: failed to fetch song from database: %v", err)
+		return nil, songNotFound(err)
 	}
 
-	s.logger.Infof("GetSongById: got song with ID: %d", song.ID)
+	s.logger.WithContext(ctx).Infof("GetSongById: got song with ID: %d", song.ID)
 	return song, nil
 }
 
 // Получение всех песен
-func (s *songService) GetAllSongs() ([]models.Song, error) {
-	s.logger.Info("GetAllSongs: fetching all songs")
-	songs, err := s.repo.GetAll()
+func (s *songService) GetAllSongs(ctx context.Context) ([]models.Song, error) {
+	s.logger.WithContext(ctx).Info("GetAllSongs: fetching all songs")
+	songs, err := s.repo.GetAll(ctx)
 	if err != nil {
-		s.logger.Errorf("GetAllSongs: failed to fetch songs: %v", err)
+		s.logger.WithContext(ctx).Errorf("GetAllSongs: failed to fetch songs: %v", err)
 		return nil, err
 	}
 	return songs, nil
 }
 
-// Вспомогательная функция для обновления полей песни
-func updateNonEmptyFields(target, source *models.Song) {
-	if source.GroupName != "" {
-		target.GroupName = source.GroupName
+// Применение частичного обновления к песне с нормализацией и валидацией полей.
+// Название группы и песни очистить нельзя; null или пустая строка очищают остальные поля.
+func applySongPatch(song *models.Song, patch models.SongPatch) error {
+	var report models.ValidationReport
+	reject := func(field, rule, message string) {
+		report.Add(models.ValidationIssue{Field: field, Rule: rule, Message: message, Action: models.ValidationActionReject})
 	}
-	if source.SongName != "" {
-		target.SongName = source.SongName
+
+	required := func(target *string, field string, value models.PatchString) {
+		if !value.Set {
+			return
+		}
+		if normalized := normalizeString(value.Value); normalized != "" {
+			*target = normalized
+			return
+		}
+		reject(field, "required", field+" must not be empty")
 	}
-	if source.ReleaseDate != "" {
-		target.ReleaseDate = source.ReleaseDate
+	required(&song.GroupName, "group", patch.GroupName)
+	required(&song.SongName, "song", patch.SongName)
+
+	if patch.ReleaseDate.Set {
+		date := normalizeString(patch.ReleaseDate.Value)
+		if parsed, ok := parseReleaseDate(date); date == "" || ok {
+			song.ReleaseDate = parsed
+		} else {
+			reject("releaseDate", "date", fmt.Sprintf("unrecognized release date %q", date))
+		}
+	}
+
+	if patch.Text.Set {
+		song.Text = normalizeText(patch.Text.Value)
 	}
-	if source.Text != "" {
-		target.Text = source.Text
+
+	if patch.Link.Set {
+		link := normalizeString(patch.Link.Value)
+		if link == "" || validLink(link) {
+			song.Link = link
+		} else {
+			reject("link", "url", fmt.Sprintf("invalid link %q", link))
+		}
 	}
-	if source.Link != "" {
-		target.Link = source.Link
+
+	if !report.Empty() {
+		return &ValidationError{Report: report}
 	}
+	return nil
 }
 
 // Обновление песни
-func (s *songService) UpdateSong(songId uint, updatedSong models.Song) (*models.Song, error) {
+func (s *songService) UpdateSong(ctx context.Context, songId uint, patch models.SongPatch, version int) (*models.Song, error) {
 	if err := s.validateId(songId); err != nil {
-		s.logger.Warn("UpdateSong: invalid id")
+		s.logger.WithContext(ctx).Warn("UpdateSong: invalid id")
 		return nil, err
 	}
+	if patch.Empty() {
+		s.logger.WithContext(ctx).Warn("UpdateSong: no fields to update")
+		return nil, ErrEmptyParameters
+	}
 
-	s.logger.Infof("UpdateSong: updating song with ID: %d", songId)
+	s.logger.WithContext(ctx).Infof("UpdateSong: updating song with ID: %d", songId)
 
-	song, err := s.repo.GetById(songId)
+	song, err := s.repo.GetById(ctx, songId)
 	if err != nil {
-		s.logger.Errorf("UpdateSong: failed to update song: %v", err)
-		return nil, ErrSongNotFound
+		s.logger.WithContext(ctx).Errorf("UpdateSong: failed to update song: %v", err)
+		return nil, songNotFound(err)
+	}
+	if version != 0 && song.Version != version {
+		s.logger.WithContext(ctx).Warnf("UpdateSong: song with ID %d has version %d, expected %d", songId, song.Version, version)
+		return nil, ErrVersionMismatch
 	}
 
 	// Обновление полей песни
-	updateNonEmptyFields(song, &updatedSong)
+	oldGroup, oldSong := song.GroupName, song.SongName
+	if err := applySongPatch(song, patch); err != nil {
+		s.logger.WithContext(ctx).Warnf("UpdateSong: update rejected: %v", err)
+		return nil, err
+	}
+
+	// Участники из названий пересчитываются при их смене и сохраняются вместе с песней
+	if song.GroupName != oldGroup || song.SongName != oldSong {
+		credits, err := s.credits.SongCredits(ctx, songId)
+		if err != nil {
+			s.logger.WithContext(ctx).Errorf("UpdateSong: failed to get song credits: %v", err)
+			return nil, err
+		}
+		song.Credits = rederiveCredits(credits, oldGroup, oldSong, song.GroupName, song.SongName)
+	}
 	song.UpdatedAt = time.Now()
+	song.UpdatedBy = identity.Subject(ctx)
 
-	if err := s.repo.Update(song); err != nil {
-		s.logger.Errorf("UpdateSong: failed to update song: %v", err)
-		return nil, err
+	if err := s.repo.Update(ctx, song); err != nil {
+		s.logger.WithContext(ctx).Errorf("UpdateSong: failed to update song: %v", err)
+		return nil, songNotFound(err)
 	}
 
-	s.logger.Infof("UpdateSong: song updated with ID: %d", song.ID)
+	s.logger.WithContext(ctx).Infof("UpdateSong: song updated with ID: %d", song.ID)
 	return song, nil
 }
 
 // Удаление песни
-func (s *songService) DeleteSong(id uint) error {
+func (s *songService) DeleteSong(ctx context.Context, id uint, version int) error {
 	if err := s.validateId(id); err != nil {
-		s.logger.Warn("DeleteSong: invalid id")
+		s.logger.WithContext(ctx).Warn("DeleteSong: invalid id")
 		return err
 	}
 
-	s.logger.Infof("DeleteSong: deleting song with ID: %d", id)
-	if err := s.repo.Delete(id); err != nil {
-		s.logger.Errorf("DeleteSong: failed to delete song: %v", err)
-		return err
+	s.logger.WithContext(ctx).Infof("DeleteSong: deleting song with ID: %d", id)
+	if err := s.repo.Delete(ctx, id, version); err != nil {
+		s.logger.WithContext(ctx).Errorf("DeleteSong: failed to delete song: %v", err)
+		return songNotFound(err)
 	}
-	s.logger.Infof("DeleteSong: song deleted with ID: %d", id)
+	s.logger.WithContext(ctx).Infof("DeleteSong: song deleted with ID: %d", id)
 	return nil
 }
 
 // Получение песен с фильтрацией и пагинацией
-func (s *songService) GetSongsWithFiltersAndPagination(filters map[string]interface{}, page int, pageSize int) ([]models.Song, error) {
+func (s *songService) GetSongsWithFiltersAndPagination(ctx context.Context, filters map[string]interface{}, fields []string, page int, pageSize int) ([]models.Song, error) {
 	if page <= 0 || pageSize <= 0 {
-		s.logger.Warn("GetSongsWithFiltersAndPagination: page and pageSize must be greater than zero")
+		s.logger.WithContext(ctx).Warn("GetSongsWithFiltersAndPagination: page and pageSize must be greater than zero")
 		return nil, fmt.Errorf("page and pageSize must be greater than zero")
 	}
 
-	s.logger.Infof("GetSongsWithFiltersAndPagination: fetching songs with filters %v, page: %d, pageSize: %d", filters, page, pageSize)
+	s.logger.WithContext(ctx).Infof("GetSongsWithFiltersAndPagination: fetching songs with filters %v, page: %d, pageSize: %d", logging.Redact(filters), page, pageSize)
 
-	songs, err := s.repo.GetWithFiltersAndPagination(filters, page, pageSize)
+	songs, err := s.repo.GetWithFiltersAndPagination(ctx, filters, fields, page, pageSize)
 	if err != nil {
-		s.logger.Errorf("GetSongsWithFiltersAndPagination: failed to fetch songs with filters: %v", err)
-		return nil, err
+		s.logger.WithContext(ctx).Errorf("GetSongsWithFiltersAndPagination: failed to fetch songs with filters: %v", err)
+		return nil, songNotFound(err)
 	}
 
 	return songs, nil
 }
 
+// Выгрузка песен по фильтру
+func (s *songService) ExportSongs(ctx context.Context, filters map[string]interface{}, fn func(*models.Song) error) error {
+	s.logger.WithContext(ctx).Infof("ExportSongs: exporting songs with filters %v", logging.Redact(filters))
+
+	if err := s.repo.Export(ctx, filters, fn); err != nil {
+		s.logger.WithContext(ctx).Errorf("ExportSongs: failed to export songs: %v", err)
+		return songNotFound(err)
+	}
+	return nil
+}
+
 // Получение текста песни с пагинацией по куплетам
-func (s *songService) GetSongVersesWithPagination(songId uint, page int, pageSize int) ([]string, error) {
+func (s *songService) GetSongVersesWithPagination(ctx context.Context, songId uint, page int, pageSize int) ([]string, error) {
 	if page <= 0 || pageSize <= 0 {
-		s.logger.Warn("GetSongsWithFiltersAndPagination : page and pageSize must be greater than zero")
+		s.logger.WithContext(ctx).Warn("GetSongsWithFiltersAndPagination : page and pageSize must be greater than zero")
 		return nil, fmt.Errorf("page and pageSize must be greater than zero")
 	}
 
 	if err := s.validateId(songId); err != nil {
-		s.logger.Warn("GetSongVersesWithPagination: invalid songId")
+		s.logger.WithContext(ctx).Warn("GetSongVersesWithPagination: invalid songId")
 		return nil, err
 	}
 
-	s.logger.Infof("GetSongVersesWithPagination: fetching verses for song ID: %d", songId)
-	verses, err := s.repo.GetVersesWithPagination(songId, page, pageSize)
+	s.logger.WithContext(ctx).Infof("GetSongVersesWithPagination: fetching verses for song ID: %d", songId)
+	verses, err := s.repo.GetVersesWithPagination(ctx, songId, page, pageSize)
 	if err != nil {
-		s.logger.Errorf("GetSongVersesWithPagination: failed to fetch verses: %v", err)
-		return nil, err
+		s.logger.WithContext(ctx).Errorf("GetSongVersesWithPagination: failed to fetch verses: %v", err)
+		return nil, songNotFound(err)
 	}
-	s.logger.Infof("GetSongVersesWithPagination: successfully fetched %d verses for song ID: %d", len(verses), songId)
+	s.logger.WithContext(ctx).Infof("GetSongVersesWithPagination: successfully fetched %d verses for song ID: %d", len(verses), songId)
 	return verses, nil
 }
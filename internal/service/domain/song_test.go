@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/ananikitina/song_lib/config"
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+func TestAddSongTracesSongInfo(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(models.SongDetail{ReleaseDate: "16.07.2006", Text: "Ooh baby"})
+	}))
+	defer api.Close()
+
+	cfg := &config.Config{
+		ExternalApi:         api.URL,
+		ValidateReleaseDate: models.ValidationActionFlag,
+		ValidateText:        models.ValidationActionFlag,
+		ValidateLink:        models.ValidationActionFlag,
+	}
+	songService := NewSongService(&memorySongs{}, nil, logger, cfg, api.Client())
+
+	if _, err := songService.AddSong(context.Background(), "Muse", "Supermassive Black Hole"); err != nil {
+		t.Fatalf("AddSong() error = %v", err)
+	}
+
+	var names []string
+	for _, span := range recorder.Ended() {
+		names = append(names, span.Name())
+	}
+	if len(names) != 1 || names[0] != "SongService.GetSongInfo" {
+		t.Errorf("AddSong() ended spans %q, want SongService.GetSongInfo", names)
+	}
+}
@@ -0,0 +1,138 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/repository"
+	"github.com/ananikitina/song_lib/internal/service"
+)
+
+var (
+	ErrInvalidTag  = errors.New("invalid tag")
+	ErrTagNotFound = errors.New("song has no such tag")
+)
+
+type tagService struct {
+	repo     repository.TagRepository
+	songRepo repository.SongRepository
+	logger   *logrus.Logger
+}
+
+func NewTagService(repo repository.TagRepository, songRepo repository.SongRepository, logger *logrus.Logger) service.TagService {
+	return &tagService{
+		repo:     repo,
+		songRepo: songRepo,
+		logger:   logger,
+	}
+}
+
+// Нормализованные теги без повторов
+func normalizeTags(tags []string) ([]string, error) {
+	names := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		name := models.NormalizeTag(tag)
+		if name == "" {
+			return nil, fmt.Errorf("%w: tag must not be empty", ErrInvalidTag)
+		}
+		if utf8.RuneCountInString(name) > models.MaxTagLength {
+			return nil, fmt.Errorf("%w: tag %q is longer than %d characters", ErrInvalidTag, name, models.MaxTagLength)
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// Проверка, что песня есть у арендатора
+func checkSongExists(ctx context.Context, repo repository.SongRepository, songId uint) error {
+	if songId == 0 {
+		return ErrInvalidID
+	}
+	if _, err := repo.GetById(ctx, songId); err != nil {
+		return songNotFound(err)
+	}
+	return nil
+}
+
+// Получение тегов с числом песен
+func (s *tagService) ListTags(ctx context.Context) ([]models.TagCount, error) {
+	tags, err := s.repo.List(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Errorf("ListTags: failed to fetch tags: %v", err)
+		return nil, err
+	}
+	return tags, nil
+}
+
+// Получение тегов песни
+func (s *tagService) GetSongTags(ctx context.Context, songId uint) ([]string, error) {
+	if err := checkSongExists(ctx, s.songRepo, songId); err != nil {
+		s.logger.WithContext(ctx).Warnf("GetSongTags: %v", err)
+		return nil, err
+	}
+	return s.repo.SongTags(ctx, songId)
+}
+
+// Добавление тегов песне
+func (s *tagService) AddSongTags(ctx context.Context, songId uint, tags []string) ([]string, error) {
+	names, err := normalizeTags(tags)
+	if err == nil {
+		err = checkSongExists(ctx, s.songRepo, songId)
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).Warnf("AddSongTags: %v", err)
+		return nil, err
+	}
+
+	if err := s.repo.AddSongTags(ctx, songId, names); err != nil {
+		s.logger.WithContext(ctx).Errorf("AddSongTags: failed to tag song with ID %d: %v", songId, err)
+		return nil, songNotFound(err)
+	}
+	return s.repo.SongTags(ctx, songId)
+}
+
+// Замена тегов песни; пустой список снимает все теги
+func (s *tagService) SetSongTags(ctx context.Context, songId uint, tags []string) ([]string, error) {
+	names, err := normalizeTags(tags)
+	if err == nil {
+		err = checkSongExists(ctx, s.songRepo, songId)
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).Warnf("SetSongTags: %v", err)
+		return nil, err
+	}
+
+	if err := s.repo.SetSongTags(ctx, songId, names); err != nil {
+		s.logger.WithContext(ctx).Errorf("SetSongTags: failed to set tags of song with ID %d: %v", songId, err)
+		return nil, songNotFound(err)
+	}
+	return s.repo.SongTags(ctx, songId)
+}
+
+// Снятие тега с песни
+func (s *tagService) RemoveSongTag(ctx context.Context, songId uint, tag string) ([]string, error) {
+	if err := checkSongExists(ctx, s.songRepo, songId); err != nil {
+		s.logger.WithContext(ctx).Warnf("RemoveSongTag: %v", err)
+		return nil, err
+	}
+
+	err := s.repo.RemoveSongTag(ctx, songId, models.NormalizeTag(tag))
+	if errors.Is(err, repository.ErrNotFound) {
+		s.logger.WithContext(ctx).Warnf("RemoveSongTag: %v", err)
+		return nil, fmt.Errorf("%w: %q", ErrTagNotFound, models.NormalizeTag(tag))
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).Errorf("RemoveSongTag: failed to remove tag from song with ID %d: %v", songId, err)
+		return nil, err
+	}
+	return s.repo.SongTags(ctx, songId)
+}
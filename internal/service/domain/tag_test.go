@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		tags    []string
+		want    []string
+		wantErr error
+	}{
+		{name: "lower case", tags: []string{"Ballad", "80S"}, want: []string{"ballad", "80s"}},
+		{name: "surrounding whitespace", tags: []string{"  ballad\t"}, want: []string{"ballad"}},
+		{name: "inner whitespace collapsed", tags: []string{"hard \t  rock"}, want: []string{"hard rock"}},
+		{name: "composed unicode", tags: []string{"Cafe\u0301"}, want: []string{"caf\u00e9"}},
+		{name: "repeats after normalization", tags: []string{"80s", " 80S ", "ballad", "80s"}, want: []string{"80s", "ballad"}},
+		{name: "same spelling after composition", tags: []string{"caf\u00e9", "cafe\u0301"}, want: []string{"caf\u00e9"}},
+		{name: "no tags", tags: nil, want: []string{}},
+		{name: "empty", tags: []string{"ballad", ""}, wantErr: ErrInvalidTag},
+		{name: "whitespace only", tags: []string{" \t\n"}, wantErr: ErrInvalidTag},
+		{name: "longest", tags: []string{strings.Repeat("я", models.MaxTagLength)}, want: []string{strings.Repeat("я", models.MaxTagLength)}},
+		{name: "too long", tags: []string{strings.Repeat("я", models.MaxTagLength+1)}, wantErr: ErrInvalidTag},
+		{name: "long before normalization", tags: []string{"  " + strings.Repeat("a", models.MaxTagLength) + "  "}, want: []string{strings.Repeat("a", models.MaxTagLength)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeTags(tt.tags)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("normalizeTags() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && !slices.Equal(got, tt.want) {
+				t.Errorf("normalizeTags() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/repository"
+	"github.com/ananikitina/song_lib/internal/service"
+)
+
+var (
+	ErrTenantNotFound    = errors.New("tenant not found")
+	ErrInvalidTenant     = errors.New("invalid tenant settings")
+	ErrSongQuotaExceeded = errors.New("song quota exceeded")
+	ErrTenantExists      = errors.New("tenant already exists")
+)
+
+// Время, в течение которого изменения настроек арендатора могут быть не видны другим экземплярам
+const tenantCacheTTL = time.Minute
+
+// Идентификатор арендатора: строчные латинские буквы, цифры и дефис
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+type cachedTenant struct {
+	tenant  *models.Tenant
+	expires time.Time
+}
+
+type tenantService struct {
+	repo   repository.TenantRepository
+	logger *logrus.Logger
+
+	mu    sync.Mutex
+	cache map[string]cachedTenant
+}
+
+func NewTenantService(repo repository.TenantRepository, logger *logrus.Logger) service.TenantService {
+	return &tenantService{
+		repo:   repo,
+		logger: logger,
+		cache:  make(map[string]cachedTenant),
+	}
+}
+
+func validateTenant(t models.Tenant) error {
+	var problems []string
+	if !tenantIDPattern.MatchString(t.ID) {
+		problems = append(problems, "id must consist of lowercase letters, digits and dashes")
+	}
+	if strings.TrimSpace(t.Name) == "" {
+		problems = append(problems, "name is required")
+	}
+	if t.ExternalApi != "" {
+		if u, err := url.Parse(t.ExternalApi); err != nil || u.Scheme == "" || u.Host == "" {
+			problems = append(problems, "externalApi must be an absolute URL")
+		}
+	}
+	if t.MaxSongs != nil && *t.MaxSongs < 0 {
+		problems = append(problems, "maxSongs must not be negative")
+	}
+	for _, rate := range []*float64{t.RateLimitReadRate, t.RateLimitWriteRate} {
+		if rate != nil && *rate <= 0 {
+			problems = append(problems, "rate limits must be positive")
+		}
+	}
+	for _, burst := range []*int{t.RateLimitReadBurst, t.RateLimitWriteBurst} {
+		if burst != nil && *burst < 1 {
+			problems = append(problems, "rate limit bursts must be at least 1")
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidTenant, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Создание арендатора
+func (s *tenantService) CreateTenant(ctx context.Context, t models.Tenant) (*models.Tenant, error) {
+	if err := validateTenant(t); err != nil {
+		s.logger.WithContext(ctx).Warnf("CreateTenant: %v", err)
+		return nil, err
+	}
+	if _, err := s.repo.GetById(ctx, t.ID); err == nil {
+		return nil, ErrTenantExists
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	if err := s.repo.Add(ctx, &t); err != nil {
+		s.logger.WithContext(ctx).Errorf("CreateTenant: failed to save tenant: %v", err)
+		return nil, err
+	}
+
+	s.logger.WithContext(ctx).Infof("CreateTenant: tenant %s created", t.ID)
+	return &t, nil
+}
+
+// Получение всех арендаторов
+func (s *tenantService) ListTenants(ctx context.Context) ([]models.Tenant, error) {
+	tenants, err := s.repo.GetAll(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Errorf("ListTenants: failed to fetch tenants: %v", err)
+		return nil, err
+	}
+	return tenants, nil
+}
+
+// Замена настроек арендатора
+func (s *tenantService) UpdateTenant(ctx context.Context, id string, t models.Tenant) (*models.Tenant, error) {
+	t.ID = id
+	if err := validateTenant(t); err != nil {
+		s.logger.WithContext(ctx).Warnf("UpdateTenant: %v", err)
+		return nil, err
+	}
+
+	t.UpdatedAt = time.Now()
+	err := s.repo.Update(ctx, &t)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrTenantNotFound
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).Errorf("UpdateTenant: failed to save tenant %s: %v", id, err)
+		return nil, err
+	}
+
+	s.mu.Lock()
+	delete(s.cache, id)
+	s.mu.Unlock()
+
+	s.logger.WithContext(ctx).Infof("UpdateTenant: tenant %s updated", id)
+	return s.repo.GetById(ctx, id)
+}
+
+// Получение арендатора из кэша или БД
+func (s *tenantService) GetTenant(ctx context.Context, id string) (*models.Tenant, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	cached, ok := s.cache[id]
+	s.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.tenant, nil
+	}
+
+	t, err := s.repo.GetById(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrTenantNotFound
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).Errorf("GetTenant: failed to get tenant %s: %v", id, err)
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[id] = cachedTenant{tenant: t, expires: now.Add(tenantCacheTTL)}
+	s.mu.Unlock()
+	return t, nil
+}
@@ -0,0 +1,13 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+type ImportService interface {
+	// Импорт строк каталога с обновлением песен, совпадающих по группе и названию;
+	// onResult вызывается последовательно для каждой строки
+	ImportSongs(ctx context.Context, rows []models.ImportRow, opts models.ImportOptions, onResult func(models.ImportResult)) error
+}
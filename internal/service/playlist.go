@@ -0,0 +1,21 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+// Плейлисты из песен библиотеки; при ненулевой version изменения применяются только к этой версии плейлиста.
+// Изменения песен плейлиста возвращают плейлист с песнями.
+type PlaylistService interface {
+	CreatePlaylist(ctx context.Context, req models.PlaylistRequest) (*models.Playlist, error)
+	ListPlaylists(ctx context.Context, page int, pageSize int) ([]models.Playlist, error)
+	GetPlaylist(ctx context.Context, id uint) (*models.Playlist, error)
+	UpdatePlaylist(ctx context.Context, id uint, req models.PlaylistRequest, version int) (*models.Playlist, error)
+	DeletePlaylist(ctx context.Context, id uint, version int) error
+	AddItem(ctx context.Context, id uint, req models.AddPlaylistItemRequest, version int) (*models.Playlist, error)
+	RemoveItem(ctx context.Context, id uint, itemId uint, version int) (*models.Playlist, error)
+	MoveItem(ctx context.Context, id uint, itemId uint, position int, version int) (*models.Playlist, error)
+	ReorderItems(ctx context.Context, id uint, itemIds []uint, version int) (*models.Playlist, error)
+}
@@ -9,10 +9,13 @@ import (
 type SongService interface {
 	AddSong(ctx context.Context, groupName, songName string) (*models.Song, error)
 	GetSongInfo(ctx context.Context, groupName, songName string) (*models.SongDetail, error)
-	GetSongById(id uint) (*models.Song, error)
-	GetAllSongs() ([]models.Song, error)
-	UpdateSong(songId uint, updatedSong models.Song) (*models.Song, error)
-	DeleteSong(id uint) error
-	GetSongsWithFiltersAndPagination(filters map[string]interface{}, page int, pageSize int) ([]models.Song, error)
-	GetSongVersesWithPagination(songId uint, page int, pageSize int) ([]string, error)
+	GetSongById(ctx context.Context, id uint) (*models.Song, error)
+	GetAllSongs(ctx context.Context) ([]models.Song, error)
+	// Изменение и удаление песни; при ненулевой version только этой версии песни
+	UpdateSong(ctx context.Context, songId uint, patch models.SongPatch, version int) (*models.Song, error)
+	DeleteSong(ctx context.Context, id uint, version int) error
+	GetSongsWithFiltersAndPagination(ctx context.Context, filters map[string]interface{}, fields []string, page int, pageSize int) ([]models.Song, error)
+	// Выгрузка песен по фильтру без загрузки всей выборки в память
+	ExportSongs(ctx context.Context, filters map[string]interface{}, fn func(*models.Song) error) error
+	GetSongVersesWithPagination(ctx context.Context, songId uint, page int, pageSize int) ([]string, error)
 }
@@ -0,0 +1,16 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+// Теги песен; изменения тегов песни возвращают ее теги по алфавиту
+type TagService interface {
+	ListTags(ctx context.Context) ([]models.TagCount, error)
+	GetSongTags(ctx context.Context, songId uint) ([]string, error)
+	AddSongTags(ctx context.Context, songId uint, tags []string) ([]string, error)
+	SetSongTags(ctx context.Context, songId uint, tags []string) ([]string, error)
+	RemoveSongTag(ctx context.Context, songId uint, tag string) ([]string, error)
+}
@@ -0,0 +1,15 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+type TenantService interface {
+	CreateTenant(ctx context.Context, tenant models.Tenant) (*models.Tenant, error)
+	ListTenants(ctx context.Context) ([]models.Tenant, error)
+	UpdateTenant(ctx context.Context, id string, tenant models.Tenant) (*models.Tenant, error)
+	// Получение арендатора с кэшированием; используется при каждом запросе
+	GetTenant(ctx context.Context, id string) (*models.Tenant, error)
+}
@@ -0,0 +1,37 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ananikitina/song_lib/internal/models"
+)
+
+// Арендатор, которому принадлежат данные однотенантной установки
+const Default = "default"
+
+// Арендатор не определен: запросы к данным арендаторов без него не выполняются
+var ErrMissing = errors.New("tenant is not set")
+
+// Заголовок для выбора арендатора анонимными клиентами и администраторами платформы
+const Header = "X-Tenant-ID"
+
+type tenantKey struct{}
+
+func WithTenant(ctx context.Context, tenant *models.Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// Арендатор из контекста запроса; nil, если не определен
+func FromContext(ctx context.Context) *models.Tenant {
+	tenant, _ := ctx.Value(tenantKey{}).(*models.Tenant)
+	return tenant
+}
+
+// Идентификатор арендатора из контекста; false, если арендатор не определен
+func ID(ctx context.Context) (string, bool) {
+	if tenant := FromContext(ctx); tenant != nil {
+		return tenant.ID, true
+	}
+	return "", false
+}
@@ -0,0 +1,139 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/repository"
+	"github.com/ananikitina/song_lib/internal/tenant"
+)
+
+// Репозиторий, создающий спан на каждый вызов метода
+type songRepository struct {
+	next repository.SongRepository
+}
+
+func InstrumentSongRepository(next repository.SongRepository) repository.SongRepository {
+	return &songRepository{next: next}
+}
+
+// Запуск спана с идентификатором арендатора; завершать через End
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if id, ok := tenant.ID(ctx); ok {
+		attrs = append(attrs, attribute.String("tenant.id", id))
+	}
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// Завершение спана с записью ошибки
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (r *songRepository) Add(ctx context.Context, song *models.Song) error {
+	ctx, span := Start(ctx, "SongRepository.Add")
+	err := r.next.Add(ctx, song)
+	End(span, err)
+	return err
+}
+
+func (r *songRepository) GetAll(ctx context.Context) ([]models.Song, error) {
+	ctx, span := Start(ctx, "SongRepository.GetAll")
+	songs, err := r.next.GetAll(ctx)
+	End(span, err)
+	return songs, err
+}
+
+func (r *songRepository) GetById(ctx context.Context, id uint) (*models.Song, error) {
+	ctx, span := Start(ctx, "SongRepository.GetById", attribute.Int("song.id", int(id)))
+	song, err := r.next.GetById(ctx, id)
+	End(span, err)
+	return song, err
+}
+
+func (r *songRepository) GetByName(ctx context.Context, groupName, songName string) (*models.Song, error) {
+	ctx, span := Start(ctx, "SongRepository.GetByName")
+	song, err := r.next.GetByName(ctx, groupName, songName)
+	End(span, err)
+	return song, err
+}
+
+func (r *songRepository) Count(ctx context.Context) (int64, error) {
+	ctx, span := Start(ctx, "SongRepository.Count")
+	count, err := r.next.Count(ctx)
+	End(span, err)
+	return count, err
+}
+
+func (r *songRepository) GetWithFiltersAndPagination(ctx context.Context, filters map[string]interface{}, fields []string, page int, pageSize int) ([]models.Song, error) {
+	ctx, span := Start(ctx, "SongRepository.GetWithFiltersAndPagination",
+		attribute.Int("page", page), attribute.Int("page_size", pageSize))
+	songs, err := r.next.GetWithFiltersAndPagination(ctx, filters, fields, page, pageSize)
+	End(span, err)
+	return songs, err
+}
+
+func (r *songRepository) Update(ctx context.Context, song *models.Song) error {
+	ctx, span := Start(ctx, "SongRepository.Update", attribute.Int("song.id", int(song.ID)))
+	err := r.next.Update(ctx, song)
+	End(span, err)
+	return err
+}
+
+func (r *songRepository) Delete(ctx context.Context, id uint, version int) error {
+	ctx, span := Start(ctx, "SongRepository.Delete", attribute.Int("song.id", int(id)))
+	err := r.next.Delete(ctx, id, version)
+	End(span, err)
+	return err
+}
+
+func (r *songRepository) GetVersesWithPagination(ctx context.Context, id uint, page int, pageSize int) ([]string, error) {
+	ctx, span := Start(ctx, "SongRepository.GetVersesWithPagination",
+		attribute.Int("song.id", int(id)), attribute.Int("page", page), attribute.Int("page_size", pageSize))
+	verses, err := r.next.GetVersesWithPagination(ctx, id, page, pageSize)
+	End(span, err)
+	return verses, err
+}
+
+func (r *songRepository) Export(ctx context.Context, filters map[string]interface{}, fn func(*models.Song) error) error {
+	ctx, span := Start(ctx, "SongRepository.Export")
+	var count int
+	err := r.next.Export(ctx, filters, func(song *models.Song) error {
+		count++
+		return fn(song)
+	})
+	span.SetAttributes(attribute.Int("songs.exported", count))
+	End(span, err)
+	return err
+}
+
+func (r *songRepository) CountMatching(ctx context.Context, match models.SongMatch) (int64, error) {
+	ctx, span := Start(ctx, "SongRepository.CountMatching", attribute.Int("songs.ids", len(match.IDs)))
+	count, err := r.next.CountMatching(ctx, match)
+	End(span, err)
+	return count, err
+}
+
+func (r *songRepository) UpdateMatching(ctx context.Context, match models.SongMatch, changes map[string]interface{}, maxRows int64, recredit repository.CreditsFunc) (int64, error) {
+	ctx, span := Start(ctx, "SongRepository.UpdateMatching", attribute.Int("songs.ids", len(match.IDs)))
+	affected, err := r.next.UpdateMatching(ctx, match, changes, maxRows, recredit)
+	span.SetAttributes(attribute.Int64("songs.affected", affected))
+	End(span, err)
+	return affected, err
+}
+
+func (r *songRepository) DeleteMatching(ctx context.Context, match models.SongMatch, maxRows int64) (int64, error) {
+	ctx, span := Start(ctx, "SongRepository.DeleteMatching", attribute.Int("songs.ids", len(match.IDs)))
+	affected, err := r.next.DeleteMatching(ctx, match, maxRows)
+	span.SetAttributes(attribute.Int64("songs.affected", affected))
+	End(span, err)
+	return affected, err
+}
@@ -0,0 +1,86 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/ananikitina/song_lib/internal/models"
+	"github.com/ananikitina/song_lib/internal/service"
+)
+
+// Сервис, создающий спан на каждый вызов метода
+type songService struct {
+	next service.SongService
+}
+
+func InstrumentSongService(next service.SongService) service.SongService {
+	return &songService{next: next}
+}
+
+func (s *songService) AddSong(ctx context.Context, groupName, songName string) (*models.Song, error) {
+	ctx, span := Start(ctx, "SongService.AddSong",
+		attribute.String("song.group", groupName), attribute.String("song.name", songName))
+	song, err := s.next.AddSong(ctx, groupName, songName)
+	End(span, err)
+	return song, err
+}
+
+func (s *songService) GetSongInfo(ctx context.Context, groupName, songName string) (*models.SongDetail, error) {
+	ctx, span := Start(ctx, "SongService.GetSongInfo",
+		attribute.String("song.group", groupName), attribute.String("song.name", songName))
+	detail, err := s.next.GetSongInfo(ctx, groupName, songName)
+	End(span, err)
+	return detail, err
+}
+
+func (s *songService) GetSongById(ctx context.Context, id uint) (*models.Song, error) {
+	ctx, span := Start(ctx, "SongService.GetSongById", attribute.Int("song.id", int(id)))
+	song, err := s.next.GetSongById(ctx, id)
+	End(span, err)
+	return song, err
+}
+
+func (s *songService) GetAllSongs(ctx context.Context) ([]models.Song, error) {
+	ctx, span := Start(ctx, "SongService.GetAllSongs")
+	songs, err := s.next.GetAllSongs(ctx)
+	End(span, err)
+	return songs, err
+}
+
+func (s *songService) UpdateSong(ctx context.Context, songId uint, patch models.SongPatch, version int) (*models.Song, error) {
+	ctx, span := Start(ctx, "SongService.UpdateSong", attribute.Int("song.id", int(songId)))
+	song, err := s.next.UpdateSong(ctx, songId, patch, version)
+	End(span, err)
+	return song, err
+}
+
+func (s *songService) DeleteSong(ctx context.Context, id uint, version int) error {
+	ctx, span := Start(ctx, "SongService.DeleteSong", attribute.Int("song.id", int(id)))
+	err := s.next.DeleteSong(ctx, id, version)
+	End(span, err)
+	return err
+}
+
+func (s *songService) GetSongsWithFiltersAndPagination(ctx context.Context, filters map[string]interface{}, fields []string, page int, pageSize int) ([]models.Song, error) {
+	ctx, span := Start(ctx, "SongService.GetSongsWithFiltersAndPagination",
+		attribute.Int("page", page), attribute.Int("page_size", pageSize))
+	songs, err := s.next.GetSongsWithFiltersAndPagination(ctx, filters, fields, page, pageSize)
+	End(span, err)
+	return songs, err
+}
+
+func (s *songService) ExportSongs(ctx context.Context, filters map[string]interface{}, fn func(*models.Song) error) error {
+	ctx, span := Start(ctx, "SongService.ExportSongs")
+	err := s.next.ExportSongs(ctx, filters, fn)
+	End(span, err)
+	return err
+}
+
+func (s *songService) GetSongVersesWithPagination(ctx context.Context, songId uint, page int, pageSize int) ([]string, error) {
+	ctx, span := Start(ctx, "SongService.GetSongVersesWithPagination",
+		attribute.Int("song.id", int(songId)), attribute.Int("page", page), attribute.Int("page_size", pageSize))
+	verses, err := s.next.GetSongVersesWithPagination(ctx, songId, page, pageSize)
+	End(span, err)
+	return verses, err
+}
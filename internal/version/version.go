@@ -0,0 +1,54 @@
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Значения задаются при сборке:
+//
+//	go build -ldflags "-X github.com/ananikitina/song_lib/internal/version.Version=v1.2.3 \
+//	  -X github.com/ananikitina/song_lib/internal/version.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/ananikitina/song_lib/internal/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildDate = ""
+)
+
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"buildDate,omitempty"`
+	GoVersion string `json:"goVersion"`
+	Modified  bool   `json:"modified,omitempty"`
+}
+
+// Информация о сборке; недостающие значения берутся из данных VCS, встроенных компилятором
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = setting.Value
+				}
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	return info
+}
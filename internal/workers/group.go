@@ -0,0 +1,54 @@
+package workers
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Группа фоновых задач, которые завершаются вместе с приложением
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	logger *logrus.Logger
+}
+
+func NewGroup(logger *logrus.Logger) *Group {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Group{
+		ctx:    ctx,
+		cancel: cancel,
+		logger: logger,
+	}
+}
+
+// Запуск фоновой задачи; контекст отменяется при остановке группы
+func (g *Group) Go(name string, fn func(ctx context.Context)) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		g.logger.Debugf("Go: worker %s started", name)
+		fn(g.ctx)
+		g.logger.Debugf("Go: worker %s finished", name)
+	}()
+}
+
+// Остановка группы: отмена контекста задач и ожидание их завершения до дедлайна
+func (g *Group) Shutdown(ctx context.Context) error {
+	g.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_needs_review;
+
+ALTER TABLE songs
+    DROP COLUMN IF EXISTS validation_report,
+    DROP COLUMN IF EXISTS needs_review;
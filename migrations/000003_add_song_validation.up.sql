@@ -0,0 +1,6 @@
+ALTER TABLE songs
+    ADD COLUMN needs_review BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN validation_report JSONB;
+
+-- Поиск песен, требующих проверки
+CREATE INDEX idx_needs_review ON songs (needs_review) WHERE needs_review;
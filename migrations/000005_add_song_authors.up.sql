@@ -0,0 +1,3 @@
+ALTER TABLE songs
+    ADD COLUMN created_by VARCHAR(255),
+    ADD COLUMN updated_by VARCHAR(255);
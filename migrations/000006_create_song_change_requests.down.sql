@@ -0,0 +1 @@
+DROP TABLE IF EXISTS song_change_requests;
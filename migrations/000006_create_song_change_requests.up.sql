@@ -0,0 +1,17 @@
+CREATE TABLE song_change_requests (
+    id SERIAL PRIMARY KEY,
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    changes JSONB NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    proposed_by VARCHAR(255),
+    reviewed_by VARCHAR(255),
+    review_comment TEXT,
+    reviewed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Очередь запросов на рассмотрение
+CREATE INDEX idx_change_requests_status ON song_change_requests (status, created_at);
+
+CREATE INDEX idx_change_requests_song_id ON song_change_requests (song_id);
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_change_requests_tenant_status;
+CREATE INDEX idx_change_requests_status ON song_change_requests (status, created_at);
+
+DROP INDEX IF EXISTS idx_songs_tenant_group_and_song;
+
+ALTER TABLE song_change_requests DROP COLUMN tenant_id;
+ALTER TABLE api_keys DROP COLUMN tenant_id;
+ALTER TABLE songs DROP COLUMN tenant_id;
+
+DROP TABLE IF EXISTS tenants;
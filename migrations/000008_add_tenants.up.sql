@@ -0,0 +1,25 @@
+CREATE TABLE tenants (
+    id VARCHAR(64) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    external_api TEXT,
+    max_songs INTEGER,
+    rate_limit_read_rate DOUBLE PRECISION,
+    rate_limit_read_burst INTEGER,
+    rate_limit_write_rate DOUBLE PRECISION,
+    rate_limit_write_burst INTEGER,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Существующие данные принадлежат арендатору по умолчанию
+INSERT INTO tenants (id, name) VALUES ('default', 'Default');
+
+ALTER TABLE songs ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES tenants (id);
+ALTER TABLE api_keys ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES tenants (id);
+ALTER TABLE song_change_requests ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES tenants (id);
+
+-- Поиск песен в пределах арендатора
+CREATE INDEX idx_songs_tenant_group_and_song ON songs (tenant_id, group_name, song_name);
+
+DROP INDEX idx_change_requests_status;
+CREATE INDEX idx_change_requests_tenant_status ON song_change_requests (tenant_id, status, created_at);
@@ -0,0 +1,2 @@
+ALTER TABLE songs
+    ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
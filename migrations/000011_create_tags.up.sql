@@ -0,0 +1,17 @@
+CREATE TABLE tags (
+    id SERIAL PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES tenants (id),
+    name VARCHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    CONSTRAINT uq_tags_tenant_name UNIQUE (tenant_id, name)
+);
+
+CREATE TABLE song_tags (
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (song_id, tag_id)
+);
+
+-- Для фильтра песен по тегу
+CREATE INDEX idx_song_tags_tag_id ON song_tags (tag_id, song_id);
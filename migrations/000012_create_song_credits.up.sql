@@ -0,0 +1,19 @@
+-- Участники песни по ролям; position - порядок участника в своей роли, начиная с 1
+CREATE TABLE song_credits (
+    id SERIAL PRIMARY KEY,
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    role VARCHAR(32) NOT NULL,
+    position INTEGER NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    CONSTRAINT uq_song_credits_role_name UNIQUE (song_id, role, name)
+);
+
+-- Для фильтра песен по участнику без учета регистра
+CREATE INDEX idx_song_credits_name ON song_credits (LOWER(name), role);
+
+-- Исполнитель уже добавленных песен - группа без приглашенных артистов
+INSERT INTO song_credits (song_id, name, role, position)
+SELECT id, TRIM(regexp_replace(group_name, '\s+(featuring\s+|feat\.\s*|feat\s+|ft\.\s*|ft\s+).*$', '', 'i')), 'artist', 1
+FROM songs
+WHERE TRIM(group_name) <> '';
@@ -0,0 +1,2 @@
+ALTER TABLE song_change_requests
+    DROP COLUMN IF EXISTS song_version;
@@ -0,0 +1,11 @@
+ALTER TABLE song_change_requests
+    ADD COLUMN song_version INTEGER;
+
+-- Версия, к которой были предложены уже созданные запросы, неизвестна; берется текущая версия песни
+UPDATE song_change_requests
+SET song_version = songs.version
+FROM songs
+WHERE songs.id = song_change_requests.song_id;
+
+ALTER TABLE song_change_requests
+    ALTER COLUMN song_version SET NOT NULL;
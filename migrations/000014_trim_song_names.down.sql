@@ -0,0 +1,2 @@
+-- Удаленные пробелы не восстанавливаются
+SELECT 1;
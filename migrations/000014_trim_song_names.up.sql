@@ -0,0 +1,6 @@
+-- Группа и название хранятся без пробелов по краям, как их ищет импорт
+UPDATE songs
+SET group_name = BTRIM(group_name),
+    song_name = BTRIM(song_name)
+WHERE group_name <> BTRIM(group_name)
+   OR song_name <> BTRIM(song_name);
@@ -0,0 +1,3 @@
+-- Нормализованные названия и удаленные повторы не восстанавливаются
+CREATE INDEX idx_songs_tenant_group_and_song ON songs (tenant_id, group_name, song_name);
+DROP INDEX IF EXISTS uq_songs_tenant_group_and_song;
@@ -0,0 +1,48 @@
+-- Группа и название нормализуются так же, как в приложении: частые HTML-сущности, Unicode NFC
+-- и пробелы по краям. Прочие сущности (например, &eacute; или &#233;) не раскрываются.
+UPDATE songs
+SET group_name = BTRIM(normalize(replace(replace(replace(replace(replace(replace(group_name,
+        '&lt;', '<'), '&gt;', '>'), '&quot;', '"'), '&#39;', ''''), '&apos;', ''''), '&amp;', '&'), NFC)),
+    song_name = BTRIM(normalize(replace(replace(replace(replace(replace(replace(song_name,
+        '&lt;', '<'), '&gt;', '>'), '&quot;', '"'), '&#39;', ''''), '&apos;', ''''), '&amp;', '&'), NFC));
+
+-- Повторы песни сводятся к песне с наименьшим ID, которую до сих пор находил импорт
+CREATE TEMPORARY TABLE song_duplicates ON COMMIT DROP AS
+SELECT id, keep_id
+FROM (
+    SELECT id, MIN(id) OVER (PARTITION BY tenant_id, group_name, song_name) AS keep_id
+    FROM songs
+) ranked
+WHERE id <> keep_id;
+
+UPDATE playlists
+SET version = version + 1, updated_at = NOW()
+WHERE id IN (
+    SELECT playlist_items.playlist_id
+    FROM playlist_items
+    JOIN song_duplicates ON song_duplicates.id = playlist_items.song_id
+);
+
+UPDATE playlist_items
+SET song_id = song_duplicates.keep_id
+FROM song_duplicates
+WHERE song_duplicates.id = playlist_items.song_id;
+
+UPDATE song_change_requests
+SET song_id = song_duplicates.keep_id
+FROM song_duplicates
+WHERE song_duplicates.id = song_change_requests.song_id;
+
+INSERT INTO song_tags (song_id, tag_id)
+SELECT song_duplicates.keep_id, song_tags.tag_id
+FROM song_tags
+JOIN song_duplicates ON song_duplicates.id = song_tags.song_id
+ON CONFLICT DO NOTHING;
+
+-- Теги и участники повторов удаляются каскадно
+DELETE FROM songs
+WHERE id IN (SELECT id FROM song_duplicates);
+
+-- Песня одна на группу и название у арендатора; индекс заменяет обычный индекс поиска
+CREATE UNIQUE INDEX uq_songs_tenant_group_and_song ON songs (tenant_id, group_name, song_name);
+DROP INDEX IF EXISTS idx_songs_tenant_group_and_song;
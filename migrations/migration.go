@@ -1,7 +1,12 @@
 package migrations
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
 
 	"github.com/golang-migrate/migrate/v4"
 	migratePg "github.com/golang-migrate/migrate/v4/database/postgres"
@@ -9,6 +14,11 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// Каталог с файлами миграций
+const migrationsDir = "migrations"
+
+var migrationFile = regexp.MustCompile(`^(\d+)_.+\.up\.sql$`)
+
 func RunMigration(dsn string, log *logrus.Logger) error {
 	// Соединение с базой данных
 	dbMigration, err := sql.Open("postgres", dsn)
@@ -30,7 +40,7 @@ func RunMigration(dsn string, log *logrus.Logger) error {
 	}
 
 	m, err := migrate.NewWithDatabaseInstance(
-		"file://migrations",
+		"file://"+migrationsDir,
 		"postgres",
 		driver)
 	if err != nil {
@@ -51,3 +61,40 @@ func RunMigration(dsn string, log *logrus.Logger) error {
 	log.Info("Migrations ran successfully")
 	return nil
 }
+
+// Последняя версия схемы среди файлов миграций
+func LatestVersion() (uint, error) {
+	entries, err := os.ReadDir(migrationsDir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	var latest uint
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid migration file name %s: %w", entry.Name(), err)
+		}
+		if uint(version) > latest {
+			latest = uint(version)
+		}
+	}
+	return latest, nil
+}
+
+// Текущая версия схемы в базе данных
+func CurrentVersion(ctx context.Context, db *sql.DB) (uint, bool, error) {
+	var (
+		version uint
+		dirty   bool
+	)
+	row := db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1")
+	if err := row.Scan(&version, &dirty); err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, dirty, nil
+}